@@ -45,6 +45,21 @@ const (
 	hubKubeConfigSecretMissing            = "HubKubeConfigSecretMissing" // #nosec G101
 	appliedManifestWorkFinalizer          = "cluster.open-cluster-management.io/applied-manifest-work-cleanup"
 	managedResourcesEvictionTimestampAnno = "operator.open-cluster-management.io/managed-resources-eviction-timestamp"
+
+	// addOnLeaseCleanupAnno enables the addon lease cleanup of the registration agent. With "DryRun", the agent only
+	// logs the leases of the deleted addons that would be deleted; with "Enabled", the agent deletes them, and the
+	// access to delete the leases is granted to the agent. The cleanup is disabled with any other value.
+	addOnLeaseCleanupAnno    = "operator.open-cluster-management.io/addon-lease-cleanup"
+	addOnLeaseCleanupDryRun  = "DryRun"
+	addOnLeaseCleanupEnabled = "Enabled"
+
+	// addOnLeaseRBACAnno enables the registration agent to grant the addon agents the access to their leases if it is
+	// "true", and the access to maintain the roles and rolebindings is granted to the agent.
+	addOnLeaseRBACAnno = "operator.open-cluster-management.io/addon-lease-rbac"
+
+	// addOnHeartbeatConfigMapAnno enables the registration agent to read the heartbeat configmaps of the addons if it
+	// is "true", and the access to get the configmaps is granted to the agent.
+	addOnHeartbeatConfigMapAnno = "operator.open-cluster-management.io/addon-heartbeat-configmap"
 )
 
 type klusterletController struct {
@@ -140,6 +155,16 @@ type klusterletConfig struct {
 	WorkFeatureGates         []string
 
 	HubApiServerHostAlias *operatorapiv1.HubApiServerHostAlias
+
+	// AddOnLeaseCleanup is the addon lease cleanup mode of the registration agent, it is read from the annotation
+	// operator.open-cluster-management.io/addon-lease-cleanup of the klusterlet.
+	AddOnLeaseCleanup string
+	// AddOnLeaseRBAC enables the addon lease RBAC of the registration agent, it is read from the annotation
+	// operator.open-cluster-management.io/addon-lease-rbac of the klusterlet.
+	AddOnLeaseRBAC bool
+	// AddOnHeartbeatConfigMap enables the addon heartbeat configmaps of the registration agent, it is read from the
+	// annotation operator.open-cluster-management.io/addon-heartbeat-configmap of the klusterlet.
+	AddOnHeartbeatConfigMap bool
 }
 
 func (n *klusterletController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
@@ -174,6 +199,9 @@ func (n *klusterletController) sync(ctx context.Context, controllerContext facto
 		ExternalManagedKubeConfigWorkSecret:         helpers.ExternalManagedKubeConfigWork,
 		InstallMode:                                 klusterlet.Spec.DeployOption.Mode,
 		HubApiServerHostAlias:                       klusterlet.Spec.HubApiServerHostAlias,
+		AddOnLeaseCleanup:                           getAddOnLeaseCleanupMode(klusterlet),
+		AddOnLeaseRBAC:                              klusterlet.Annotations[addOnLeaseRBACAnno] == "true",
+		AddOnHeartbeatConfigMap:                     klusterlet.Annotations[addOnHeartbeatConfigMapAnno] == "true",
 	}
 
 	managedClusterClients, err := n.managedClusterClientsBuilder.
@@ -294,6 +322,17 @@ func (n *klusterletController) sync(ctx context.Context, controllerContext facto
 	return utilerrors.NewAggregate(errs)
 }
 
+// getAddOnLeaseCleanupMode returns the addon lease cleanup mode of the klusterlet, it is empty if the cleanup is
+// disabled.
+func getAddOnLeaseCleanupMode(klusterlet *operatorapiv1.Klusterlet) string {
+	switch mode := klusterlet.Annotations[addOnLeaseCleanupAnno]; mode {
+	case addOnLeaseCleanupDryRun, addOnLeaseCleanupEnabled:
+		return mode
+	default:
+		return ""
+	}
+}
+
 // TODO also read CABundle from ExternalServerURLs and set into registration deployment
 func getServersFromKlusterlet(klusterlet *operatorapiv1.Klusterlet) string {
 	if klusterlet.Spec.ExternalServerURLs == nil {
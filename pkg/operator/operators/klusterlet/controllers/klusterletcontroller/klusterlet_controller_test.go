@@ -12,6 +12,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	fakeapiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -19,6 +20,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
 	fakekube "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
@@ -870,6 +872,152 @@ func TestSyncWithPullSecret(t *testing.T) {
 	}
 }
 
+func TestSyncWithAddOnLeaseCleanup(t *testing.T) {
+	cases := []struct {
+		name                string
+		mode                string
+		expectedArgs        []string
+		expectedLeaseDelete bool
+	}{
+		{
+			name: "cleanup is disabled by default",
+		},
+		{
+			name:         "cleanup in dry run mode",
+			mode:         addOnLeaseCleanupDryRun,
+			expectedArgs: []string{"--addon-lease-cleanup"},
+		},
+		{
+			name:                "cleanup is enabled",
+			mode:                addOnLeaseCleanupEnabled,
+			expectedArgs:        []string{"--addon-lease-cleanup", "--addon-lease-cleanup-dry-run=false"},
+			expectedLeaseDelete: true,
+		},
+		{
+			name: "unknown cleanup mode",
+			mode: "Unknown",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+			if len(c.mode) != 0 {
+				klusterlet.Annotations = map[string]string{addOnLeaseCleanupAnno: c.mode}
+			}
+			hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+			hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+			controller := newTestController(t, klusterlet, nil, newSecret(helpers.BootstrapHubKubeConfig, "testns"),
+				hubKubeConfigSecret, newNamespace("testns"))
+			if err := controller.controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "klusterlet")); err != nil {
+				t.Errorf("Expected non error when sync, %v", err)
+			}
+
+			var args []string
+			leaseDelete := false
+			for _, action := range controller.kubeClient.Actions() {
+				if action.GetVerb() != "create" {
+					continue
+				}
+				switch object := action.(clienttesting.CreateActionImpl).Object.(type) {
+				case *appsv1.Deployment:
+					if object.Name == "klusterlet-registration-agent" {
+						args = object.Spec.Template.Spec.Containers[0].Args
+					}
+				case *rbacv1.ClusterRole:
+					if !strings.HasSuffix(object.Name, ":addon-management") {
+						continue
+					}
+					for _, rule := range object.Rules {
+						if len(rule.Resources) == 1 && rule.Resources[0] == "leases" &&
+							sets.New[string](rule.Verbs...).Has("delete") {
+							leaseDelete = true
+						}
+					}
+				}
+			}
+
+			argSet := sets.New[string](args...)
+			for _, arg := range []string{"--addon-lease-cleanup", "--addon-lease-cleanup-dry-run=false"} {
+				expected := sets.New[string](c.expectedArgs...).Has(arg)
+				if argSet.Has(arg) != expected {
+					t.Errorf("expected arg %q %v, but got args %v", arg, expected, args)
+				}
+			}
+			if leaseDelete != c.expectedLeaseDelete {
+				t.Errorf("expected the access to delete the leases %v, but got %v", c.expectedLeaseDelete, leaseDelete)
+			}
+		})
+	}
+}
+
+func TestSyncWithAddOnLeaseRBAC(t *testing.T) {
+	cases := []struct {
+		name         string
+		annotation   string
+		expectedRBAC bool
+	}{
+		{
+			name: "lease rbac is disabled by default",
+		},
+		{
+			name:         "lease rbac is enabled",
+			annotation:   "true",
+			expectedRBAC: true,
+		},
+		{
+			name:       "invalid annotation value",
+			annotation: "yes",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+			if len(c.annotation) != 0 {
+				klusterlet.Annotations = map[string]string{addOnLeaseRBACAnno: c.annotation}
+			}
+			hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+			hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+			controller := newTestController(t, klusterlet, nil, newSecret(helpers.BootstrapHubKubeConfig, "testns"),
+				hubKubeConfigSecret, newNamespace("testns"))
+			if err := controller.controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "klusterlet")); err != nil {
+				t.Errorf("Expected non error when sync, %v", err)
+			}
+
+			var args []string
+			rbacGranted := false
+			for _, action := range controller.kubeClient.Actions() {
+				if action.GetVerb() != "create" {
+					continue
+				}
+				switch object := action.(clienttesting.CreateActionImpl).Object.(type) {
+				case *appsv1.Deployment:
+					if object.Name == "klusterlet-registration-agent" {
+						args = object.Spec.Template.Spec.Containers[0].Args
+					}
+				case *rbacv1.ClusterRole:
+					if !strings.HasSuffix(object.Name, ":addon-management") {
+						continue
+					}
+					for _, rule := range object.Rules {
+						if sets.New[string](rule.Resources...).HasAny("roles", "rolebindings") {
+							rbacGranted = true
+						}
+					}
+				}
+			}
+
+			if sets.New[string](args...).Has("--addon-lease-rbac") != c.expectedRBAC {
+				t.Errorf("expected arg --addon-lease-rbac %v, but got args %v", c.expectedRBAC, args)
+			}
+			if rbacGranted != c.expectedRBAC {
+				t.Errorf("expected the access to the roles and rolebindings %v, but got %v", c.expectedRBAC, rbacGranted)
+			}
+		})
+	}
+}
+
 func TestDeployOnKube111(t *testing.T) {
 	klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
 	bootStrapSecret := newSecret(helpers.BootstrapHubKubeConfig, "testns")
@@ -1006,3 +1154,66 @@ func (m *fakeManagedClusterBuilder) build(ctx context.Context) (*managedClusterC
 		},
 	}, nil
 }
+
+func TestSyncWithAddOnHeartbeatConfigMap(t *testing.T) {
+	cases := []struct {
+		name             string
+		annotation       string
+		expectedReadable bool
+	}{
+		{
+			name: "heartbeat configmap is disabled by default",
+		},
+		{
+			name:             "heartbeat configmap is enabled",
+			annotation:       "true",
+			expectedReadable: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			klusterlet := newKlusterlet("klusterlet", "testns", "cluster1")
+			if len(c.annotation) != 0 {
+				klusterlet.Annotations = map[string]string{addOnHeartbeatConfigMapAnno: c.annotation}
+			}
+			hubKubeConfigSecret := newSecret(helpers.HubKubeConfig, "testns")
+			hubKubeConfigSecret.Data["kubeconfig"] = []byte("dummuykubeconnfig")
+			controller := newTestController(t, klusterlet, nil, newSecret(helpers.BootstrapHubKubeConfig, "testns"),
+				hubKubeConfigSecret, newNamespace("testns"))
+			if err := controller.controller.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "klusterlet")); err != nil {
+				t.Errorf("Expected non error when sync, %v", err)
+			}
+
+			var args []string
+			configMapsReadable := false
+			for _, action := range controller.kubeClient.Actions() {
+				if action.GetVerb() != "create" {
+					continue
+				}
+				switch object := action.(clienttesting.CreateActionImpl).Object.(type) {
+				case *appsv1.Deployment:
+					if object.Name == "klusterlet-registration-agent" {
+						args = object.Spec.Template.Spec.Containers[0].Args
+					}
+				case *rbacv1.ClusterRole:
+					if !strings.HasSuffix(object.Name, ":addon-management") {
+						continue
+					}
+					for _, rule := range object.Rules {
+						if sets.New[string](rule.Resources...).Has("configmaps") {
+							configMapsReadable = true
+						}
+					}
+				}
+			}
+
+			if sets.New[string](args...).Has("--addon-heartbeat-configmap") != c.expectedReadable {
+				t.Errorf("expected arg --addon-heartbeat-configmap %v, but got args %v", c.expectedReadable, args)
+			}
+			if configMapsReadable != c.expectedReadable {
+				t.Errorf("expected the access to the configmaps %v, but got %v", c.expectedReadable, configMapsReadable)
+			}
+		})
+	}
+}
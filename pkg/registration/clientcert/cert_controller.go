@@ -46,6 +46,10 @@ const (
 	ClientCertificateUpdatedReason = "ClientCertificateUpdated"
 )
 
+// DefaultClientCertRotationFraction is the default fraction of the validity period of the client certificate
+// after which the client certificate will be rotated.
+const DefaultClientCertRotationFraction = 0.8
+
 // ControllerResyncInterval is exposed so that integration tests can crank up the constroller sync speed.
 var ControllerResyncInterval = 5 * time.Minute
 
@@ -92,6 +96,10 @@ type ClientCertOption struct {
 	// AdditonalSecretDataSensitive is true indicates the client cert is sensitive to the AdditonalSecretData.
 	// That means once AdditonalSecretData changes, the client cert will be recreated.
 	AdditionalSecretDataSensitive bool
+	// RotationFraction is the fraction of the validity period of the client certificate after which the client
+	// certificate will be rotated. It is computed from the NotBefore/NotAfter of the client certificate, and the
+	// default value 0.8 is used if it is not set.
+	RotationFraction float64
 }
 
 type StatusUpdateFunc func(ctx context.Context, cond metav1.Condition) error
@@ -135,6 +143,8 @@ func NewClientCertificateController(
 	recorder events.Recorder,
 	controllerName string,
 ) factory.Controller {
+	registerCertMetrics()
+
 	c := clientCertificateController{
 		ClientCertOption:     clientCertOption,
 		CSROption:            csrOption,
@@ -260,6 +270,7 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 		}
 
 		notBefore, notAfter, err := getCertValidityPeriod(secret)
+		recordClientCertExpiry(secret)
 
 		cond := metav1.Condition{
 			Type:    "ClusterCertificateRotated",
@@ -290,17 +301,22 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 		return nil
 	}
 
+	recordClientCertExpiry(secret)
+
 	// create a csr to request new client certificate if
 	// a. there is no valid client certificate issued for the current cluster/agent;
 	// b. client certificate is sensitive to the additional secret data and the data changes;
-	// c. client certificate exists and has less than a random percentage range from 20% to 25% of its life remaining;
+	// c. client certificate exists and has passed the rotation fraction of its life, a random jitter of up to 25% of
+	//    the remaining life is applied, e.g. the client certificate is rotated once it has less than a random percentage
+	//    range from 20% to 25% of its life remaining by default;
 	shouldCreate, err := shouldCreateCSR(
 		c.controllerName,
 		secret,
 		syncCtx.Recorder(),
 		c.Subject,
 		c.AdditionalSecretDataSensitive,
-		c.AdditionalSecretData)
+		c.AdditionalSecretData,
+		c.RotationFraction)
 	if err != nil {
 		return err
 	}
@@ -365,7 +381,8 @@ func shouldCreateCSR(
 	recorder events.Recorder,
 	subject *pkix.Name,
 	additionalSecretDataSensitive bool,
-	additionalSecretData map[string][]byte) (bool, error) {
+	additionalSecretData map[string][]byte,
+	rotationFraction float64) (bool, error) {
 	switch {
 	case !hasValidClientCertificate(subject, secret):
 		recorder.Eventf("NoValidCertificateFound",
@@ -379,13 +396,19 @@ func shouldCreateCSR(
 			return false, err
 		}
 
+		if rotationFraction <= 0 || rotationFraction >= 1 {
+			rotationFraction = DefaultClientCertRotationFraction
+		}
+
+		// both of the total and elapsed life are computed from the NotBefore of the client certificate
 		total := notAfter.Sub(*notBefore)
-		remaining := time.Until(*notAfter)
+		elapsed := time.Since(*notBefore)
+		remaining := total - elapsed
 		klog.V(4).Infof("Client certificate for %s: time total=%v, remaining=%v, remaining/total=%v",
 			controllerName, total, remaining, remaining.Seconds()/total.Seconds())
-		threshold := jitter(0.2, 0.25)
+		threshold := jitter(1-rotationFraction, 0.25)
 		if remaining.Seconds()/total.Seconds() > threshold {
-			// Do nothing if the client certificate is valid and has more than a random percentage range from 20% to 25% of its life remaining
+			// Do nothing if the client certificate is valid and has more than the threshold of its life remaining
 			klog.V(4).Infof("Client certificate for %s is valid and has more than %.2f%% of its life remaining", controllerName, threshold*100)
 			return false, nil
 		}
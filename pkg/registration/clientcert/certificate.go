@@ -5,6 +5,9 @@ import (
 	"crypto/x509/pkix"
 	"errors"
 	"fmt"
+	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -12,6 +15,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	certificatesinformers "k8s.io/client-go/informers/certificates"
 	certificatesv1informers "k8s.io/client-go/informers/certificates/v1"
 	"k8s.io/client-go/kubernetes"
@@ -29,6 +33,8 @@ import (
 	"open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
+var signerNamePathRegexp = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
 // HasValidClientCertificate checks if there exists a valid client certificate in the given secret
 // Returns true if all the conditions below are met:
 //  1. KubeconfigFile exists when hasKubeconfig is true
@@ -104,6 +110,58 @@ func IsCertificateValid(certData []byte, subject *pkix.Name) (bool, error) {
 	return false, nil
 }
 
+// ValidateClientCertificate checks the client certificate of the client config, an error with the validity
+// period of the certificate is returned if any cert in the certificate is expired or not yet valid. It returns
+// nil if there is no client certificate in the client config, e.g. a token is used for authentication.
+func ValidateClientCertificate(clientConfig *restclient.Config) error {
+	certData := clientConfig.CertData
+	if len(certData) == 0 && len(clientConfig.CertFile) != 0 {
+		data, err := os.ReadFile(clientConfig.CertFile)
+		if err != nil {
+			return fmt.Errorf("unable to read client certificate from file %q: %w", clientConfig.CertFile, err)
+		}
+		certData = data
+	}
+	if len(certData) == 0 {
+		return nil
+	}
+
+	certs, err := certutil.ParseCertsPEM(certData)
+	if err != nil {
+		return fmt.Errorf("unable to parse client certificate: %w", err)
+	}
+
+	now := time.Now()
+	for _, cert := range certs {
+		if now.Before(cert.NotBefore) {
+			return fmt.Errorf("client certificate (cn=%s) is not yet valid, NotBefore: %s, NotAfter: %s",
+				cert.Subject.CommonName, cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+		}
+		if now.After(cert.NotAfter) {
+			return fmt.Errorf("client certificate (cn=%s) is expired, NotBefore: %s, NotAfter: %s",
+				cert.Subject.CommonName, cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// ValidateSignerName checks the format of a csr signer name, a signer name consists of a domain and a path
+// separated by a '/', e.g. example.com/signer-name.
+func ValidateSignerName(signerName string) error {
+	domain, path, found := strings.Cut(signerName, "/")
+	if !found || len(domain) == 0 || len(path) == 0 {
+		return fmt.Errorf("invalid signer name %q: it must be of the form <domain>/<path>", signerName)
+	}
+	if errs := validation.IsDNS1123Subdomain(domain); len(errs) > 0 {
+		return fmt.Errorf("invalid signer name %q: the domain is invalid: %s", signerName, strings.Join(errs, ", "))
+	}
+	if !signerNamePathRegexp.MatchString(path) {
+		return fmt.Errorf("invalid signer name %q: the path must consist of alphanumeric characters, '-', '_' or '.', "+
+			"and must start and end with an alphanumeric character", signerName)
+	}
+	return nil
+}
+
 // getCertValidityPeriod returns the validity period of the client certificate in the secret
 func getCertValidityPeriod(secret *corev1.Secret) (*time.Time, *time.Time, error) {
 	if secret.Data == nil {
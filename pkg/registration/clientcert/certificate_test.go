@@ -10,6 +10,7 @@ import (
 	certificates "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/client-go/listers/certificates/v1"
+	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	certutil "k8s.io/client-go/util/cert"
 
@@ -231,3 +232,92 @@ func TestGetCertValidityPeriod(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateClientCertificate(t *testing.T) {
+	validCert := testinghelpers.NewTestCert("cluster0", 60*time.Second)
+	expiredCert := testinghelpers.NewTestCert("cluster0", -60*time.Second)
+
+	cases := []struct {
+		name         string
+		clientConfig *restclient.Config
+		expectedErr  bool
+	}{
+		{
+			name:         "no client certificate",
+			clientConfig: &restclient.Config{BearerToken: "token"},
+		},
+		{
+			name:         "valid client certificate",
+			clientConfig: &restclient.Config{TLSClientConfig: restclient.TLSClientConfig{CertData: validCert.Cert}},
+		},
+		{
+			name:         "expired client certificate",
+			clientConfig: &restclient.Config{TLSClientConfig: restclient.TLSClientConfig{CertData: expiredCert.Cert}},
+			expectedErr:  true,
+		},
+		{
+			name:         "bad client certificate",
+			clientConfig: &restclient.Config{TLSClientConfig: restclient.TLSClientConfig{CertData: []byte("bad cert")}},
+			expectedErr:  true,
+		},
+		{
+			name:         "client certificate file not found",
+			clientConfig: &restclient.Config{TLSClientConfig: restclient.TLSClientConfig{CertFile: "/not/found"}},
+			expectedErr:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateClientCertificate(c.clientConfig)
+			if c.expectedErr && err == nil {
+				t.Errorf("expected error, but failed")
+			}
+			if !c.expectedErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateSignerName(t *testing.T) {
+	cases := []struct {
+		name        string
+		signerName  string
+		expectedErr bool
+	}{
+		{
+			name:       "kube-apiserver-client signer",
+			signerName: certificates.KubeAPIServerClientSignerName,
+		},
+		{
+			name:       "custom signer",
+			signerName: "example.com/signer-name",
+		},
+		{
+			name:        "no path",
+			signerName:  "example.com",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid domain",
+			signerName:  "Example_com/signer",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid path",
+			signerName:  "example.com/signer/name",
+			expectedErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateSignerName(c.signerName)
+			if c.expectedErr && err == nil {
+				t.Errorf("expected error, but failed")
+			}
+			if !c.expectedErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
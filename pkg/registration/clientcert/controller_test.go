@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	certificates "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -306,3 +307,68 @@ func (m *mockCSRControl) getIssuedCertificate(name string) ([]byte, error) {
 func (m *mockCSRControl) Informer() cache.SharedIndexInformer {
 	panic("implement me")
 }
+
+func TestShouldCreateCSRWithRotationFraction(t *testing.T) {
+	testSubject := &pkix.Name{
+		CommonName: commonName,
+	}
+
+	cases := []struct {
+		name             string
+		validity         time.Duration
+		elapsed          time.Duration
+		rotationFraction float64
+		expected         bool
+	}{
+		{
+			name:     "half of the life is passed with the default fraction",
+			validity: 100 * time.Hour,
+			elapsed:  50 * time.Hour,
+			expected: false,
+		},
+		{
+			name:     "most of the life is passed with the default fraction",
+			validity: 100 * time.Hour,
+			elapsed:  90 * time.Hour,
+			expected: true,
+		},
+		{
+			name:             "short lived certificate passes the customized fraction",
+			validity:         2 * time.Hour,
+			elapsed:          70 * time.Minute,
+			rotationFraction: 0.5,
+			expected:         true,
+		},
+		{
+			name:             "long lived certificate does not pass the customized fraction",
+			validity:         365 * 24 * time.Hour,
+			elapsed:          100 * 24 * time.Hour,
+			rotationFraction: 0.5,
+			expected:         false,
+		},
+		{
+			name:             "invalid fraction falls back to the default",
+			validity:         100 * time.Hour,
+			elapsed:          70 * time.Hour,
+			rotationFraction: 1.5,
+			expected:         false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			notBefore := time.Now().Add(-c.elapsed)
+			cert := testinghelpers.NewTestCertWithValidityPeriod(*testSubject, notBefore, notBefore.Add(c.validity))
+			secret := testinghelpers.NewHubKubeconfigSecret(testNamespace, testSecretName, "1", cert, map[string][]byte{})
+
+			actual, err := shouldCreateCSR("test", secret, eventstesting.NewTestingEventRecorder(t),
+				testSubject, false, nil, c.rotationFraction)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if actual != c.expected {
+				t.Errorf("expected %v, but got %v", c.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package clientcert
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	// clientCertExpiry is the NotAfter of the client certificate stored in a secret, so that the expiry of the
+	// client certificate can be alerted independent of the rotation.
+	clientCertExpiry = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "registration_client_cert_expiry_seconds",
+			Help:           "Unix timestamp in seconds of the NotAfter of the client certificate maintained by the registration agent.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"secret"},
+	)
+
+	registerCertMetricsOnce sync.Once
+)
+
+// registerCertMetrics registers the metrics of the client certificate controller, so that they can be exported
+// on the metrics endpoint of the agent.
+func registerCertMetrics() {
+	registerCertMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(clientCertExpiry)
+	})
+}
+
+// recordClientCertExpiry records the expiry of the client certificate in the secret, it is a no-op if there is
+// no valid client certificate in the secret.
+func recordClientCertExpiry(secret *corev1.Secret) {
+	_, notAfter, err := getCertValidityPeriod(secret)
+	if err != nil {
+		return
+	}
+	clientCertExpiry.WithLabelValues(secret.Namespace + "/" + secret.Name).Set(float64(notAfter.Unix()))
+}
@@ -0,0 +1,40 @@
+package clientcert
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/component-base/metrics/testutil"
+
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestRecordClientCertExpiry(t *testing.T) {
+	registerCertMetrics()
+
+	cert := testinghelpers.NewTestCert(commonName, 60*time.Minute)
+	secret := testinghelpers.NewHubKubeconfigSecret(testNamespace, testSecretName, "1", cert, map[string][]byte{})
+	_, notAfter, err := getCertValidityPeriod(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recordClientCertExpiry(secret)
+	expiry, err := testutil.GetGaugeMetricValue(clientCertExpiry.WithLabelValues(testNamespace + "/" + testSecretName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiry != float64(notAfter.Unix()) {
+		t.Errorf("expected the expiry %v, but got %v", notAfter.Unix(), expiry)
+	}
+
+	// the expiry is not changed if there is no valid client certificate
+	recordClientCertExpiry(testinghelpers.NewHubKubeconfigSecret(testNamespace, testSecretName, "2", nil, map[string][]byte{}))
+	expiry, err = testutil.GetGaugeMetricValue(clientCertExpiry.WithLabelValues(testNamespace + "/" + testSecretName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiry != float64(notAfter.Unix()) {
+		t.Errorf("expected the expiry %v, but got %v", notAfter.Unix(), expiry)
+	}
+}
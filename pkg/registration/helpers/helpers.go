@@ -3,6 +3,7 @@ package helpers
 import (
 	"embed"
 	"net/url"
+	"time"
 
 	"github.com/openshift/library-go/pkg/assets"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
@@ -158,3 +159,36 @@ func IsCSRSupported(nativeClient kubernetes.Interface) (bool, bool, error) {
 	}
 	return v1CSRSupported, v1beta1CSRSupported, nil
 }
+
+// LeaseFreshness is the freshness of a lease decided by CheckLeaseFreshness
+type LeaseFreshness string
+
+const (
+	// LeaseFresh means the lease is renewed within the first half of its grace period
+	LeaseFresh LeaseFreshness = "Fresh"
+	// LeaseDegraded means the lease is renewed within its grace period but not the first half of it, it gives an
+	// early warning before the lease is expired.
+	LeaseDegraded LeaseFreshness = "Degraded"
+	// LeaseExpired means the lease is not renewed within its grace period
+	LeaseExpired LeaseFreshness = "Expired"
+)
+
+// CheckLeaseFreshness checks whether a lease renewed at renewTime is still fresh at now, the grace period of the
+// lease is the lease duration times leaseDurationTimes. It returns false with LeaseExpired if the lease is not
+// renewed within the grace period, otherwise it returns true with LeaseFresh or LeaseDegraded.
+func CheckLeaseFreshness(renewTime, now time.Time, leaseDuration time.Duration, leaseDurationTimes int) (bool, LeaseFreshness) {
+	return CheckLeaseFreshnessWithGracePeriod(renewTime, now, time.Duration(leaseDurationTimes)*leaseDuration)
+}
+
+// CheckLeaseFreshnessWithGracePeriod is the same as CheckLeaseFreshness, but with the grace period of the lease
+// given directly, e.g. the grace period is overridden by the configuration of the lease holder.
+func CheckLeaseFreshnessWithGracePeriod(renewTime, now time.Time, gracePeriod time.Duration) (bool, LeaseFreshness) {
+	switch {
+	case now.Before(renewTime.Add(gracePeriod / 2)):
+		return true, LeaseFresh
+	case now.Before(renewTime.Add(gracePeriod)):
+		return true, LeaseDegraded
+	default:
+		return false, LeaseExpired
+	}
+}
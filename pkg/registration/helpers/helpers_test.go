@@ -3,6 +3,7 @@ package helpers
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 
@@ -251,3 +252,100 @@ func TestRemoveTaints(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckLeaseFreshness(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name               string
+		renewTime          time.Time
+		leaseDuration      time.Duration
+		leaseDurationTimes int
+		expectedFresh      bool
+		expectedFreshness  LeaseFreshness
+	}{
+		{
+			name:               "lease is just renewed",
+			renewTime:          now,
+			leaseDuration:      time.Minute,
+			leaseDurationTimes: 5,
+			expectedFresh:      true,
+			expectedFreshness:  LeaseFresh,
+		},
+		{
+			name:               "lease is renewed within the first half of the grace period",
+			renewTime:          now.Add(-2 * time.Minute),
+			leaseDuration:      time.Minute,
+			leaseDurationTimes: 5,
+			expectedFresh:      true,
+			expectedFreshness:  LeaseFresh,
+		},
+		{
+			name:               "lease is renewed at the half of the grace period",
+			renewTime:          now.Add(-150 * time.Second),
+			leaseDuration:      time.Minute,
+			leaseDurationTimes: 5,
+			expectedFresh:      true,
+			expectedFreshness:  LeaseDegraded,
+		},
+		{
+			name:               "lease is renewed within the grace period",
+			renewTime:          now.Add(-4 * time.Minute),
+			leaseDuration:      time.Minute,
+			leaseDurationTimes: 5,
+			expectedFresh:      true,
+			expectedFreshness:  LeaseDegraded,
+		},
+		{
+			name:               "lease is renewed at the end of the grace period",
+			renewTime:          now.Add(-5 * time.Minute),
+			leaseDuration:      time.Minute,
+			leaseDurationTimes: 5,
+			expectedFresh:      false,
+			expectedFreshness:  LeaseExpired,
+		},
+		{
+			name:               "lease is expired",
+			renewTime:          now.Add(-time.Hour),
+			leaseDuration:      time.Minute,
+			leaseDurationTimes: 5,
+			expectedFresh:      false,
+			expectedFreshness:  LeaseExpired,
+		},
+		{
+			name:               "lease is renewed in the future",
+			renewTime:          now.Add(time.Minute),
+			leaseDuration:      time.Minute,
+			leaseDurationTimes: 5,
+			expectedFresh:      true,
+			expectedFreshness:  LeaseFresh,
+		},
+		{
+			name:               "lease with zero grace period",
+			renewTime:          now,
+			leaseDuration:      time.Minute,
+			leaseDurationTimes: 0,
+			expectedFresh:      false,
+			expectedFreshness:  LeaseExpired,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fresh, freshness := CheckLeaseFreshness(c.renewTime, now, c.leaseDuration, c.leaseDurationTimes)
+			if fresh != c.expectedFresh {
+				t.Errorf("expected fresh %v, but got %v", c.expectedFresh, fresh)
+			}
+			if freshness != c.expectedFreshness {
+				t.Errorf("expected freshness %q, but got %q", c.expectedFreshness, freshness)
+			}
+
+			// the grace period is the lease duration times the lease duration times
+			fresh, freshness = CheckLeaseFreshnessWithGracePeriod(c.renewTime, now,
+				time.Duration(c.leaseDurationTimes)*c.leaseDuration)
+			if fresh != c.expectedFresh || freshness != c.expectedFreshness {
+				t.Errorf("expected fresh %v with freshness %q, but got %v with %q", c.expectedFresh,
+					c.expectedFreshness, fresh, freshness)
+			}
+		})
+	}
+}
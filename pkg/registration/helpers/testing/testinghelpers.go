@@ -28,6 +28,7 @@ import (
 	certutil "k8s.io/client-go/util/cert"
 	"k8s.io/client-go/util/keyutil"
 
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 )
@@ -182,6 +183,20 @@ func NewAddOnLease(namespace, name string, renewTime time.Time) *coordv1.Lease {
 	}
 }
 
+// NewFakeAddOn returns an addon in the namespace of the test managed cluster, the addon agent is installed in the
+// given installation namespace.
+func NewFakeAddOn(name, installNamespace string) *addonv1alpha1.ManagedClusterAddOn {
+	return &addonv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: TestManagedClusterName,
+		},
+		Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+			InstallNamespace: installNamespace,
+		},
+	}
+}
+
 func NewNamespace(name string, terminated bool) *corev1.Namespace {
 	namespace := &corev1.Namespace{}
 	namespace.Name = name
@@ -436,6 +451,11 @@ func NewHubKubeconfigSecret(namespace, name, resourceVersion string, cert *TestC
 }
 
 func NewTestCertWithSubject(subject pkix.Name, duration time.Duration) *TestCert {
+	now := time.Now()
+	return NewTestCertWithValidityPeriod(subject, now, now.Add(duration))
+}
+
+func NewTestCertWithValidityPeriod(subject pkix.Name, notBefore, notAfter time.Time) *TestCert {
 	caKey, err := rsa.GenerateKey(cryptorand.Reader, 2048)
 	if err != nil {
 		panic(err)
@@ -456,8 +476,8 @@ func NewTestCertWithSubject(subject pkix.Name, duration time.Duration) *TestCert
 		&x509.Certificate{
 			Subject:      subject,
 			SerialNumber: big.NewInt(1),
-			NotBefore:    caCert.NotBefore,
-			NotAfter:     time.Now().Add(duration).UTC(),
+			NotBefore:    notBefore.UTC(),
+			NotAfter:     notAfter.UTC(),
 			KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 		},
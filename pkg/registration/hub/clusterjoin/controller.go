@@ -0,0 +1,195 @@
+package clusterjoin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+)
+
+// ManagedClusterConditionJoinRejected is the condition type of a managed cluster indicating the join of the
+// managed cluster is rejected, since it has the same identity with another accepted managed cluster. A managed
+// cluster whose join is rejected is not accepted by the hub, while an accepted managed cluster is kept accepted until
+// it is denied by the hub cluster admin.
+const ManagedClusterConditionJoinRejected = "ManagedClusterJoinRejected"
+
+// joinValidationController rejects the join of a managed cluster whose cluster claim has the same value with an
+// earlier accepted managed cluster, so that two spokes cannot impersonate the same identity.
+type joinValidationController struct {
+	claimName     string
+	patcher       patcher.Patcher[*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus]
+	clusterLister listerv1.ManagedClusterLister
+	eventRecorder events.Recorder
+}
+
+// NewJoinValidationController creates a new join validation controller, the value of the cluster claim claimName
+// identifies a managed cluster.
+func NewJoinValidationController(
+	claimName string,
+	clusterClient clientset.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &joinValidationController{
+		claimName: claimName,
+		patcher: patcher.NewPatcher[
+			*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		clusterLister: clusterInformer.Lister(),
+		eventRecorder: recorder.WithComponentSuffix("join-validation-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeysFunc(c.clusterQueueKeysFunc, clusterInformer.Informer()).
+		WithSync(c.sync).
+		ToController("JoinValidationController", recorder)
+}
+
+// clusterQueueKeysFunc enqueues the managed cluster together with the managed clusters which have the same claim
+// value, since the join of them may be rejected or allowed by the change of the managed cluster.
+func (c *joinValidationController) clusterQueueKeysFunc(obj runtime.Object) []string {
+	accessor, _ := meta.Accessor(obj)
+	keys := []string{accessor.GetName()}
+
+	cluster, ok := obj.(*v1.ManagedCluster)
+	if !ok {
+		return keys
+	}
+	value, ok := getClaimValue(cluster, c.claimName)
+	if !ok {
+		return keys
+	}
+
+	clusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		return keys
+	}
+	for _, other := range clusters {
+		if other.Name == cluster.Name {
+			continue
+		}
+		if otherValue, ok := getClaimValue(other, c.claimName); ok && otherValue == value {
+			keys = append(keys, other.Name)
+		}
+	}
+	return keys
+}
+
+func (c *joinValidationController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Validating the join of ManagedCluster %s", managedClusterName)
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !managedCluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	conflictCluster, err := c.findConflictCluster(managedCluster)
+	if err != nil {
+		return err
+	}
+
+	newManagedCluster := managedCluster.DeepCopy()
+	rejected := meta.IsStatusConditionTrue(managedCluster.Status.Conditions, ManagedClusterConditionJoinRejected)
+	switch {
+	case conflictCluster != nil:
+		value, _ := getClaimValue(managedCluster, c.claimName)
+		meta.SetStatusCondition(&newManagedCluster.Status.Conditions, metav1.Condition{
+			Type:   ManagedClusterConditionJoinRejected,
+			Status: metav1.ConditionTrue,
+			Reason: "ClusterClaimConflicted",
+			Message: fmt.Sprintf("The value %q of the cluster claim %q is same with the accepted managed cluster %q",
+				value, c.claimName, conflictCluster.Name),
+		})
+	case rejected:
+		meta.SetStatusCondition(&newManagedCluster.Status.Conditions, metav1.Condition{
+			Type:    ManagedClusterConditionJoinRejected,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ClusterClaimUnique",
+			Message: fmt.Sprintf("The value of the cluster claim %q is unique", c.claimName),
+		})
+	default:
+		return nil
+	}
+
+	updated, err := c.patcher.PatchStatus(ctx, newManagedCluster, newManagedCluster.Status, managedCluster.Status)
+	if err != nil {
+		return err
+	}
+	if updated && conflictCluster != nil {
+		c.eventRecorder.Warningf("ManagedClusterJoinRejected",
+			"managed cluster %s is rejected since it has the same cluster claim %q with managed cluster %s",
+			managedClusterName, c.claimName, conflictCluster.Name)
+	}
+	return nil
+}
+
+// findConflictCluster returns the accepted managed cluster which has the same claim value with the managed cluster
+// and is created earlier than it. It returns nil if there is no such managed cluster.
+func (c *joinValidationController) findConflictCluster(managedCluster *v1.ManagedCluster) (*v1.ManagedCluster, error) {
+	value, ok := getClaimValue(managedCluster, c.claimName)
+	if !ok {
+		return nil, nil
+	}
+
+	clusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var conflictCluster *v1.ManagedCluster
+	for _, cluster := range clusters {
+		if cluster.Name == managedCluster.Name || !cluster.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if !meta.IsStatusConditionTrue(cluster.Status.Conditions, v1.ManagedClusterConditionHubAccepted) {
+			continue
+		}
+		if otherValue, ok := getClaimValue(cluster, c.claimName); !ok || otherValue != value {
+			continue
+		}
+		if !isCreatedEarlier(cluster, managedCluster) {
+			continue
+		}
+		if conflictCluster == nil || isCreatedEarlier(cluster, conflictCluster) {
+			conflictCluster = cluster
+		}
+	}
+	return conflictCluster, nil
+}
+
+// isCreatedEarlier returns true if the cluster is created earlier than the other one, the name is compared if they
+// are created at the same time.
+func isCreatedEarlier(cluster, other *v1.ManagedCluster) bool {
+	if !cluster.CreationTimestamp.Equal(&other.CreationTimestamp) {
+		return cluster.CreationTimestamp.Before(&other.CreationTimestamp)
+	}
+	return cluster.Name < other.Name
+}
+
+func getClaimValue(cluster *v1.ManagedCluster, claimName string) (string, bool) {
+	for _, claim := range cluster.Status.ClusterClaims {
+		if claim.Name == claimName && len(claim.Value) != 0 {
+			return claim.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,169 @@
+package clusterjoin
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+const testClaimName = "id.k8s.io"
+
+func TestSync(t *testing.T) {
+	cases := []struct {
+		name            string
+		startingObjects []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "sync a deleted spoke cluster",
+			startingObjects: []runtime.Object{},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "spoke cluster has a unique claim",
+			startingObjects: []runtime.Object{
+				newClaimedManagedCluster(testinghelpers.TestManagedClusterName, "id1", time.Now()),
+				newClaimedManagedCluster("cluster1", "id2", time.Now().Add(-time.Hour)),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "spoke cluster has the same claim with an earlier accepted cluster",
+			startingObjects: []runtime.Object{
+				newClaimedManagedCluster(testinghelpers.TestManagedClusterName, "id1", time.Now()),
+				newClaimedManagedCluster("cluster1", "id1", time.Now().Add(-time.Hour)),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				condition := getJoinRejectedCondition(t, actions[0])
+				if condition == nil || condition.Status != metav1.ConditionTrue {
+					t.Fatalf("expected the join is rejected, but got %v", condition)
+				}
+				if !strings.Contains(condition.Message, "cluster1") {
+					t.Errorf("expected the conflict cluster in the message, but got %q", condition.Message)
+				}
+			},
+		},
+		{
+			name: "spoke cluster has the same claim with a later accepted cluster",
+			startingObjects: []runtime.Object{
+				newClaimedManagedCluster(testinghelpers.TestManagedClusterName, "id1", time.Now().Add(-time.Hour)),
+				newClaimedManagedCluster("cluster1", "id1", time.Now()),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "conflict cluster is removed",
+			startingObjects: []runtime.Object{
+				func() *v1.ManagedCluster {
+					cluster := newClaimedManagedCluster(testinghelpers.TestManagedClusterName, "id1", time.Now())
+					meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+						Type:   ManagedClusterConditionJoinRejected,
+						Status: metav1.ConditionTrue,
+						Reason: "ClusterClaimConflicted",
+					})
+					return cluster
+				}(),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				condition := getJoinRejectedCondition(t, actions[0])
+				if condition == nil || condition.Status != metav1.ConditionFalse {
+					t.Errorf("expected the join is allowed, but got %v", condition)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.startingObjects...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, cluster := range c.startingObjects {
+				if err := clusterStore.Add(cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctrl := &joinValidationController{
+				claimName: testClaimName,
+				patcher: patcher.NewPatcher[
+					*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+					clusterClient.ClusterV1().ManagedClusters()),
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func TestClusterQueueKeysFunc(t *testing.T) {
+	clusters := []runtime.Object{
+		newClaimedManagedCluster(testinghelpers.TestManagedClusterName, "id1", time.Now()),
+		newClaimedManagedCluster("cluster1", "id1", time.Now()),
+		newClaimedManagedCluster("cluster2", "id2", time.Now()),
+	}
+	clusterClient := clusterfake.NewSimpleClientset(clusters...)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+	for _, cluster := range clusters {
+		if err := clusterStore.Add(cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctrl := &joinValidationController{
+		claimName:     testClaimName,
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+	}
+	keys := ctrl.clusterQueueKeysFunc(clusters[0])
+	if len(keys) != 2 || keys[0] != testinghelpers.TestManagedClusterName || keys[1] != "cluster1" {
+		t.Errorf("expected the clusters with the same claim are enqueued, but got %v", keys)
+	}
+}
+
+func newClaimedManagedCluster(name, claimValue string, creationTime time.Time) *v1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	cluster.Name = name
+	cluster.CreationTimestamp = metav1.NewTime(creationTime)
+	cluster.Status.ClusterClaims = []v1.ManagedClusterClaim{{Name: testClaimName, Value: claimValue}}
+	return cluster
+}
+
+func getJoinRejectedCondition(t *testing.T, action clienttesting.Action) *metav1.Condition {
+	patch := action.(clienttesting.PatchAction).GetPatch()
+	managedCluster := &v1.ManagedCluster{}
+	if err := json.Unmarshal(patch, managedCluster); err != nil {
+		t.Fatal(err)
+	}
+	return meta.FindStatusCondition(managedCluster.Status.Conditions, ManagedClusterConditionJoinRejected)
+}
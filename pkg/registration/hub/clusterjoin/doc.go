@@ -0,0 +1,2 @@
+// package clusterjoin contains the hub-side controller validating the uniqueness of the joining ManagedClusters.
+package clusterjoin
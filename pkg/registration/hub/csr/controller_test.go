@@ -13,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 
@@ -208,12 +209,13 @@ func TestSync(t *testing.T) {
 						eventRecorder: recorder,
 						approvalUsers: sets.Set[string]{},
 					},
-					NewCSRRenewalReconciler(kubeClient, recorder),
+					NewCSRRenewalReconciler(kubeClient, nil, recorder),
 					NewCSRBootstrapReconciler(
 						kubeClient,
 						clusterClient,
 						clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
 						c.approvalUsers,
+						nil,
 						recorder,
 					),
 				},
@@ -231,9 +233,13 @@ func TestSync(t *testing.T) {
 func TestIsSpokeClusterClientCertRenewal(t *testing.T) {
 	invalidSignerName := "invalidsigner"
 
+	customSigner := validCSR
+	customSigner.SignerName = "example.com/signer-name"
+
 	cases := []struct {
 		name        string
 		csr         testinghelpers.CSRHolder
+		signerNames []string
 		isRenewal   bool
 		clusterName string
 		commonName  string
@@ -309,11 +315,24 @@ func TestIsSpokeClusterClientCertRenewal(t *testing.T) {
 			clusterName: "managedcluster1",
 			commonName:  validCSR.CN,
 		},
+		{
+			name:      "a renewal csr of an unknown custom signer",
+			csr:       customSigner,
+			isRenewal: false,
+		},
+		{
+			name:        "a renewal csr of a custom signer",
+			csr:         customSigner,
+			signerNames: []string{customSigner.SignerName},
+			isRenewal:   true,
+			clusterName: "managedcluster1",
+			commonName:  validCSR.CN,
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			isRenewal, clusterName, commonName := validateCSR(newCSRInfo(testinghelpers.NewCSR(c.csr)))
+			isRenewal, clusterName, commonName := validateCSR(newCSRInfo(testinghelpers.NewCSR(c.csr)), sets.New(c.signerNames...))
 			if isRenewal != c.isRenewal {
 				t.Errorf("expected %t, but failed", c.isRenewal)
 			}
@@ -326,3 +345,267 @@ func TestIsSpokeClusterClientCertRenewal(t *testing.T) {
 		})
 	}
 }
+
+func TestAcceptedClusterRenewalReconciler(t *testing.T) {
+	acceptedCluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "managedcluster1"},
+		Spec:       clusterv1.ManagedClusterSpec{HubAcceptsClient: true},
+		Status: clusterv1.ManagedClusterStatus{
+			Conditions: []metav1.Condition{
+				{Type: clusterv1.ManagedClusterConditionHubAccepted, Status: metav1.ConditionTrue},
+			},
+		},
+	}
+	newCluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "managedcluster1"},
+	}
+	deniedCluster := acceptedCluster.DeepCopy()
+	deniedCluster.Spec.HubAcceptsClient = false
+	deletingCluster := acceptedCluster.DeepCopy()
+	deletingCluster.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+	newRenewalCSR := func(cn string, groups ...string) *certificatesv1.CertificateSigningRequest {
+		holder := validCSR
+		holder.CN = cn
+		holder.Username = cn
+		csr := testinghelpers.NewCSR(holder)
+		csr.Spec.Groups = groups
+		return csr
+	}
+	clusterGroup := user.SubjectPrefix + "managedcluster1"
+
+	cases := []struct {
+		name             string
+		cluster          *clusterv1.ManagedCluster
+		csr              *certificatesv1.CertificateSigningRequest
+		denied           bool
+		expectedApproved bool
+		expectedState    reconcileState
+		expectedActions  []string
+	}{
+		{
+			name:             "approve the renewal csr of an accepted cluster",
+			cluster:          acceptedCluster,
+			csr:              newRenewalCSR(validCSR.CN, clusterGroup, user.ManagedClustersGroup),
+			expectedApproved: true,
+			expectedState:    reconcileStop,
+			expectedActions:  []string{"create"},
+		},
+		{
+			name:          "leave the csr of a new cluster",
+			cluster:       newCluster,
+			csr:           newRenewalCSR(validCSR.CN, clusterGroup, user.ManagedClustersGroup),
+			expectedState: reconcileStop,
+		},
+		{
+			name:          "leave the csr of a denied cluster",
+			cluster:       deniedCluster,
+			csr:           newRenewalCSR(validCSR.CN, clusterGroup, user.ManagedClustersGroup),
+			expectedState: reconcileStop,
+		},
+		{
+			name:          "leave the csr of a deleting cluster",
+			cluster:       deletingCluster,
+			csr:           newRenewalCSR(validCSR.CN, clusterGroup, user.ManagedClustersGroup),
+			expectedState: reconcileStop,
+		},
+		{
+			name:          "leave the csr of a nonexistent cluster",
+			csr:           newRenewalCSR(validCSR.CN, clusterGroup, user.ManagedClustersGroup),
+			expectedState: reconcileStop,
+		},
+		{
+			name:    "skip the csr requested by a bootstrap user",
+			cluster: acceptedCluster,
+			csr: func() *certificatesv1.CertificateSigningRequest {
+				csr := newRenewalCSR(validCSR.CN, "system:bootstrappers")
+				csr.Spec.Username = "system:bootstrap:test"
+				return csr
+			}(),
+			expectedState: reconcileContinue,
+		},
+		{
+			name:            "deny the csr requested by an unauthorized user",
+			cluster:         acceptedCluster,
+			csr:             newRenewalCSR(validCSR.CN, user.ManagedClustersGroup),
+			denied:          true,
+			expectedState:   reconcileStop,
+			expectedActions: []string{"create"},
+		},
+		{
+			name:          "skip the csr with a common name of another cluster",
+			cluster:       acceptedCluster,
+			csr:           newRenewalCSR(user.SubjectPrefix+"managedcluster10:spokeagent1", clusterGroup),
+			expectedState: reconcileContinue,
+		},
+		{
+			name:          "skip the csr with an invalid agent name",
+			cluster:       acceptedCluster,
+			csr:           newRenewalCSR(validCSR.CN+":admin", clusterGroup),
+			expectedState: reconcileContinue,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset()
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if c.cluster != nil {
+				if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: !c.denied,
+						},
+					}, nil
+				},
+			)
+			reconciler := NewCSRAcceptedClusterRenewalReconciler(
+				kubeClient,
+				clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				nil,
+				eventstesting.NewTestingEventRecorder(t),
+			)
+
+			approved := false
+			state, err := reconciler.Reconcile(context.TODO(), newCSRInfo(c.csr), func(kubernetes.Interface) error {
+				approved = true
+				return nil
+			})
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if state != c.expectedState {
+				t.Errorf("expected state %v, but got %v", c.expectedState, state)
+			}
+			if approved != c.expectedApproved {
+				t.Errorf("expected approved %t, but got %t", c.expectedApproved, approved)
+			}
+			// the subject access review is created only for the renewal csr of an accepted cluster
+			testingcommon.AssertActions(t, kubeClient.Actions(), c.expectedActions...)
+		})
+	}
+}
+
+func TestRenewalReconcilerWithCustomSigner(t *testing.T) {
+	customSigner := validCSR
+	customSigner.SignerName = "example.com/signer-name"
+
+	cases := []struct {
+		name             string
+		signerNames      []string
+		expectedApproved bool
+	}{
+		{
+			name: "the csr of an unknown custom signer is not approved",
+		},
+		{
+			name:             "the csr of a custom signer is approved",
+			signerNames:      []string{customSigner.SignerName},
+			expectedApproved: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: true},
+					}, nil
+				},
+			)
+			reconciler := NewCSRRenewalReconciler(kubeClient, c.signerNames, eventstesting.NewTestingEventRecorder(t))
+
+			approved := false
+			if _, err := reconciler.Reconcile(context.TODO(), newCSRInfo(testinghelpers.NewCSR(customSigner)),
+				func(kubernetes.Interface) error {
+					approved = true
+					return nil
+				}); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if approved != c.expectedApproved {
+				t.Errorf("expected approved %t, but got %t", c.expectedApproved, approved)
+			}
+		})
+	}
+}
+
+func TestSyncWithAcceptedClusterRenewal(t *testing.T) {
+	newRenewalCSR := func() *certificatesv1.CertificateSigningRequest {
+		csr := testinghelpers.NewCSR(validCSR)
+		csr.Spec.Groups = []string{user.SubjectPrefix + "managedcluster1", user.ManagedClustersGroup}
+		return csr
+	}
+	acceptedCluster := testinghelpers.NewAcceptedManagedCluster()
+	acceptedCluster.Name = "managedcluster1"
+	notAcceptedCluster := testinghelpers.NewManagedCluster()
+	notAcceptedCluster.Name = "managedcluster1"
+
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		expectedActions []string
+	}{
+		{
+			name:            "the renewal csr of an accepted cluster is approved",
+			cluster:         acceptedCluster,
+			expectedActions: []string{"create", "update"},
+		},
+		{
+			name:    "the renewal csr of a cluster which is not accepted stays pending",
+			cluster: notAcceptedCluster,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			csr := newRenewalCSR()
+			kubeClient := kubefake.NewSimpleClientset(csr)
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: true},
+					}, nil
+				},
+			)
+			informerFactory := informers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+			if err := informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(csr); err != nil {
+				t.Fatal(err)
+			}
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterfake.NewSimpleClientset(), time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := eventstesting.NewTestingEventRecorder(t)
+			ctrl := &csrApprovingController[*certificatesv1.CertificateSigningRequest]{
+				lister:   informerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+				approver: NewCSRV1Approver(kubeClient),
+				reconcilers: []Reconciler{
+					NewCSRAcceptedClusterRenewalReconciler(kubeClient,
+						clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(), nil, recorder),
+					NewCSRRenewalReconciler(kubeClient, nil, recorder),
+				},
+			}
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, validCSR.Name)); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			testingcommon.AssertActions(t, kubeClient.Actions(), c.expectedActions...)
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	certificatesv1 "k8s.io/api/certificates/v1"
 	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -51,19 +52,23 @@ type Reconciler interface {
 
 type csrRenewalReconciler struct {
 	kubeClient    kubernetes.Interface
+	signerNames   sets.Set[string]
 	eventRecorder events.Recorder
 }
 
-func NewCSRRenewalReconciler(kubeClient kubernetes.Interface, recorder events.Recorder) Reconciler {
+// NewCSRRenewalReconciler returns a reconciler approving the renewal csrs of the spoke agents, the csrs of the
+// signerNames are approved in addition to the csrs of the kube-apiserver-client signer.
+func NewCSRRenewalReconciler(kubeClient kubernetes.Interface, signerNames []string, recorder events.Recorder) Reconciler {
 	return &csrRenewalReconciler{
 		kubeClient:    kubeClient,
+		signerNames:   sets.New(signerNames...),
 		eventRecorder: recorder.WithComponentSuffix("csr-approving-controller"),
 	}
 }
 
 func (r *csrRenewalReconciler) Reconcile(ctx context.Context, csr csrInfo, approveCSR approveCSRFunc) (reconcileState, error) {
 	// Check whether current csr is a valid spoker cluster csr.
-	valid, _, commonName := validateCSR(csr)
+	valid, _, commonName := validateCSR(csr, r.signerNames)
 	if !valid {
 		klog.V(4).Infof("CSR %q was not recognized", csr.name)
 		return reconcileStop, nil
@@ -92,11 +97,104 @@ func (r *csrRenewalReconciler) Reconcile(ctx context.Context, csr csrInfo, appro
 	return reconcileStop, nil
 }
 
+// csrAcceptedClusterRenewalReconciler auto approves the renewal csr of an accepted managed cluster. The csr is
+// approved only when it is requested by the agent of the same cluster and the requesting user is authorized to renew
+// its client certificate by the SubjectAccessReview. The renewal csr of a cluster which is not accepted is left
+// pending without being passed to the other reconcilers, while the csrs of the bootstrap users are left to them.
+type csrAcceptedClusterRenewalReconciler struct {
+	kubeClient    kubernetes.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+	signerNames   sets.Set[string]
+	eventRecorder events.Recorder
+}
+
+func NewCSRAcceptedClusterRenewalReconciler(kubeClient kubernetes.Interface,
+	clusterLister clusterv1listers.ManagedClusterLister,
+	signerNames []string,
+	recorder events.Recorder) Reconciler {
+	return &csrAcceptedClusterRenewalReconciler{
+		kubeClient:    kubeClient,
+		clusterLister: clusterLister,
+		signerNames:   sets.New(signerNames...),
+		eventRecorder: recorder.WithComponentSuffix("csr-approving-controller"),
+	}
+}
+
+func (r *csrAcceptedClusterRenewalReconciler) Reconcile(
+	ctx context.Context, csr csrInfo, approveCSR approveCSRFunc) (reconcileState, error) {
+	valid, clusterName, commonName := validateCSR(csr, r.signerNames)
+	if !valid {
+		klog.V(4).Infof("CSR %q was not recognized", csr.name)
+		return reconcileStop, nil
+	}
+
+	if !isRenewalRequestOfCluster(csr, clusterName, commonName) {
+		return reconcileContinue, nil
+	}
+
+	cluster, err := r.clusterLister.Get(clusterName)
+	if errors.IsNotFound(err) {
+		klog.V(4).Infof("Managed cluster csr %q cannot be auto approved since cluster %q is not found", csr.name, clusterName)
+		return reconcileStop, nil
+	}
+	if err != nil {
+		return reconcileContinue, err
+	}
+
+	if !isAcceptedCluster(cluster) {
+		klog.V(4).Infof("Managed cluster csr %q cannot be auto approved since cluster %q is not accepted", csr.name, clusterName)
+		return reconcileStop, nil
+	}
+
+	// Authorize whether the current spoke agent has been authorized to renew its csr.
+	allowed, err := authorize(ctx, r.kubeClient, csr)
+	if err != nil {
+		return reconcileContinue, err
+	}
+	if !allowed {
+		klog.V(4).Infof("Managed cluster csr %q cannont be auto approved due to subject access review was not approved", csr.name)
+		return reconcileStop, nil
+	}
+
+	if err := approveCSR(r.kubeClient); err != nil {
+		return reconcileContinue, err
+	}
+
+	r.eventRecorder.Eventf("ManagedClusterCSRAutoApproved",
+		"spoke cluster csr %q is auto approved by hub csr controller since cluster %q is accepted", csr.name, clusterName)
+	return reconcileStop, nil
+}
+
+// isRenewalRequestOfCluster checks whether the csr is requested by the agent of the cluster with its client
+// certificate. The common name must be <prefix><cluster name>:<agent name>, and the requesting user must be the
+// common name. The groups of the requesting user are checked by the SubjectAccessReview.
+func isRenewalRequestOfCluster(csr csrInfo, clusterName, commonName string) bool {
+	if csr.username != commonName {
+		return false
+	}
+
+	clusterGroup := fmt.Sprintf("%s%s", user.SubjectPrefix, clusterName)
+	agentName := strings.TrimPrefix(commonName, clusterGroup+":")
+	return agentName != commonName && len(agentName) != 0 && !strings.Contains(agentName, ":")
+}
+
+// isAcceptedCluster checks whether the cluster is accepted by the hub and not being deleted.
+func isAcceptedCluster(cluster *clusterv1.ManagedCluster) bool {
+	if !cluster.DeletionTimestamp.IsZero() {
+		return false
+	}
+	if !cluster.Spec.HubAcceptsClient {
+		return false
+	}
+	return meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionHubAccepted)
+}
+
 type csrBootstrapReconciler struct {
 	kubeClient    kubernetes.Interface
 	clusterClient clusterclientset.Interface
 	clusterLister clusterv1listers.ManagedClusterLister
 	approvalUsers sets.Set[string]
+	signerNames   sets.Set[string]
 	eventRecorder events.Recorder
 }
 
@@ -104,19 +202,21 @@ func NewCSRBootstrapReconciler(kubeClient kubernetes.Interface,
 	clusterClient clusterclientset.Interface,
 	clusterLister clusterv1listers.ManagedClusterLister,
 	approvalUsers []string,
+	signerNames []string,
 	recorder events.Recorder) Reconciler {
 	return &csrBootstrapReconciler{
 		kubeClient:    kubeClient,
 		clusterClient: clusterClient,
 		clusterLister: clusterLister,
 		approvalUsers: sets.New(approvalUsers...),
+		signerNames:   sets.New(signerNames...),
 		eventRecorder: recorder.WithComponentSuffix("csr-approving-controller"),
 	}
 }
 
 func (b *csrBootstrapReconciler) Reconcile(ctx context.Context, csr csrInfo, approveCSR approveCSRFunc) (reconcileState, error) {
 	// Check whether current csr is a valid spoker cluster csr.
-	valid, clusterName, _ := validateCSR(csr)
+	valid, clusterName, _ := validateCSR(csr, b.signerNames)
 	if !valid {
 		klog.V(4).Infof("CSR %q was not recognized", csr.name)
 		return reconcileStop, nil
@@ -161,15 +261,16 @@ func (b *csrBootstrapReconciler) accpetCluster(ctx context.Context, managedClust
 }
 
 // To validate a managed cluster csr, we check
-// 1. if the signer name in csr request is valid.
+// 1. if the signer name in csr request is valid, it is either the kube-apiserver-client signer or one of the
+// signerNames.
 // 2. if organization field and commonName field in csr request is valid.
-func validateCSR(csr csrInfo) (bool, string, string) {
+func validateCSR(csr csrInfo, signerNames sets.Set[string]) (bool, string, string) {
 	spokeClusterName, existed := csr.labels[clusterv1.ClusterNameLabelKey]
 	if !existed {
 		return false, "", ""
 	}
 
-	if csr.signerName != certificatesv1.KubeAPIServerClientSignerName {
+	if csr.signerName != certificatesv1.KubeAPIServerClientSignerName && !signerNames.Has(csr.signerName) {
 		return false, "", ""
 	}
 
@@ -22,6 +22,7 @@ import (
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	"open-cluster-management.io/ocm/pkg/common/queue"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
 const leaseDurationTimes = 5
@@ -118,8 +119,8 @@ func (c *leaseController) sync(ctx context.Context, syncCtx factory.SyncContext)
 		gracePeriod = time.Duration(leaseDurationTimes*LeaseDurationSeconds) * time.Second
 	}
 
-	now := time.Now()
-	if !now.Before(observedLease.Spec.RenewTime.Add(gracePeriod)) {
+	_, freshness := helpers.CheckLeaseFreshnessWithGracePeriod(observedLease.Spec.RenewTime.Time, time.Now(), gracePeriod)
+	if freshness == helpers.LeaseExpired {
 		// the lease is not updated constantly, change the cluster available condition to unknown
 		if err := c.updateClusterStatus(ctx, cluster); err != nil {
 			return err
@@ -20,6 +20,7 @@ import (
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
 )
 
@@ -152,6 +153,74 @@ func TestSync(t *testing.T) {
 	}
 }
 
+// TestSyncWithLeaseFreshness runs the leases of each freshness through the hub lease controller, a cluster is marked
+// unknown only once its lease is expired, a degraded lease keeps the cluster available.
+func TestSyncWithLeaseFreshness(t *testing.T) {
+	now := time.Now()
+	gracePeriod := time.Duration(leaseDurationTimes*testinghelpers.TestLeaseDurationSeconds) * time.Second
+	cases := []struct {
+		name              string
+		renewTime         time.Time
+		expectedFreshness helpers.LeaseFreshness
+	}{
+		{name: "lease is just renewed", renewTime: now, expectedFreshness: helpers.LeaseFresh},
+		{
+			name:              "lease is renewed within the first half of the grace period",
+			renewTime:         now.Add(-gracePeriod/2 + time.Second),
+			expectedFreshness: helpers.LeaseFresh,
+		},
+		{name: "lease is degraded", renewTime: now.Add(-gracePeriod / 2), expectedFreshness: helpers.LeaseDegraded},
+		{
+			name:              "lease is renewed at the end of the grace period",
+			renewTime:         now.Add(-gracePeriod + time.Second),
+			expectedFreshness: helpers.LeaseDegraded,
+		},
+		{name: "lease is expired", renewTime: now.Add(-time.Hour), expectedFreshness: helpers.LeaseExpired},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewAvailableManagedCluster()
+			lease := testinghelpers.NewManagedClusterLease("managed-cluster-lease", c.renewTime)
+
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+				t.Fatal(err)
+			}
+			leaseClient := kubefake.NewSimpleClientset(lease)
+			leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
+			if err := leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore().Add(lease); err != nil {
+				t.Fatal(err)
+			}
+
+			syncCtx := testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)
+			ctrl := &leaseController{
+				kubeClient: leaseClient,
+				patcher: patcher.NewPatcher[
+					*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](
+					clusterClient.ClusterV1().ManagedClusters()),
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
+				eventRecorder: syncCtx.Recorder(),
+			}
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			clusterUnknown := len(clusterClient.Actions()) != 0
+
+			_, freshness := helpers.CheckLeaseFreshnessWithGracePeriod(c.renewTime, time.Now(), gracePeriod)
+			if freshness != c.expectedFreshness {
+				t.Errorf("expected the lease is %q, but got %q", c.expectedFreshness, freshness)
+			}
+			if expectedUnknown := c.expectedFreshness == helpers.LeaseExpired; clusterUnknown != expectedUnknown {
+				t.Errorf("expected the cluster is unknown %v with the %q lease, but got %v",
+					expectedUnknown, c.expectedFreshness, clusterUnknown)
+			}
+		})
+	}
+}
+
 func newDeletingManagedCluster() *clusterv1.ManagedCluster {
 	now := metav1.Now()
 	cluster := testinghelpers.NewAcceptedManagedCluster()
@@ -26,6 +26,7 @@ import (
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	"open-cluster-management.io/ocm/pkg/common/queue"
 	"open-cluster-management.io/ocm/pkg/registration/helpers"
+	"open-cluster-management.io/ocm/pkg/registration/hub/clusterjoin"
 )
 
 const (
@@ -143,6 +144,14 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 		return nil
 	}
 
+	// The join of current spoke cluster is rejected, do not accept it. The resources of an accepted spoke cluster
+	// are left alone, since the conflict may be reported only once the cluster claims are synced by its agent, the
+	// hub cluster-admin denies the spoke cluster if it is an impersonation.
+	if meta.IsStatusConditionTrue(managedCluster.Status.Conditions, clusterjoin.ManagedClusterConditionJoinRejected) &&
+		!meta.IsStatusConditionTrue(managedCluster.Status.Conditions, v1.ManagedClusterConditionHubAccepted) {
+		return nil
+	}
+
 	// TODO consider to add the managedcluster-namespace.yaml back to staticFiles,
 	// currently, we keep the namespace after the managed cluster is deleted.
 	// apply namespace at first
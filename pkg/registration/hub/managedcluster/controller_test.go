@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubeinformers "k8s.io/client-go/informers"
@@ -21,6 +22,7 @@ import (
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+	"open-cluster-management.io/ocm/pkg/registration/hub/clusterjoin"
 )
 
 func TestSyncManagedCluster(t *testing.T) {
@@ -147,3 +149,73 @@ func TestSyncManagedCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncJoinRejectedManagedCluster(t *testing.T) {
+	joinRejectedCondition := metav1.Condition{
+		Type:    clusterjoin.ManagedClusterConditionJoinRejected,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ClusterClaimConflicted",
+		Message: "conflicted",
+	}
+
+	newController := func(cluster *v1.ManagedCluster) (*managedClusterController, *clusterfake.Clientset, *kubefake.Clientset) {
+		clusterClient := clusterfake.NewSimpleClientset(cluster)
+		kubeClient := kubefake.NewSimpleClientset()
+		clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+		kubeInformer := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, time.Minute*10)
+		if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+			t.Fatal(err)
+		}
+		return &managedClusterController{
+			kubeClient,
+			clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+			apply.NewPermissionApplier(
+				kubeClient,
+				kubeInformer.Rbac().V1().Roles().Lister(),
+				kubeInformer.Rbac().V1().RoleBindings().Lister(),
+				kubeInformer.Rbac().V1().ClusterRoles().Lister(),
+				kubeInformer.Rbac().V1().ClusterRoleBindings().Lister(),
+			),
+			patcher.NewPatcher[*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](clusterClient.ClusterV1().ManagedClusters()),
+			eventstesting.NewTestingEventRecorder(t)}, clusterClient, kubeClient
+	}
+
+	// the rejected spoke cluster is not accepted
+	cluster := testinghelpers.NewAcceptingManagedCluster()
+	cluster.Status.Conditions = []metav1.Condition{joinRejectedCondition}
+	ctrl, clusterClient, kubeClient := newController(cluster)
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, clusterClient.Actions())
+	testingcommon.AssertNoActions(t, kubeClient.Actions())
+
+	// the spoke cluster is accepted, then its join is rejected once its cluster claims are synced
+	ctrl, clusterClient, _ = newController(testinghelpers.NewAcceptingManagedCluster())
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertActions(t, clusterClient.Actions(), "patch")
+	accepted := &v1.ManagedCluster{}
+	if err := json.Unmarshal(clusterClient.Actions()[0].(clienttesting.PatchAction).GetPatch(), accepted); err != nil {
+		t.Fatal(err)
+	}
+	if !meta.IsStatusConditionTrue(accepted.Status.Conditions, v1.ManagedClusterConditionHubAccepted) {
+		t.Errorf("expected the spoke cluster is accepted")
+	}
+
+	cluster = testinghelpers.NewAcceptedManagedCluster()
+	cluster.Status.Conditions = append(cluster.Status.Conditions, joinRejectedCondition)
+	ctrl, clusterClient, kubeClient = newController(cluster)
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	// the accepted spoke cluster is kept accepted and its resources are not removed
+	testingcommon.AssertNoActions(t, clusterClient.Actions())
+	for _, action := range kubeClient.Actions() {
+		if action.GetVerb() == "delete" {
+			t.Errorf("expected the resources of the accepted spoke cluster are kept, but got %s %s",
+				action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+}
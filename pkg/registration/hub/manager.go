@@ -29,6 +29,7 @@ import (
 	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/registration/helpers"
 	"open-cluster-management.io/ocm/pkg/registration/hub/addon"
+	"open-cluster-management.io/ocm/pkg/registration/hub/clusterjoin"
 	"open-cluster-management.io/ocm/pkg/registration/hub/clusterrole"
 	"open-cluster-management.io/ocm/pkg/registration/hub/csr"
 	"open-cluster-management.io/ocm/pkg/registration/hub/lease"
@@ -44,11 +45,24 @@ var ResyncInterval = 5 * time.Minute
 // HubManagerOptions holds configuration for hub manager controller
 type HubManagerOptions struct {
 	ClusterAutoApprovalUsers []string
+	ClusterUniquenessClaim   string
+	ClusterCSRSignerNames    []string
+
+	AcceptedClusterRenewalAutoApproval bool
+
+	AddOnUnavailableTaintKey      string
+	AddOnUnavailableTaintEffect   string
+	AddOnUnavailableTaintDebounce time.Duration
+	AddOnAvailableConditionType   string
 }
 
 // NewHubManagerOptions returns a HubManagerOptions
 func NewHubManagerOptions() *HubManagerOptions {
-	return &HubManagerOptions{}
+	return &HubManagerOptions{
+		AddOnUnavailableTaintEffect:   string(clusterv1.TaintEffectNoSelect),
+		AddOnUnavailableTaintDebounce: 5 * time.Minute,
+		AddOnAvailableConditionType:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+	}
 }
 
 // AddFlags registers flags for manager
@@ -56,7 +70,27 @@ func (m *HubManagerOptions) AddFlags(fs *pflag.FlagSet) {
 	features.DefaultHubRegistrationMutableFeatureGate.AddFlag(fs)
 	fs.StringSliceVar(&m.ClusterAutoApprovalUsers, "cluster-auto-approval-users", m.ClusterAutoApprovalUsers,
 		"A bootstrap user list whose cluster registration requests can be automatically approved.")
-
+	fs.StringVar(&m.ClusterUniquenessClaim, "cluster-uniqueness-claim", m.ClusterUniquenessClaim,
+		"The name of the cluster claim identifying a managed cluster, e.g. id.k8s.io. The join of a managed cluster "+
+			"whose claim value is same with an accepted managed cluster is rejected. The uniqueness is not validated if it is empty.")
+	fs.StringSliceVar(&m.ClusterCSRSignerNames, "cluster-csr-signer-names", m.ClusterCSRSignerNames,
+		"The signer names of the managed cluster csrs which are approved by the hub in addition to the "+
+			"kubernetes.io/kube-apiserver-client signer, e.g. the custom signer of the registration agent set with its flag "+
+			"--client-cert-signer-name. The approved csrs are signed by the custom signers.")
+	fs.BoolVar(&m.AcceptedClusterRenewalAutoApproval, "accepted-cluster-renewal-auto-approval", m.AcceptedClusterRenewalAutoApproval,
+		"If true, the renewal csr requested by the agent of an accepted managed cluster with its current client certificate "+
+			"is approved once the agent is authorized to renew its client certificate by the SubjectAccessReview.")
+	fs.StringVar(&m.AddOnUnavailableTaintKey, "addon-unavailable-taint-key", m.AddOnUnavailableTaintKey,
+		"The key of the taint added to a managed cluster once all of its add-ons are unavailable, so that the placement "+
+			"avoids the cluster. The taint is not maintained if it is empty.")
+	fs.StringVar(&m.AddOnUnavailableTaintEffect, "addon-unavailable-taint-effect", m.AddOnUnavailableTaintEffect,
+		"The effect of the add-on unavailable taint, one of NoSelect, PreferNoSelect and NoSelectIfNew.")
+	fs.DurationVar(&m.AddOnUnavailableTaintDebounce, "addon-unavailable-taint-debounce", m.AddOnUnavailableTaintDebounce,
+		"The duration that the aggregate availability of the add-ons on a managed cluster must be unchanged before the "+
+			"add-on unavailable taint is added or removed, so that the taint will not flap.")
+	fs.StringVar(&m.AddOnAvailableConditionType, "addon-available-condition-type", m.AddOnAvailableConditionType,
+		"The condition type of the add-ons which the add-on unavailable taint is determined with, it must be the same "+
+			"with the condition type reported by the add-on lease controller of the registration agents.")
 }
 
 // RunControllerManager starts the controllers on hub to manage spoke cluster registration.
@@ -123,19 +157,59 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 		controllerContext.EventRecorder,
 	)
 
+	var joinValidationController factory.Controller
+	if len(m.ClusterUniquenessClaim) != 0 {
+		joinValidationController = clusterjoin.NewJoinValidationController(
+			m.ClusterUniquenessClaim,
+			clusterClient,
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			controllerContext.EventRecorder,
+		)
+	}
+
 	taintController := taint.NewTaintController(
 		clusterClient,
 		clusterInformers.Cluster().V1().ManagedClusters(),
 		controllerContext.EventRecorder,
 	)
 
-	csrReconciles := []csr.Reconciler{csr.NewCSRRenewalReconciler(kubeClient, controllerContext.EventRecorder)}
+	var addOnTaintController factory.Controller
+	if len(m.AddOnUnavailableTaintKey) != 0 {
+		effect := clusterv1.TaintEffect(m.AddOnUnavailableTaintEffect)
+		switch effect {
+		case clusterv1.TaintEffectNoSelect, clusterv1.TaintEffectPreferNoSelect, clusterv1.TaintEffectNoSelectIfNew:
+		default:
+			return errors.Errorf("invalid add-on unavailable taint effect %q", m.AddOnUnavailableTaintEffect)
+		}
+		addOnTaintController = taint.NewAddOnTaintController(
+			clusterClient,
+			clusterInformers.Cluster().V1().ManagedClusters(),
+			addOnInformers.Addon().V1alpha1().ManagedClusterAddOns(),
+			clusterv1.Taint{Key: m.AddOnUnavailableTaintKey, Effect: effect},
+			m.AddOnAvailableConditionType,
+			m.AddOnUnavailableTaintDebounce,
+			controllerContext.EventRecorder,
+		)
+	}
+
+	var csrReconciles []csr.Reconciler
+	if m.AcceptedClusterRenewalAutoApproval {
+		csrReconciles = append(csrReconciles, csr.NewCSRAcceptedClusterRenewalReconciler(
+			kubeClient,
+			clusterInformers.Cluster().V1().ManagedClusters().Lister(),
+			m.ClusterCSRSignerNames,
+			controllerContext.EventRecorder,
+		))
+	}
+	csrReconciles = append(csrReconciles, csr.NewCSRRenewalReconciler(kubeClient, m.ClusterCSRSignerNames,
+		controllerContext.EventRecorder))
 	if features.DefaultHubRegistrationMutableFeatureGate.Enabled(ocmfeature.ManagedClusterAutoApproval) {
 		csrReconciles = append(csrReconciles, csr.NewCSRBootstrapReconciler(
 			kubeClient,
 			clusterClient,
 			clusterInformers.Cluster().V1().ManagedClusters().Lister(),
 			m.ClusterAutoApprovalUsers,
+			m.ClusterCSRSignerNames,
 			controllerContext.EventRecorder,
 		))
 	}
@@ -242,6 +316,12 @@ func (m *HubManagerOptions) RunControllerManager(ctx context.Context, controller
 
 	go managedClusterController.Run(ctx, 1)
 	go taintController.Run(ctx, 1)
+	if addOnTaintController != nil {
+		go addOnTaintController.Run(ctx, 1)
+	}
+	if joinValidationController != nil {
+		go joinValidationController.Run(ctx, 1)
+	}
 	go csrController.Run(ctx, 1)
 	go leaseController.Run(ctx, 1)
 	go rbacFinalizerController.Run(ctx, 1)
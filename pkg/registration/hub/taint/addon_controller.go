@@ -0,0 +1,164 @@
+package taint
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
+	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	informerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	listerv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/common/queue"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
+)
+
+// addOnTaintController adds a taint to the managed cluster once all of its addons are unavailable, and removes the
+// taint once any of its addons is available, so that the placement avoids the clusters whose addons are unhealthy.
+type addOnTaintController struct {
+	patcher       patcher.Patcher[*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus]
+	clusterLister listerv1.ManagedClusterLister
+	addOnLister   addonlisterv1alpha1.ManagedClusterAddOnLister
+	taint         v1.Taint
+	conditionType string
+	debounce      time.Duration
+	clock         clock.Clock
+	eventRecorder events.Recorder
+
+	// pendingSince records the time since when the taint of a cluster is expected to be changed, the taint is
+	// only changed once the expectation lasts for the debounce duration, so that the taint will not flap.
+	pendingSince     map[string]time.Time
+	pendingSinceLock sync.Mutex
+}
+
+// NewAddOnTaintController creates a new addon taint controller, the taint is added to or removed from a managed
+// cluster only if the aggregate availability of its addons is unchanged for the debounce duration. The availability
+// of an addon is read from its condition of the given type, which is the condition type reported by the addon lease
+// controller of the registration agents.
+func NewAddOnTaintController(
+	clusterClient clientset.Interface,
+	clusterInformer informerv1.ManagedClusterInformer,
+	addOnInformer addoninformerv1alpha1.ManagedClusterAddOnInformer,
+	taint v1.Taint,
+	conditionType string,
+	debounce time.Duration,
+	recorder events.Recorder) factory.Controller {
+	c := &addOnTaintController{
+		patcher: patcher.NewPatcher[
+			*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		clusterLister: clusterInformer.Lister(),
+		addOnLister:   addOnInformer.Lister(),
+		taint:         taint,
+		conditionType: conditionType,
+		debounce:      debounce,
+		clock:         clock.RealClock{},
+		eventRecorder: recorder.WithComponentSuffix("addon-taint-controller"),
+		pendingSince:  map[string]time.Time{},
+	}
+	return factory.New().
+		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, clusterInformer.Informer()).
+		WithInformersQueueKeysFunc(queue.QueueKeyByMetaNamespace, addOnInformer.Informer()).
+		WithSync(c.sync).
+		ToController("addOnTaintController", recorder)
+}
+
+func (c *addOnTaintController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling the addon taint of ManagedCluster %s", managedClusterName)
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		c.forgetPending(managedClusterName)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !managedCluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	addOns, err := c.addOnLister.ManagedClusterAddOns(managedClusterName).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	expectTaint := allAddOnsUnavailable(addOns, c.conditionType)
+	hasTaint := helpers.FindTaint(managedCluster.Spec.Taints, c.taint) != nil
+	if expectTaint == hasTaint {
+		c.forgetPending(managedClusterName)
+		return nil
+	}
+
+	if remaining := c.debounceRemaining(managedClusterName); remaining > 0 {
+		syncCtx.Queue().AddAfter(managedClusterName, remaining)
+		return nil
+	}
+
+	newManagedCluster := managedCluster.DeepCopy()
+	newTaints := newManagedCluster.Spec.Taints
+	if expectTaint {
+		helpers.AddTaints(&newTaints, c.taint)
+	} else {
+		helpers.RemoveTaints(&newTaints, c.taint)
+	}
+	newManagedCluster.Spec.Taints = newTaints
+	if _, err = c.patcher.PatchSpec(ctx, newManagedCluster, newManagedCluster.Spec, managedCluster.Spec); err != nil {
+		return err
+	}
+	c.forgetPending(managedClusterName)
+	c.eventRecorder.Eventf("ManagedClusterAddOnTaintUpdated", "Update the addon taint of managed cluster %s, "+
+		"all addons unavailable: %v, taints: %+v", managedClusterName, expectTaint, newTaints)
+	return nil
+}
+
+// allAddOnsUnavailable returns true if the cluster has addons and none of them is available with the condition type.
+func allAddOnsUnavailable(addOns []*addonv1alpha1.ManagedClusterAddOn, conditionType string) bool {
+	if len(addOns) == 0 {
+		return false
+	}
+	for _, addOn := range addOns {
+		if meta.IsStatusConditionTrue(addOn.Status.Conditions, conditionType) {
+			return false
+		}
+	}
+	return true
+}
+
+// debounceRemaining returns the remaining duration before the taint of the cluster can be changed, the pending
+// change starts once it is observed the first time.
+func (c *addOnTaintController) debounceRemaining(managedClusterName string) time.Duration {
+	if c.debounce <= 0 {
+		return 0
+	}
+
+	c.pendingSinceLock.Lock()
+	defer c.pendingSinceLock.Unlock()
+	since, ok := c.pendingSince[managedClusterName]
+	if !ok {
+		c.pendingSince[managedClusterName] = c.clock.Now()
+		return c.debounce
+	}
+	return c.debounce - c.clock.Since(since)
+}
+
+// forgetPending removes the pending change of the taint of the cluster
+func (c *addOnTaintController) forgetPending(managedClusterName string) {
+	c.pendingSinceLock.Lock()
+	defer c.pendingSinceLock.Unlock()
+	delete(c.pendingSince, managedClusterName)
+}
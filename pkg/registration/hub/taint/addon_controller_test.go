@@ -0,0 +1,244 @@
+package taint
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+var addOnUnavailableTaint = v1.Taint{
+	Key:    "addon.open-cluster-management.io/unavailable",
+	Effect: v1.TaintEffectNoSelect,
+}
+
+func newAddOnWithAvailability(name string, status metav1.ConditionStatus) *addonv1alpha1.ManagedClusterAddOn {
+	addOn := testinghelpers.NewFakeAddOn(name, "test")
+	addOn.Status.Conditions = []metav1.Condition{
+		{
+			Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+			Status: status,
+		},
+	}
+	return addOn
+}
+
+func newTestAddOnTaintController(t *testing.T, clusters []runtime.Object, addOns []runtime.Object,
+	debounce time.Duration) (*addOnTaintController, *clusterfake.Clientset, cache.Store, *clocktesting.FakeClock) {
+	clusterClient := clusterfake.NewSimpleClientset(clusters...)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+	for _, cluster := range clusters {
+		if err := clusterStore.Add(cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	addOnInformerFactory := addoninformers.NewSharedInformerFactory(addonfake.NewSimpleClientset(), time.Minute*10)
+	addOnStore := addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore()
+	for _, addOn := range addOns {
+		if err := addOnStore.Add(addOn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	ctrl := &addOnTaintController{
+		patcher: patcher.NewPatcher[
+			*v1.ManagedCluster, v1.ManagedClusterSpec, v1.ManagedClusterStatus](
+			clusterClient.ClusterV1().ManagedClusters()),
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		addOnLister:   addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+		taint:         addOnUnavailableTaint,
+		conditionType: addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		debounce:      debounce,
+		clock:         fakeClock,
+		eventRecorder: eventstesting.NewTestingEventRecorder(t),
+		pendingSince:  map[string]time.Time{},
+	}
+	return ctrl, clusterClient, addOnStore, fakeClock
+}
+
+func assertPatchedTaints(t *testing.T, action clienttesting.Action, expectedTaints []v1.Taint) {
+	t.Helper()
+	patchData := action.(clienttesting.PatchActionImpl).Patch
+	managedCluster := &v1.ManagedCluster{}
+	if err := json.Unmarshal(patchData, managedCluster); err != nil {
+		t.Fatal(err)
+	}
+	if len(managedCluster.Spec.Taints) == 0 && len(expectedTaints) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(managedCluster.Spec.Taints, expectedTaints) {
+		t.Errorf("expected taint %#v, but actualTaints: %#v", expectedTaints, managedCluster.Spec.Taints)
+	}
+}
+
+func TestSyncAddOnTaint(t *testing.T) {
+	taintedCluster := testinghelpers.NewAvailableManagedCluster()
+	taintedCluster.Spec.Taints = []v1.Taint{addOnUnavailableTaint}
+
+	cases := []struct {
+		name            string
+		clusters        []runtime.Object
+		addOns          []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:     "all addons are unavailable",
+			clusters: []runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+			addOns: []runtime.Object{
+				newAddOnWithAvailability("addon1", metav1.ConditionFalse),
+				newAddOnWithAvailability("addon2", metav1.ConditionUnknown),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertPatchedTaints(t, actions[0], []v1.Taint{addOnUnavailableTaint})
+			},
+		},
+		{
+			name:     "some addons are available",
+			clusters: []runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+			addOns: []runtime.Object{
+				newAddOnWithAvailability("addon1", metav1.ConditionFalse),
+				newAddOnWithAvailability("addon2", metav1.ConditionTrue),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:     "addon is available again",
+			clusters: []runtime.Object{taintedCluster},
+			addOns:   []runtime.Object{newAddOnWithAvailability("addon1", metav1.ConditionTrue)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertPatchedTaints(t, actions[0], []v1.Taint{})
+			},
+		},
+		{
+			name:     "no addons",
+			clusters: []runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:   "cluster is deleted",
+			addOns: []runtime.Object{newAddOnWithAvailability("addon1", metav1.ConditionFalse)},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl, clusterClient, _, _ := newTestAddOnTaintController(t, c.clusters, c.addOns, 0)
+			syncErr := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func TestSyncAddOnTaintWithConditionType(t *testing.T) {
+	// the addon is available with the condition type reported by the addon lease controller
+	addOn := newAddOnWithAvailability("addon1", metav1.ConditionFalse)
+	addOn.Status.Conditions = append(addOn.Status.Conditions, metav1.Condition{
+		Type:   "LeaseAvailable",
+		Status: metav1.ConditionTrue,
+	})
+	ctrl, clusterClient, _, _ := newTestAddOnTaintController(t,
+		[]runtime.Object{testinghelpers.NewAvailableManagedCluster()}, []runtime.Object{addOn}, 0)
+	ctrl.conditionType = "LeaseAvailable"
+
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, clusterClient.Actions())
+}
+
+func TestSyncAddOnTaintWithDebounce(t *testing.T) {
+	ctrl, clusterClient, _, fakeClock := newTestAddOnTaintController(t,
+		[]runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+		[]runtime.Object{newAddOnWithAvailability("addon1", metav1.ConditionFalse)}, time.Minute)
+	syncCtx := testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)
+
+	// the taint is not added until the addons are unavailable for the debounce duration
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, clusterClient.Actions())
+
+	fakeClock.Step(30 * time.Second)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, clusterClient.Actions())
+
+	fakeClock.Step(30 * time.Second)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertActions(t, clusterClient.Actions(), "patch")
+	assertPatchedTaints(t, clusterClient.Actions()[0], []v1.Taint{addOnUnavailableTaint})
+	if _, ok := ctrl.pendingSince[testinghelpers.TestManagedClusterName]; ok {
+		t.Errorf("expected the pending change is forgotten once the taint is added")
+	}
+}
+
+func TestSyncAddOnTaintFlapping(t *testing.T) {
+	ctrl, clusterClient, addOnStore, fakeClock := newTestAddOnTaintController(t,
+		[]runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+		[]runtime.Object{newAddOnWithAvailability("addon1", metav1.ConditionFalse)}, time.Minute)
+	syncCtx := testingcommon.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)
+
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	// the addon recovers within the debounce duration, the pending change is dropped
+	fakeClock.Step(30 * time.Second)
+	if err := addOnStore.Update(newAddOnWithAvailability("addon1", metav1.ConditionTrue)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if _, ok := ctrl.pendingSince[testinghelpers.TestManagedClusterName]; ok {
+		t.Errorf("expected the pending change is dropped once the addon recovers")
+	}
+
+	// the addon is unavailable again, the debounce duration restarts
+	fakeClock.Step(45 * time.Second)
+	if err := addOnStore.Update(newAddOnWithAvailability("addon1", metav1.ConditionFalse)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, clusterClient.Actions())
+}
@@ -0,0 +1,256 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/utils/clock"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// AvailabilityChecker checks the availability of an addon, it allows the addon lease controller to determine the
+// availability of an addon with more than its lease, e.g. probing a secret or configmap of the addon agent.
+type AvailabilityChecker interface {
+	// Check returns the available condition of the addon with its observed lease. The lease is nil if the addon
+	// lease is not found on the managed/management cluster nor the hub cluster.
+	Check(ctx context.Context, addOn *addonv1alpha1.ManagedClusterAddOn, lease *coordv1.Lease) (metav1.Condition, error)
+}
+
+// leaseAvailabilityChecker is the built-in AvailabilityChecker of the addon lease controller, an addon is available
+// if its lease is constantly renewed within the lease grace period.
+type leaseAvailabilityChecker struct {
+	clock                clock.Clock
+	leaseDurationTimes   int
+	clockSkewTolerance   time.Duration
+	startupPendingWindow time.Duration
+	leaseDefaults        leaseDefaults
+	conditionType        string
+	// clusterGracePeriod is the default grace period of the addon leases on the managed cluster, it is used for the
+	// addons without their own grace period or lease duration if it is positive.
+	clusterGracePeriod time.Duration
+	// useLeaseDurationSeconds derives the grace period from the lease duration declared by the lease itself
+	useLeaseDurationSeconds bool
+
+	clockRegressionTolerance time.Duration
+}
+
+// NewLeaseAvailabilityChecker returns the lease based AvailabilityChecker, so that a customized checker can combine
+// the lease freshness with its own probes. The LeaseDurationTimes, ClockSkewTolerance and StartupPendingWindow of
+// the options are honored by the checker, as well as the ClockRegressionTolerance, UseLeaseDurationSeconds,
+// ConditionType and Clock.
+func NewLeaseAvailabilityChecker(options AddOnLeaseControllerOptions) AvailabilityChecker {
+	if options.LeaseDurationTimes <= 0 {
+		options.LeaseDurationTimes = defaultLeaseDurationTimes
+	}
+	if len(options.ConditionType) == 0 {
+		options.ConditionType = addonv1alpha1.ManagedClusterAddOnConditionAvailable
+	}
+	if options.Clock == nil {
+		options.Clock = clock.RealClock{}
+	}
+	return &leaseAvailabilityChecker{
+		clock:                options.Clock,
+		leaseDurationTimes:   options.LeaseDurationTimes,
+		clockSkewTolerance:   options.ClockSkewTolerance,
+		startupPendingWindow: options.StartupPendingWindow,
+		conditionType:        options.ConditionType,
+
+		useLeaseDurationSeconds:  options.UseLeaseDurationSeconds,
+		clockRegressionTolerance: options.ClockRegressionTolerance,
+	}
+}
+
+func (l *leaseAvailabilityChecker) Check(_ context.Context,
+	addOn *addonv1alpha1.ManagedClusterAddOn, lease *coordv1.Lease) (metav1.Condition, error) {
+	if lease == nil && l.clock.Since(addOn.CreationTimestamp.Time) < l.startupPendingWindow {
+		// the addon agent may not create its lease yet
+		return metav1.Condition{
+			Type:    l.conditionType,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "ManagedClusterAddOnLeasePending",
+			Message: fmt.Sprintf("The status of %s add-on is unknown, waiting for its agent to create the lease.", addOn.Name),
+		}, nil
+	}
+
+	if lease == nil {
+		return getLeaseAvailableCondition(l.conditionType, addOn.Name, nil, l.clock.Now(), 0), nil
+	}
+
+	leaseConfig, err := getAddOnLeaseConfig(addOn)
+	if err != nil {
+		return metav1.Condition{}, err
+	}
+	applyLeaseDefaults(addOn, leaseConfig, l.leaseDefaults)
+
+	// a lease renewed in the future indicates the clock of the managed cluster has regressed, the lease is always
+	// fresh and cannot indicate the availability of the addon
+	if ahead := l.renewTimeAhead(lease); l.clockRegressionTolerance > 0 && ahead > l.clockRegressionTolerance {
+		return metav1.Condition{
+			Type:   l.conditionType,
+			Status: metav1.ConditionUnknown,
+			Reason: "ManagedClusterAddOnLeaseClockRegression",
+			Message: fmt.Sprintf("The status of %s add-on is unknown, its lease is renewed at %s which is ahead of "+
+				"the current time, the clock of the managed cluster may have regressed.",
+				addOn.Name, lease.Spec.RenewTime.UTC().Format(time.RFC3339)),
+		}, nil
+	}
+
+	// a leader election lease is available as long as it is renewed by any of the replicas
+	if leaseConfig.leaderElection {
+		now := l.clock.Now().Add(-l.clockSkewTolerance)
+		condition := getLeaseAvailableCondition(l.conditionType, addOn.Name, lease, now, l.gracePeriod(addOn, leaseConfig, lease))
+		if leader := leaseHolderIdentity(lease); len(leader) != 0 {
+			condition.Message = fmt.Sprintf("%s Its current leader is %s.", condition.Message, leader)
+		}
+		return condition, nil
+	}
+
+	// the lease renewed by an unexpected agent, e.g. two agents share the same lease name, cannot indicate the
+	// availability of the addon
+	if holderIdentity := leaseHolderIdentity(lease); len(leaseConfig.leaseHolderIdentity) != 0 &&
+		holderIdentity != leaseConfig.leaseHolderIdentity {
+		return metav1.Condition{
+			Type:   l.conditionType,
+			Status: metav1.ConditionFalse,
+			Reason: "ManagedClusterAddOnLeaseHolderMismatch",
+			Message: fmt.Sprintf("%s add-on is not available, its lease is held by %q instead of %q.",
+				addOn.Name, holderIdentity, leaseConfig.leaseHolderIdentity),
+		}, nil
+	}
+
+	// tolerate the clock skew by checking the lease against an earlier time
+	now := l.clock.Now().Add(-l.clockSkewTolerance)
+	return getLeaseAvailableCondition(l.conditionType, addOn.Name, lease, now, l.gracePeriod(addOn, leaseConfig, lease)), nil
+}
+
+// renewTimeAhead returns how far the renew time of the lease is ahead of the current time, it is not positive if
+// the lease is renewed in the past or has no renew time.
+func (l *leaseAvailabilityChecker) renewTimeAhead(lease *coordv1.Lease) time.Duration {
+	if lease.Spec.RenewTime == nil {
+		return 0
+	}
+	return lease.Spec.RenewTime.Sub(l.clock.Now())
+}
+
+// leaseHolderIdentity returns the holder identity of the lease, it is empty if the lease has no holder.
+func leaseHolderIdentity(lease *coordv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+// gracePeriod returns the grace period of the addon lease with the precedence:
+//  1. the addon-specific configuration, i.e. the lease-grace-seconds annotation of the addon, or the lease duration
+//     of the addon, which is declared by the lease itself if useLeaseDurationSeconds is set, or by the
+//     lease-duration-seconds annotation of the addon;
+//  2. the cluster-level grace period, i.e. the lease-grace-seconds annotation of the managed cluster;
+//  3. the lease duration of the ConfigMap addon-lease-defaults, or the package default.
+//
+// The lease duration is multiplied by the lease duration times of the ConfigMap addon-lease-defaults or the
+// controller.
+func (l *leaseAvailabilityChecker) gracePeriod(addOn *addonv1alpha1.ManagedClusterAddOn, leaseConfig *leaseConfig,
+	lease *coordv1.Lease) time.Duration {
+	leaseDurationTimes := l.leaseDurationTimes
+	if leaseConfig.leaseDurationTimes > 0 {
+		leaseDurationTimes = leaseConfig.leaseDurationTimes
+	}
+	leaseDurationSeconds := leaseConfig.leaseDurationSeconds
+	_, addOnLeaseDuration := addOn.Annotations[leaseDurationSecondsAnnotation]
+	if l.useLeaseDurationSeconds && lease != nil && lease.Spec.LeaseDurationSeconds != nil &&
+		*lease.Spec.LeaseDurationSeconds > 0 {
+		leaseDurationSeconds = int(*lease.Spec.LeaseDurationSeconds)
+		addOnLeaseDuration = true
+	}
+
+	defaultGracePeriod := time.Duration(leaseDurationTimes*leaseDurationSeconds) * time.Second
+	if !addOnLeaseDuration && l.clusterGracePeriod > 0 {
+		defaultGracePeriod = l.clusterGracePeriod
+	}
+	return getLeaseGracePeriod(leaseConfig, defaultGracePeriod)
+}
+
+// podAvailabilityChecker falls back to the agent pods of an addon if the addon has no lease, an addon is available
+// if one of its agent pods is running and ready. The lease is preferred once it exists.
+type podAvailabilityChecker struct {
+	leaseChecker      AvailabilityChecker
+	podLister         corev1listers.PodLister
+	conditionType     string
+	namespaceResolver installNamespaceResolver
+}
+
+// NewPodAvailabilityChecker returns an AvailabilityChecker for the addons whose agent does not maintain a lease.
+// The agent pods of an addon are selected by the annotation addon.open-cluster-management.io/agent-pod-selector
+// in the addon installation namespace, which is resolved from the AddOnDeploymentConfigInformer of the options if it
+// is set, and the lease based check is used for the addons without the annotation or with an observed lease.
+func NewPodAvailabilityChecker(options AddOnLeaseControllerOptions, podInformer corev1informers.PodInformer) AvailabilityChecker {
+	if len(options.ConditionType) == 0 {
+		options.ConditionType = addonv1alpha1.ManagedClusterAddOnConditionAvailable
+	}
+	return &podAvailabilityChecker{
+		leaseChecker:      NewLeaseAvailabilityChecker(options),
+		podLister:         podInformer.Lister(),
+		conditionType:     options.ConditionType,
+		namespaceResolver: newInstallNamespaceResolver(options.AddOnDeploymentConfigInformer),
+	}
+}
+
+func (p *podAvailabilityChecker) Check(ctx context.Context,
+	addOn *addonv1alpha1.ManagedClusterAddOn, lease *coordv1.Lease) (metav1.Condition, error) {
+	podSelector, ok := addOn.Annotations[agentPodSelectorAnnotation]
+	if lease != nil || !ok || len(podSelector) == 0 {
+		return p.leaseChecker.Check(ctx, addOn, lease)
+	}
+
+	selector, err := labels.Parse(podSelector)
+	if err != nil {
+		return metav1.Condition{}, fmt.Errorf("the agent pod selector %q of addon %q is invalid: %v", podSelector, addOn.Name, err)
+	}
+
+	pods, err := p.podLister.Pods(p.namespaceResolver.getAddOnInstallationNamespace(addOn)).List(selector)
+	if err != nil {
+		return metav1.Condition{}, err
+	}
+	if len(pods) == 0 {
+		return p.leaseChecker.Check(ctx, addOn, lease)
+	}
+
+	for _, pod := range pods {
+		if isPodRunningAndReady(pod) {
+			return metav1.Condition{
+				Type:    p.conditionType,
+				Status:  metav1.ConditionTrue,
+				Reason:  "ManagedClusterAddOnAgentPodReady",
+				Message: fmt.Sprintf("%s add-on is available, its agent pod %s is ready.", addOn.Name, pod.Name),
+			}, nil
+		}
+	}
+
+	return metav1.Condition{
+		Type:    p.conditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  "ManagedClusterAddOnAgentPodNotReady",
+		Message: fmt.Sprintf("%s add-on is not available, none of its agent pods is ready.", addOn.Name),
+	}, nil
+}
+
+// isPodRunningAndReady returns true if the pod is running and its Ready condition is true.
+func isPodRunningAndReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,484 @@
+package addon
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+type fakeAvailabilityChecker struct {
+	leaseChecker AvailabilityChecker
+	probeFailed  bool
+}
+
+func (f *fakeAvailabilityChecker) Check(ctx context.Context,
+	addOn *addonv1alpha1.ManagedClusterAddOn, lease *coordv1.Lease) (metav1.Condition, error) {
+	condition, err := f.leaseChecker.Check(ctx, addOn, lease)
+	if err != nil || condition.Status != metav1.ConditionTrue || !f.probeFailed {
+		return condition, err
+	}
+	return metav1.Condition{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionFalse,
+		Reason: "ProbeFailed",
+	}, nil
+}
+
+func TestAvailableConditionsWithConditionType(t *testing.T) {
+	checker := NewLeaseAvailabilityChecker(AddOnLeaseControllerOptions{ConditionType: "LeaseAvailable"})
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+
+	for _, lease := range []*coordv1.Lease{
+		nil,
+		testinghelpers.NewAddOnLease("test", "test", time.Now()),
+		testinghelpers.NewAddOnLease("test", "test", time.Now().Add(-10*time.Minute)),
+	} {
+		condition, err := checker.Check(context.TODO(), addOn, lease)
+		if err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+		if condition.Type != "LeaseAvailable" {
+			t.Errorf("expected the condition type %q, but got %q", "LeaseAvailable", condition.Type)
+		}
+	}
+
+	addOn.Annotations = map[string]string{forceStatusAnnotation: forceStatusUnavailable}
+	if forced, ok := getForcedAvailableCondition("LeaseAvailable", addOn); !ok || forced.Type != "LeaseAvailable" {
+		t.Errorf("expected the forced condition type %q, but got %v", "LeaseAvailable", forced)
+	}
+}
+
+func TestLeaseAvailabilityChecker(t *testing.T) {
+	checker := NewLeaseAvailabilityChecker(AddOnLeaseControllerOptions{})
+
+	cases := []struct {
+		name           string
+		holderIdentity string
+		leaseMode      string
+		lease          *coordv1.Lease
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "lease is not found",
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "ManagedClusterAddOnLeaseNotFound",
+		},
+		{
+			name:           "lease is fresh",
+			lease:          testinghelpers.NewAddOnLease("test", "test", time.Now()),
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+		},
+		{
+			name:           "lease is expired",
+			lease:          testinghelpers.NewAddOnLease("test", "test", time.Now().Add(-10*time.Minute)),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseUpdateStopped",
+		},
+		{
+			name:           "lease holder is expected",
+			holderIdentity: "agent1",
+			lease:          newHeldAddOnLease("agent1"),
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+		},
+		{
+			name:           "lease holder is mismatched",
+			holderIdentity: "agent1",
+			lease:          newHeldAddOnLease("agent2"),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseHolderMismatch",
+		},
+		{
+			name:           "lease has no holder",
+			holderIdentity: "agent1",
+			lease:          testinghelpers.NewAddOnLease("test", "test", time.Now()),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseHolderMismatch",
+		},
+		{
+			name:           "lease holder is not checked",
+			lease:          newHeldAddOnLease("agent2"),
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+		},
+		{
+			name:           "leader election lease is renewed by another leader",
+			holderIdentity: "agent1",
+			leaseMode:      leaseModeLeaderElection,
+			lease:          newHeldAddOnLease("agent2"),
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+		},
+		{
+			name:      "leader election lease is expired",
+			leaseMode: leaseModeLeaderElection,
+			lease: func() *coordv1.Lease {
+				lease := newHeldAddOnLease("agent1")
+				lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now().Add(-10 * time.Minute)}
+				return lease
+			}(),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseUpdateStopped",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = map[string]string{}
+			if len(c.holderIdentity) != 0 {
+				addOn.Annotations[leaseHolderIdentityAnnotation] = c.holderIdentity
+			}
+			if len(c.leaseMode) != 0 {
+				addOn.Annotations[leaseModeAnnotation] = c.leaseMode
+			}
+			condition, err := checker.Check(context.TODO(), addOn, c.lease)
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if condition.Status != c.expectedStatus {
+				t.Errorf("expected status %q, but got %q", c.expectedStatus, condition.Status)
+			}
+			if condition.Reason != c.expectedReason {
+				t.Errorf("expected reason %q, but got %q", c.expectedReason, condition.Reason)
+			}
+		})
+	}
+}
+
+func TestSyncWithAvailabilityChecker(t *testing.T) {
+	cases := []struct {
+		name           string
+		probeFailed    bool
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "probe succeeded",
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+		},
+		{
+			name:           "probe failed",
+			probeFailed:    true,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ProbeFailed",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl, addOnClient := newTestLeaseController(t,
+				[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+			ctrl.availabilityChecker = &fakeAvailabilityChecker{
+				leaseChecker: NewLeaseAvailabilityChecker(AddOnLeaseControllerOptions{}),
+				probeFailed:  c.probeFailed,
+			}
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], c.expectedStatus, c.expectedReason)
+		})
+	}
+}
+
+func newHeldAddOnLease(holderIdentity string) *coordv1.Lease {
+	lease := testinghelpers.NewAddOnLease("test", "test", time.Now())
+	lease.Spec.HolderIdentity = &holderIdentity
+	return lease
+}
+
+func TestLeaseAvailabilityCheckerWithStartupPendingWindow(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	checker := &leaseAvailabilityChecker{
+		clock:                fakeClock,
+		leaseDurationTimes:   defaultLeaseDurationTimes,
+		startupPendingWindow: 5 * time.Minute,
+	}
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	addOn.CreationTimestamp = metav1.NewTime(fakeClock.Now())
+
+	condition, err := checker.Check(context.TODO(), addOn, nil)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if condition.Status != metav1.ConditionUnknown || condition.Reason != "ManagedClusterAddOnLeasePending" {
+		t.Errorf("expected the addon is pending, but got %q/%q", condition.Status, condition.Reason)
+	}
+
+	// the lease is not found after the startup window
+	fakeClock.Step(6 * time.Minute)
+	condition, err = checker.Check(context.TODO(), addOn, nil)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if condition.Status != metav1.ConditionUnknown || condition.Reason != "ManagedClusterAddOnLeaseNotFound" {
+		t.Errorf("expected the addon lease is not found, but got %q/%q", condition.Status, condition.Reason)
+	}
+}
+
+func TestLeaseAvailabilityCheckerWithClockRegression(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	checker := &leaseAvailabilityChecker{
+		clock:                    fakeClock,
+		leaseDurationTimes:       defaultLeaseDurationTimes,
+		clockRegressionTolerance: time.Minute,
+	}
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+
+	cases := []struct {
+		name           string
+		renewTime      time.Time
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "lease is renewed in the past",
+			renewTime:      fakeClock.Now().Add(-10 * time.Second),
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+		},
+		{
+			name:           "lease is renewed in the future within the tolerance",
+			renewTime:      fakeClock.Now().Add(30 * time.Second),
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+		},
+		{
+			name:           "lease is renewed in the future beyond the tolerance",
+			renewTime:      fakeClock.Now().Add(time.Hour),
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "ManagedClusterAddOnLeaseClockRegression",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			condition, err := checker.Check(context.TODO(), addOn, testinghelpers.NewAddOnLease("test", "test", c.renewTime))
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if condition.Status != c.expectedStatus || condition.Reason != c.expectedReason {
+				t.Errorf("expected %q/%q, but got %q/%q", c.expectedStatus, c.expectedReason, condition.Status, condition.Reason)
+			}
+		})
+	}
+
+	// the detection is disabled without the tolerance
+	checker.clockRegressionTolerance = 0
+	condition, err := checker.Check(context.TODO(), addOn,
+		testinghelpers.NewAddOnLease("test", "test", fakeClock.Now().Add(time.Hour)))
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if condition.Reason != "ManagedClusterAddOnLeaseUpdated" {
+		t.Errorf("expected the clock regression is not detected, but got %q", condition.Reason)
+	}
+}
+
+func TestSyncWithClockRegression(t *testing.T) {
+	ctrl, addOnClient := newTestLeaseController(t,
+		[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now().Add(time.Hour))})
+	ctrl.clockRegressionTolerance = time.Minute
+
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	assertAvailableCondition(t, actions[0], metav1.ConditionUnknown, "ManagedClusterAddOnLeaseClockRegression")
+}
+
+func TestLeaseAvailabilityCheckerWithLeaseDurationSeconds(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	newLease := func(leaseDurationSeconds *int32) *coordv1.Lease {
+		lease := testinghelpers.NewAddOnLease("test", "test", fakeClock.Now().Add(-2*time.Minute))
+		lease.Spec.LeaseDurationSeconds = leaseDurationSeconds
+		return lease
+	}
+	declaredSeconds := int32(10)
+
+	cases := []struct {
+		name                    string
+		useLeaseDurationSeconds bool
+		lease                   *coordv1.Lease
+		expectedStatus          metav1.ConditionStatus
+	}{
+		{
+			name:           "declared duration is ignored by default",
+			lease:          newLease(&declaredSeconds),
+			expectedStatus: metav1.ConditionTrue,
+		},
+		{
+			name:                    "declared duration is honored",
+			useLeaseDurationSeconds: true,
+			lease:                   newLease(&declaredSeconds),
+			expectedStatus:          metav1.ConditionFalse,
+		},
+		{
+			name:                    "duration of the addon is used if the lease declares no duration",
+			useLeaseDurationSeconds: true,
+			lease:                   newLease(nil),
+			expectedStatus:          metav1.ConditionTrue,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			checker := &leaseAvailabilityChecker{
+				clock:                   fakeClock,
+				leaseDurationTimes:      defaultLeaseDurationTimes,
+				useLeaseDurationSeconds: c.useLeaseDurationSeconds,
+			}
+			condition, err := checker.Check(context.TODO(), addOn, c.lease)
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if condition.Status != c.expectedStatus {
+				t.Errorf("expected status %q, but got %q", c.expectedStatus, condition.Status)
+			}
+		})
+	}
+}
+
+func TestSyncWithLeaseDurationSeconds(t *testing.T) {
+	declaredSeconds := int32(10)
+	lease := testinghelpers.NewAddOnLease("test", "test", time.Now().Add(-2*time.Minute))
+	lease.Spec.LeaseDurationSeconds = &declaredSeconds
+	ctrl, addOnClient := newTestLeaseController(t,
+		[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")}, []runtime.Object{lease})
+	ctrl.useLeaseDurationSeconds = true
+
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	assertAvailableCondition(t, actions[0], metav1.ConditionFalse, "ManagedClusterAddOnLeaseUpdateStopped")
+}
+
+func TestPodAvailabilityChecker(t *testing.T) {
+	newPod := func(name string, phase corev1.PodPhase, ready corev1.ConditionStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test",
+				Labels:    map[string]string{"app": "agent"},
+			},
+			Status: corev1.PodStatus{
+				Phase:      phase,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+			},
+		}
+	}
+
+	cases := []struct {
+		name           string
+		podSelector    string
+		lease          *coordv1.Lease
+		pods           []*corev1.Pod
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "no pod selector",
+			pods:           []*corev1.Pod{newPod("agent", corev1.PodRunning, corev1.ConditionTrue)},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "ManagedClusterAddOnLeaseNotFound",
+		},
+		{
+			name:           "lease is preferred",
+			podSelector:    "app=agent",
+			lease:          testinghelpers.NewAddOnLease("test", "test", time.Now().Add(-10*time.Minute)),
+			pods:           []*corev1.Pod{newPod("agent", corev1.PodRunning, corev1.ConditionTrue)},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseUpdateStopped",
+		},
+		{
+			name:           "agent pod is ready",
+			podSelector:    "app=agent",
+			pods:           []*corev1.Pod{newPod("agent1", corev1.PodPending, corev1.ConditionFalse), newPod("agent2", corev1.PodRunning, corev1.ConditionTrue)},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnAgentPodReady",
+		},
+		{
+			name:           "agent pod is not ready",
+			podSelector:    "app=agent",
+			pods:           []*corev1.Pod{newPod("agent", corev1.PodRunning, corev1.ConditionFalse)},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnAgentPodNotReady",
+		},
+		{
+			name:           "agent pod is not found",
+			podSelector:    "app=other",
+			pods:           []*corev1.Pod{newPod("agent", corev1.PodRunning, corev1.ConditionTrue)},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "ManagedClusterAddOnLeaseNotFound",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			podInformer := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute).Core().V1().Pods()
+			for _, pod := range c.pods {
+				if err := podInformer.Informer().GetStore().Add(pod); err != nil {
+					t.Fatal(err)
+				}
+			}
+			checker := NewPodAvailabilityChecker(AddOnLeaseControllerOptions{}, podInformer)
+
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Spec.InstallNamespace = "test"
+			if len(c.podSelector) != 0 {
+				addOn.Annotations = map[string]string{agentPodSelectorAnnotation: c.podSelector}
+			}
+			condition, err := checker.Check(context.TODO(), addOn, c.lease)
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if condition.Status != c.expectedStatus {
+				t.Errorf("expected status %q, but got %q", c.expectedStatus, condition.Status)
+			}
+			if condition.Reason != c.expectedReason {
+				t.Errorf("expected reason %q, but got %q", c.expectedReason, condition.Reason)
+			}
+		})
+	}
+}
+
+func TestLeaseAvailabilityCheckerWithLeaderElection(t *testing.T) {
+	checker := NewLeaseAvailabilityChecker(AddOnLeaseControllerOptions{})
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	addOn.Annotations = map[string]string{leaseModeAnnotation: leaseModeLeaderElection}
+
+	condition, err := checker.Check(context.TODO(), addOn, newHeldAddOnLease("agent-7d9f"))
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if !strings.HasSuffix(condition.Message, "Its current leader is agent-7d9f.") {
+		t.Errorf("expected the leader in the message, but got %q", condition.Message)
+	}
+}
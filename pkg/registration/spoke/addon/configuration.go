@@ -5,10 +5,15 @@ import (
 	"crypto/sha256"
 	"crypto/x509/pkix"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/valyala/fasttemplate"
 	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 )
@@ -17,6 +22,42 @@ const (
 	defaultAddOnInstallationNamespace = "open-cluster-management-agent-addon"
 	// hostingClusterNameAnnotation is the annotation for indicating the hosting cluster name
 	hostingClusterNameAnnotation = "addon.open-cluster-management.io/hosting-cluster-name"
+	// leaseDurationSecondsAnnotation is the annotation for customizing the lease update interval of an addon agent
+	leaseDurationSecondsAnnotation = "addon.open-cluster-management.io/lease-duration-seconds"
+	// leaseNamespaceAnnotation is the annotation for indicating the namespace of the addon lease if the addon
+	// agent does not update its lease in the addon installation namespace
+	leaseNamespaceAnnotation = "addon.open-cluster-management.io/lease-namespace"
+	// leaseSelectorAnnotation is the annotation for indicating the label selector of the addon leases if the addon
+	// agent has multiple replicas and each of them maintains its own lease
+	leaseSelectorAnnotation = "addon.open-cluster-management.io/lease-selector"
+	// componentLeaseSelectorAnnotation is the annotation for indicating the label selector of the component leases if
+	// the addon has multiple components and each of them maintains its own lease, the addon is available only if all
+	// of its components are available
+	componentLeaseSelectorAnnotation = "addon.open-cluster-management.io/component-lease-selector"
+	// leaseGraceSecondsAnnotation is the annotation for overriding the grace period of the addon lease, an addon
+	// is considered unavailable if its lease is not updated within the grace period
+	leaseGraceSecondsAnnotation = "addon.open-cluster-management.io/lease-grace-seconds"
+	// leaseHolderIdentityAnnotation is the annotation for indicating the expected holder identity of the addon lease
+	leaseHolderIdentityAnnotation = "addon.open-cluster-management.io/lease-holder-identity"
+	// leaseResyncSecondsAnnotation is the annotation for overriding the resync interval of the addon lease, so that
+	// the lease of an addon can be checked more frequently than the others
+	leaseResyncSecondsAnnotation = "addon.open-cluster-management.io/lease-resync-seconds"
+	// leaseModeAnnotation is the annotation for indicating how the addon lease is maintained by the addon agent,
+	// see leaseModeLeaderElection
+	leaseModeAnnotation = "addon.open-cluster-management.io/lease-mode"
+	// leaseModeLeaderElection indicates the addon agent has multiple replicas and the addon lease is the leader
+	// election lease of the replicas, which is renewed by the current leader and its holder changes on failover
+	leaseModeLeaderElection = "LeaderElection"
+	// heartbeatConfigMapAnnotation is the annotation for indicating the name of the configmap in the lease namespace
+	// whose heartbeat key is updated by the addon agent instead of a lease, e.g. the agent is not allowed to write
+	// leases. The availability of the addon is determined by the heartbeat in the same way as the lease.
+	heartbeatConfigMapAnnotation = "addon.open-cluster-management.io/heartbeat-configmap"
+	// agentPodSelectorAnnotation is the annotation for indicating the label selector of the addon agent pods in
+	// the addon installation namespace, it is used by the pod availability checker if the addon has no lease
+	agentPodSelectorAnnotation = "addon.open-cluster-management.io/agent-pod-selector"
+	// agentVersionKey is the label or annotation of the addon lease for indicating the version of the addon agent
+	// which maintains the lease
+	agentVersionKey = "addon.open-cluster-management.io/version"
 )
 
 // registrationConfig contains necessary information for addon registration
@@ -39,6 +80,54 @@ type addonInstallOption struct {
 	AgentRunningOutsideManagedCluster bool   `json:"agentRunningOutsideManagedCluster"`
 }
 
+// leaseConfig contains necessary information for checking the lease of an addon
+type leaseConfig struct {
+	addOnName string
+
+	// leaseDurationSeconds is the interval that the addon agent updates its lease.
+	leaseDurationSeconds int
+
+	// leaseDurationTimes overrides the lease duration times of the controller to determine the grace period of the
+	// addon lease if it is positive.
+	leaseDurationTimes int
+
+	// leaseGraceSeconds overrides the grace period of the addon lease if it is positive.
+	leaseGraceSeconds int
+
+	// leaseNamespace is the namespace of the addon lease, it is the addon installation namespace by default.
+	leaseNamespace string
+
+	// leaseSelector selects the leases of the addon agent replicas. If it is nil, the addon has a single lease
+	// whose name is same with the addon name.
+	leaseSelector labels.Selector
+
+	// componentLeaseSelector selects the leases of the addon components. If it is set, the availability of the addon
+	// is aggregated from the leases of all of its components.
+	componentLeaseSelector labels.Selector
+
+	// leaseHolderIdentity is the expected holder identity of the addon lease. The holder identity is not checked
+	// if it is empty.
+	leaseHolderIdentity string
+
+	// leaderElection is true if the addon lease is a leader election lease, the holder identity of such a lease is
+	// not checked since any of the replicas may be the leader.
+	leaderElection bool
+
+	// heartbeatConfigMap is the name of the configmap from which the heartbeat of the addon agent is read instead
+	// of the addon lease. The addon lease is used if it is empty.
+	heartbeatConfigMap string
+
+	// resyncInterval is the interval to recheck the addon lease. The default resync interval of the controller is
+	// used if it is zero.
+	resyncInterval time.Duration
+
+	// messageTemplates are the templates of the available condition messages keyed by the condition status, the
+	// built-in message is used for the status without a template.
+	messageTemplates map[metav1.ConditionStatus]*fasttemplate.Template
+
+	addonInstallOption
+}
+
 func (c *registrationConfig) x509Subject(clusterName, agentName string) *pkix.Name {
 	subject := &pkix.Name{
 		CommonName:         c.registration.Subject.User,
@@ -62,6 +151,9 @@ func (c *registrationConfig) x509Subject(clusterName, agentName string) *pkix.Na
 // getAddOnInstallationNamespace returns addon installation namespace from addon spec.
 // It first checks the installation namespace in status then addon spec, the addon default
 // installation namespace open-cluster-management-agent-addon will be returned.
+//
+// The lease controller prefers the agent install namespace of the AddOnDeploymentConfig referenced by
+// the addon if the configs are read by the controller, see AddOnDeploymentConfigInformer.
 func getAddOnInstallationNamespace(addOn *addonv1alpha1.ManagedClusterAddOn) string {
 	installationNamespace := addOn.Status.Namespace
 	if installationNamespace == "" {
@@ -74,6 +166,13 @@ func getAddOnInstallationNamespace(addOn *addonv1alpha1.ManagedClusterAddOn) str
 	return installationNamespace
 }
 
+// isInstallationNamespaceEmpty returns true if the installation namespace of the addon is blank, e.g. a namespace of
+// whitespaces is reported in the status of the addon. The lease of such an addon cannot be located, so it is treated
+// as a misconfiguration rather than looked up in a namespace which does not exist.
+func isInstallationNamespaceEmpty(installOption addonInstallOption) bool {
+	return len(strings.TrimSpace(installOption.InstallationNamespace)) == 0
+}
+
 // isAddonRunningOutsideManagedCluster returns whether the addon agent is running on the managed cluster
 func isAddonRunningOutsideManagedCluster(addOn *addonv1alpha1.ManagedClusterAddOn) bool {
 	hostingCluster, ok := addOn.Annotations[hostingClusterNameAnnotation]
@@ -83,6 +182,49 @@ func isAddonRunningOutsideManagedCluster(addOn *addonv1alpha1.ManagedClusterAddO
 	return false
 }
 
+// addOnConfigUnresolvableError indicates the lease configuration of an addon cannot be resolved from the addon
+type addOnConfigUnresolvableError struct {
+	addOnName string
+	err       error
+}
+
+func (e *addOnConfigUnresolvableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *addOnConfigUnresolvableError) Unwrap() error {
+	return e.err
+}
+
+// isAddOnConfigUnresolvable returns true if the error is returned since the lease configuration of an addon
+// cannot be resolved
+func isAddOnConfigUnresolvable(err error) bool {
+	var unresolvableErr *addOnConfigUnresolvableError
+	return errors.As(err, &unresolvableErr)
+}
+
+// getAddOnLeaseConfig reads the addon and returns its lease configuration. If the lease duration seconds is
+// not specified by the annotation of the addon, AddOnLeaseControllerLeaseDurationSeconds will be used. If the lease
+// namespace is not specified by the annotation of the addon, the addon installation namespace will be used.
+// An addOnConfigUnresolvableError is returned if the lease configuration is invalid.
+func getAddOnLeaseConfig(addOn *addonv1alpha1.ManagedClusterAddOn) (*leaseConfig, error) {
+	config, err := resolveAddOnLeaseConfig(addOn)
+	if err != nil {
+		return nil, &addOnConfigUnresolvableError{addOnName: addOn.Name, err: err}
+	}
+	return config, nil
+}
+
+// resolveAddOnLeaseConfig returns the lease configuration of the addon, an error listing all of the invalid lease
+// annotations of the addon is returned if any of them is invalid.
+func resolveAddOnLeaseConfig(addOn *addonv1alpha1.ManagedClusterAddOn) (*leaseConfig, error) {
+	config, errs := validateLeaseAnnotations(addOn)
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("invalid annotations of addon %q: %w", addOn.Name, errs.ToAggregate())
+	}
+	return config, nil
+}
+
 // getRegistrationConfigs reads annotations of a addon and returns a map of registrationConfig whose
 // key is the hash of the registrationConfig
 func getRegistrationConfigs(addOn *addonv1alpha1.ManagedClusterAddOn) (map[string]registrationConfig, error) {
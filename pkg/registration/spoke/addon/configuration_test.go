@@ -2,6 +2,7 @@ package addon
 
 import (
 	"testing"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -142,6 +143,148 @@ func TestGetRegistrationConfigs(t *testing.T) {
 	}
 }
 
+func TestGetAddOnLeaseConfig(t *testing.T) {
+	cases := []struct {
+		name                          string
+		annotations                   map[string]string
+		statusNamespace               string
+		expectedInstallationNamespace string
+		expectedLeaseDurationSeconds  int
+		expectedLeaseNamespace        string
+		expectedResyncInterval        time.Duration
+		expectedLeaderElection        bool
+		expectedErr                   bool
+	}{
+		{
+			name:                         "default lease duration seconds",
+			expectedLeaseDurationSeconds: AddOnLeaseControllerLeaseDurationSeconds,
+			expectedLeaseNamespace:       "ns1",
+		},
+		{
+			name:                          "installation namespace reported in the status",
+			statusNamespace:               "ns3",
+			expectedInstallationNamespace: "ns3",
+			expectedLeaseDurationSeconds:  AddOnLeaseControllerLeaseDurationSeconds,
+			expectedLeaseNamespace:        "ns3",
+		},
+		{
+			name:                         "customized lease namespace",
+			annotations:                  map[string]string{leaseNamespaceAnnotation: "ns2"},
+			expectedLeaseDurationSeconds: AddOnLeaseControllerLeaseDurationSeconds,
+			expectedLeaseNamespace:       "ns2",
+		},
+		{
+			name:                         "customized lease duration seconds",
+			annotations:                  map[string]string{leaseDurationSecondsAnnotation: "120"},
+			expectedLeaseDurationSeconds: 120,
+			expectedLeaseNamespace:       "ns1",
+		},
+		{
+			name:        "invalid lease duration seconds",
+			annotations: map[string]string{leaseDurationSecondsAnnotation: "abc"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid lease selector",
+			annotations: map[string]string{leaseSelectorAnnotation: "app in (a"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid component lease selector",
+			annotations: map[string]string{componentLeaseSelectorAnnotation: "app in (a"},
+			expectedErr: true,
+		},
+		{
+			name: "component lease selector with lease selector",
+			annotations: map[string]string{
+				leaseSelectorAnnotation:          "app=test",
+				componentLeaseSelectorAnnotation: "app=test",
+			},
+			expectedErr: true,
+		},
+		{
+			name:                         "customized lease resync seconds",
+			annotations:                  map[string]string{leaseResyncSecondsAnnotation: "30"},
+			expectedLeaseDurationSeconds: AddOnLeaseControllerLeaseDurationSeconds,
+			expectedLeaseNamespace:       "ns1",
+			expectedResyncInterval:       30 * time.Second,
+		},
+		{
+			name:        "invalid lease resync seconds",
+			annotations: map[string]string{leaseResyncSecondsAnnotation: "0"},
+			expectedErr: true,
+		},
+		{
+			name:                         "leader election lease mode",
+			annotations:                  map[string]string{leaseModeAnnotation: leaseModeLeaderElection},
+			expectedLeaseDurationSeconds: AddOnLeaseControllerLeaseDurationSeconds,
+			expectedLeaseNamespace:       "ns1",
+			expectedLeaderElection:       true,
+		},
+		{
+			name:        "invalid lease mode",
+			annotations: map[string]string{leaseModeAnnotation: "Unknown"},
+			expectedErr: true,
+		},
+		{
+			name:        "negative lease duration seconds",
+			annotations: map[string]string{leaseDurationSecondsAnnotation: "-1"},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := &addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   testinghelpers.TestManagedClusterName,
+					Name:        "addon1",
+					Annotations: c.annotations,
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "ns1",
+				},
+				Status: addonv1alpha1.ManagedClusterAddOnStatus{
+					Namespace: c.statusNamespace,
+				},
+			}
+
+			config, err := getAddOnLeaseConfig(addOn)
+			if c.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, but failed")
+				}
+				if !isAddOnConfigUnresolvable(err) {
+					t.Errorf("expected config unresolvable error, but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			expectedInstallationNamespace := c.expectedInstallationNamespace
+			if len(expectedInstallationNamespace) == 0 {
+				expectedInstallationNamespace = "ns1"
+			}
+			if config.InstallationNamespace != expectedInstallationNamespace {
+				t.Errorf("expected installation namespace %q, but got %q", expectedInstallationNamespace, config.InstallationNamespace)
+			}
+			if config.leaseNamespace != c.expectedLeaseNamespace {
+				t.Errorf("expected lease namespace %q, but got %q", c.expectedLeaseNamespace, config.leaseNamespace)
+			}
+			if config.leaseDurationSeconds != c.expectedLeaseDurationSeconds {
+				t.Errorf("expected lease duration seconds %d, but got %d", c.expectedLeaseDurationSeconds, config.leaseDurationSeconds)
+			}
+			if config.resyncInterval != c.expectedResyncInterval {
+				t.Errorf("expected resync interval %v, but got %v", c.expectedResyncInterval, config.resyncInterval)
+			}
+			if config.leaderElection != c.expectedLeaderElection {
+				t.Errorf("expected leader election %t, but got %t", c.expectedLeaderElection, config.leaderElection)
+			}
+		})
+	}
+}
+
 func newRegistrationConfig(addOnName, addOnNamespace, signerName, commonName string, organization []string,
 	addOnAgentRunningOutsideManagedCluster bool) registrationConfig {
 	registration := addonv1alpha1.RegistrationConfig{
@@ -0,0 +1,63 @@
+package addon
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// expectedAgentVersionAnnotation is the annotation of an addon to specify the expected version of its agent, e.g. it
+// is set to the new version during a rolling upgrade of the agent. An addon whose lease is renewed by an agent of
+// another version is not available, so that an old agent renewing the lease cannot mask that the new agent failed
+// to start. The check requires the agent to report its version with the label addon.open-cluster-management.io/version
+// of the lease, and it is skipped if the version is not reported.
+const expectedAgentVersionAnnotation = "addon.open-cluster-management.io/expected-agent-version"
+
+// staleAgentVersions records the stale agent versions which have been reported for each addon
+type staleAgentVersions struct {
+	lock     sync.Mutex
+	versions map[string]string
+}
+
+// getStaleAgentVersionCondition returns the unavailable condition of an addon if its lease is renewed by an agent
+// whose version is not the expected one. A warning event is emitted the first time the stale version is found, and
+// emitted again if the addon turns to another stale version.
+func (c *managedClusterAddOnLeaseController) getStaleAgentVersionCondition(addOn *addonv1alpha1.ManagedClusterAddOn,
+	agentVersion string, recorder events.Recorder) (metav1.Condition, bool) {
+	c.staleAgentVersions.lock.Lock()
+	defer c.staleAgentVersions.lock.Unlock()
+
+	expectedVersion := addOn.Annotations[expectedAgentVersionAnnotation]
+	if len(expectedVersion) == 0 || len(agentVersion) == 0 || agentVersion == expectedVersion {
+		delete(c.staleAgentVersions.versions, addOn.Name)
+		return metav1.Condition{}, false
+	}
+
+	if c.staleAgentVersions.versions == nil {
+		c.staleAgentVersions.versions = map[string]string{}
+	}
+	if c.staleAgentVersions.versions[addOn.Name] != agentVersion {
+		c.staleAgentVersions.versions[addOn.Name] = agentVersion
+		recorder.Warningf("ManagedClusterAddOnLeaseStaleAgentVersion",
+			"The lease of addon %s on managed cluster %s is renewed by the agent of version %s instead of %s",
+			addOn.Name, c.clusterName, agentVersion, expectedVersion)
+	}
+	return metav1.Condition{
+		Type:   c.conditionType,
+		Status: metav1.ConditionFalse,
+		Reason: "ManagedClusterAddOnLeaseStaleAgentVersion",
+		Message: fmt.Sprintf("%s add-on is not available, its lease is renewed by the agent of version %s "+
+			"instead of the expected %s.", addOn.Name, agentVersion, expectedVersion),
+	}, true
+}
+
+// forgetStaleAgentVersion removes the reported stale agent version of the addon
+func (c *managedClusterAddOnLeaseController) forgetStaleAgentVersion(addOnName string) {
+	c.staleAgentVersions.lock.Lock()
+	defer c.staleAgentVersions.lock.Unlock()
+	delete(c.staleAgentVersions.versions, addOnName)
+}
@@ -0,0 +1,122 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithExpectedAgentVersion(t *testing.T) {
+	cases := []struct {
+		name            string
+		annotations     map[string]string
+		leaseVersion    string
+		leaseRenewTime  time.Time
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:           "expected version is not specified",
+			leaseVersion:   "v1",
+			leaseRenewTime: time.Now(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+			},
+		},
+		{
+			name:           "lease is renewed by the expected version",
+			annotations:    map[string]string{expectedAgentVersionAnnotation: "v2"},
+			leaseVersion:   "v2",
+			leaseRenewTime: time.Now(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+			},
+		},
+		{
+			name:           "lease is renewed by a stale version",
+			annotations:    map[string]string{expectedAgentVersionAnnotation: "v2"},
+			leaseVersion:   "v1",
+			leaseRenewTime: time.Now(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertAvailableCondition(t, actions[0], metav1.ConditionFalse, "ManagedClusterAddOnLeaseStaleAgentVersion")
+			},
+		},
+		{
+			name:           "lease does not report the version",
+			annotations:    map[string]string{expectedAgentVersionAnnotation: "v2"},
+			leaseRenewTime: time.Now(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+			},
+		},
+		{
+			name:           "stale lease of a stale version",
+			annotations:    map[string]string{expectedAgentVersionAnnotation: "v2"},
+			leaseVersion:   "v1",
+			leaseRenewTime: time.Now().Add(-10 * time.Minute),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertAvailableCondition(t, actions[0], metav1.ConditionFalse, "ManagedClusterAddOnLeaseUpdateStopped")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = c.annotations
+			lease := testinghelpers.NewAddOnLease("test", "test", c.leaseRenewTime)
+			if len(c.leaseVersion) != 0 {
+				lease.Labels = map[string]string{agentVersionKey: c.leaseVersion}
+			}
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{lease})
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			c.validateActions(t, addOnClient.Actions())
+		})
+	}
+}
+
+func TestGetStaleAgentVersionCondition(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	addOn.Annotations = map[string]string{expectedAgentVersionAnnotation: "v3"}
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+
+	recorder := events.NewInMemoryRecorder("test")
+
+	// the event is emitted once for each stale version, and again once the stale version is back after the
+	// expected version renews the lease
+	for i, version := range []string{"v1", "v1", "v2", "v3", "v2"} {
+		_, stale := ctrl.getStaleAgentVersionCondition(addOn, version, recorder)
+		if expected := version != "v3"; stale != expected {
+			t.Errorf("expected stale %v of version %d, but got %v", expected, i, stale)
+		}
+	}
+	staleEvents := 0
+	for _, event := range recorder.Events() {
+		if event.Reason == "ManagedClusterAddOnLeaseStaleAgentVersion" {
+			staleEvents++
+		}
+	}
+	if staleEvents != 3 {
+		t.Errorf("expected 3 stale events, but got %d", staleEvents)
+	}
+
+	ctrl.forgetStaleAgentVersion(addOn.Name)
+	if len(ctrl.staleAgentVersions.versions) != 0 {
+		t.Errorf("expected the stale version is forgotten, but got %v", ctrl.staleAgentVersions.versions)
+	}
+}
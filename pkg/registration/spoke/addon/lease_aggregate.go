@@ -0,0 +1,136 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// ManagedClusterConditionAllAddOnsAvailable is the condition type of the managed cluster indicating whether all of
+// the addons managed by the addon lease controller are available.
+const ManagedClusterConditionAllAddOnsAvailable = "AllAddOnsAvailable"
+
+// aggregateQueueKey is the queue key to update the aggregate available condition of the addons
+const aggregateQueueKey = "aggregate/addons/available"
+
+// addOnConditions records the last available condition of each addon.
+type addOnConditions struct {
+	lock       sync.Mutex
+	conditions map[string]metav1.Condition
+}
+
+func (w *addOnConditions) set(addOnName string, condition metav1.Condition) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.conditions == nil {
+		w.conditions = map[string]metav1.Condition{}
+	}
+	w.conditions[addOnName] = condition
+}
+
+func (w *addOnConditions) get(addOnName string) (metav1.Condition, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	condition, ok := w.conditions[addOnName]
+	return condition, ok
+}
+
+func (w *addOnConditions) forget(addOnName string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	delete(w.conditions, addOnName)
+}
+
+// getAllAddOnsAvailableCondition returns the aggregate available condition of the addons, the addons with customized
+// health check are included as well since their available condition is maintained by the addon manager. The
+// availability of each addon is read from its condition of the given type.
+func getAllAddOnsAvailableCondition(addOns []*addonv1alpha1.ManagedClusterAddOn, conditionType string) metav1.Condition {
+	unavailableAddOns := []string{}
+	for _, addOn := range addOns {
+		if !meta.IsStatusConditionTrue(addOn.Status.Conditions, conditionType) {
+			unavailableAddOns = append(unavailableAddOns, addOn.Name)
+		}
+	}
+
+	if len(unavailableAddOns) == 0 {
+		return metav1.Condition{
+			Type:    ManagedClusterConditionAllAddOnsAvailable,
+			Status:  metav1.ConditionTrue,
+			Reason:  "AllAddOnsAvailable",
+			Message: fmt.Sprintf("All of the %d add-ons are available.", len(addOns)),
+		}
+	}
+
+	sort.Strings(unavailableAddOns)
+	return metav1.Condition{
+		Type:    ManagedClusterConditionAllAddOnsAvailable,
+		Status:  metav1.ConditionFalse,
+		Reason:  "AddOnsUnavailable",
+		Message: fmt.Sprintf("Add-ons %s are not available.", strings.Join(unavailableAddOns, ", ")),
+	}
+}
+
+// enqueueAggregate enqueues the update of the aggregate available condition of the addons. The queue key is added
+// behind the addons enqueued before, and is deduplicated until it is processed.
+func (c *managedClusterAddOnLeaseController) enqueueAggregate(syncCtx factory.SyncContext) {
+	if c.clusterPatcher == nil || c.clusterLister == nil {
+		return
+	}
+	syncCtx.Queue().Add(aggregateQueueKey)
+}
+
+// updateAllAddOnsAvailableCondition updates the aggregate available condition of the addons on the managed cluster.
+// The addons excluded from the aggregate reporting are not counted, and the available condition last written by the
+// controller takes precedence over the one in the addon cache. It is a no-op if the managed cluster client or lister
+// is not set, and it is skipped until the informer caches are synced.
+func (c *managedClusterAddOnLeaseController) updateAllAddOnsAvailableCondition(ctx context.Context) error {
+	if c.clusterPatcher == nil || c.clusterLister == nil || c.isStatusUpdateDisabled(c.syncCtx.Recorder()) {
+		return nil
+	}
+
+	if !c.hasCachesSynced() {
+		// the aggregate condition is enqueued again by the next resync.
+		klog.V(4).InfoS("Skip updating the aggregate addons available condition", "cluster", c.clusterName,
+			"reason", "informer caches are not synced")
+		return nil
+	}
+
+	addOns, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).List(c.addOnSelector)
+	if err != nil {
+		return err
+	}
+	for i, addOn := range addOns {
+		if written, ok := c.writtenConditions.get(addOn.Name); ok {
+			addOns[i] = addOn.DeepCopy()
+			meta.SetStatusCondition(&addOns[i].Status.Conditions, written)
+		}
+	}
+
+	cluster, err := c.clusterLister.Get(c.clusterName)
+	if err != nil {
+		return err
+	}
+
+	newCluster := cluster.DeepCopy()
+	condition := getAllAddOnsAvailableCondition(c.aggregateExclusion.filter(addOns), c.conditionType)
+	meta.SetStatusCondition(&newCluster.Status.Conditions, condition)
+	if c.observeOnly {
+		klog.V(2).InfoS("Skip updating the aggregate addons available condition in observe only mode",
+			"cluster", c.clusterName, "status", condition.Status, "reason", condition.Reason)
+		return nil
+	}
+	_, err = c.clusterPatcher.PatchStatus(ctx, newCluster, newCluster.Status, cluster.Status)
+	if c.resyncBackoff != nil {
+		c.resyncBackoff.record(c.clock.Now(), err)
+	}
+	return err
+}
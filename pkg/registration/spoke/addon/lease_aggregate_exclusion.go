@@ -0,0 +1,51 @@
+package addon
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// aggregateExclusion selects the addons excluded from the aggregate reporting of the addons, e.g. the
+// infrastructure addons which should not count toward the health of the user-facing dashboards. The excluded addons
+// are still checked and their available conditions are still updated individually.
+type aggregateExclusion struct {
+	names    sets.Set[string]
+	selector labels.Selector
+}
+
+func newAggregateExclusion(names []string, selector labels.Selector) *aggregateExclusion {
+	if len(names) == 0 && selector == nil {
+		return nil
+	}
+	return &aggregateExclusion{
+		names:    sets.New[string](names...),
+		selector: selector,
+	}
+}
+
+// excludes returns true if the addon is excluded from the aggregate reporting, nothing is excluded by a nil exclusion.
+func (e *aggregateExclusion) excludes(addOn *addonv1alpha1.ManagedClusterAddOn) bool {
+	if e == nil {
+		return false
+	}
+	if e.names.Has(addOn.Name) {
+		return true
+	}
+	return e.selector != nil && e.selector.Matches(labels.Set(addOn.Labels))
+}
+
+// filter returns the addons which are not excluded from the aggregate reporting
+func (e *aggregateExclusion) filter(addOns []*addonv1alpha1.ManagedClusterAddOn) []*addonv1alpha1.ManagedClusterAddOn {
+	if e == nil {
+		return addOns
+	}
+	filtered := []*addonv1alpha1.ManagedClusterAddOn{}
+	for _, addOn := range addOns {
+		if !e.excludes(addOn) {
+			filtered = append(filtered, addOn)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,185 @@
+package addon
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestUpdateAllAddOnsAvailableCondition(t *testing.T) {
+	newAddOn := func(name string, status metav1.ConditionStatus) *addonv1alpha1.ManagedClusterAddOn {
+		addOn := testinghelpers.NewFakeAddOn(name, "test")
+		addOn.Status.Conditions = []metav1.Condition{
+			{Type: addonv1alpha1.ManagedClusterAddOnConditionAvailable, Status: status},
+		}
+		return addOn
+	}
+
+	cases := []struct {
+		name           string
+		addOns         []runtime.Object
+		exclusion      *aggregateExclusion
+		written        map[string]metav1.ConditionStatus
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "all addons are available",
+			addOns:         []runtime.Object{newAddOn("test1", metav1.ConditionTrue), newAddOn("test2", metav1.ConditionTrue)},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "AllAddOnsAvailable",
+		},
+		{
+			name:           "an addon is not available",
+			addOns:         []runtime.Object{newAddOn("test1", metav1.ConditionTrue), newAddOn("test2", metav1.ConditionUnknown)},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "AddOnsUnavailable",
+		},
+		{
+			name:           "an unavailable addon is excluded by name",
+			addOns:         []runtime.Object{newAddOn("test1", metav1.ConditionTrue), newAddOn("test2", metav1.ConditionUnknown)},
+			exclusion:      newAggregateExclusion([]string{"test2"}, nil),
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "AllAddOnsAvailable",
+		},
+		{
+			name: "an unavailable addon is excluded by label",
+			addOns: []runtime.Object{
+				newAddOn("test1", metav1.ConditionFalse),
+				newLabeledAddOn(newAddOn("test2", metav1.ConditionFalse), map[string]string{"platform": "true"}),
+			},
+			exclusion:      newAggregateExclusion(nil, labels.SelectorFromSet(labels.Set{"platform": "true"})),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "AddOnsUnavailable",
+		},
+		{
+			name:           "the written condition is not observed by the addon cache yet",
+			addOns:         []runtime.Object{newAddOn("test1", metav1.ConditionTrue), newAddOn("test2", metav1.ConditionUnknown)},
+			written:        map[string]metav1.ConditionStatus{"test2": metav1.ConditionTrue},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "AllAddOnsAvailable",
+		},
+		{
+			name:           "the written condition turns the addon unavailable",
+			addOns:         []runtime.Object{newAddOn("test1", metav1.ConditionTrue), newAddOn("test2", metav1.ConditionTrue)},
+			written:        map[string]metav1.ConditionStatus{"test1": metav1.ConditionFalse},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "AddOnsUnavailable",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl, _ := newTestLeaseController(t, c.addOns, []runtime.Object{})
+			clusterClient := newTestAggregateCluster(t, ctrl)
+			ctrl.aggregateExclusion = c.exclusion
+			for name, status := range c.written {
+				ctrl.writtenConditions.set(name, metav1.Condition{
+					Type: addonv1alpha1.ManagedClusterAddOnConditionAvailable, Status: status})
+			}
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, aggregateQueueKey)); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			actions := clusterClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			cluster := &clusterv1.ManagedCluster{}
+			if err := json.Unmarshal(actions[0].(clienttesting.PatchAction).GetPatch(), cluster); err != nil {
+				t.Fatal(err)
+			}
+			condition := meta.FindStatusCondition(cluster.Status.Conditions, ManagedClusterConditionAllAddOnsAvailable)
+			if condition == nil {
+				t.Fatalf("expected aggregate condition, but failed")
+			}
+			if condition.Status != c.expectedStatus || condition.Reason != c.expectedReason {
+				t.Errorf("expected aggregate condition %q/%q, but got %q/%q",
+					c.expectedStatus, c.expectedReason, condition.Status, condition.Reason)
+			}
+		})
+	}
+}
+
+func TestEnqueueAllAddOnsAvailableCondition(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+	clusterClient := newTestAggregateCluster(t, ctrl)
+
+	// the aggregate condition is enqueued behind the addons by the resync rather than computed in the resync
+	syncCtx := testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, clusterClient.Actions())
+	var queueKeys []string
+	for syncCtx.Queue().Len() > 0 {
+		key, _ := syncCtx.Queue().Get()
+		queueKeys = append(queueKeys, key.(string))
+		syncCtx.Queue().Done(key)
+	}
+	if len(queueKeys) != 2 || queueKeys[0] != "test/test" || queueKeys[1] != aggregateQueueKey {
+		t.Errorf("expected the addon and then the aggregate condition are enqueued, but got %v", queueKeys)
+	}
+
+	// the aggregate condition is not updated with the unsynced addon cache
+	ctrl.cachesSynced = []cache.InformerSynced{func() bool { return false }}
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, aggregateQueueKey)); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, clusterClient.Actions())
+
+	// the aggregate condition is enqueued once the availability of an addon changes
+	ctrl.cachesSynced = nil
+	syncCtx = testingcommon.NewFakeSyncContext(t, "test/test")
+	ctrl.syncCtx = syncCtx
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if syncCtx.Queue().Len() != 1 {
+		t.Fatalf("expected the aggregate condition is enqueued, but the queue has %d keys", syncCtx.Queue().Len())
+	}
+	if condition, ok := ctrl.writtenConditions.get("test"); !ok || condition.Status != metav1.ConditionTrue {
+		t.Errorf("expected the written condition of the addon is recorded, but got %v", condition)
+	}
+}
+
+// newTestAggregateCluster sets the managed cluster client and lister of the controller to maintain the aggregate
+// condition, and returns the fake client.
+func newTestAggregateCluster(t *testing.T, ctrl *managedClusterAddOnLeaseController) *clusterfake.Clientset {
+	cluster := testinghelpers.NewManagedCluster()
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+		t.Fatal(err)
+	}
+	ctrl.clusterLister = clusterInformerFactory.Cluster().V1().ManagedClusters().Lister()
+	ctrl.clusterClient = clusterClient.ClusterV1().ManagedClusters()
+	ctrl.clusterPatcher = patcher.NewPatcher[
+		*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](ctrl.clusterClient)
+	return clusterClient
+}
+
+func newLabeledAddOn(addOn *addonv1alpha1.ManagedClusterAddOn,
+	addOnLabels map[string]string) *addonv1alpha1.ManagedClusterAddOn {
+	addOn.Labels = addOnLabels
+	return addOn
+}
@@ -0,0 +1,168 @@
+package addon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// validateLeaseAnnotations parses and validates all of the recognized lease annotations of the addon in one place.
+// It returns the lease configuration resolved from the valid annotations and the errors of the invalid ones, the
+// annotations which are read by the availability checkers are validated as well, so that a misconfigured addon is
+// surfaced by its available condition instead of being evaluated with the defaults silently.
+func validateLeaseAnnotations(addOn *addonv1alpha1.ManagedClusterAddOn) (*leaseConfig, field.ErrorList) {
+	var errs field.ErrorList
+	annotationsPath := field.NewPath("metadata", "annotations")
+	annotations := addOn.Annotations
+
+	config := &leaseConfig{
+		addOnName:            addOn.Name,
+		leaseDurationSeconds: AddOnLeaseControllerLeaseDurationSeconds,
+		addonInstallOption: addonInstallOption{
+			AgentRunningOutsideManagedCluster: isAddonRunningOutsideManagedCluster(addOn),
+			InstallationNamespace:             getAddOnInstallationNamespace(addOn),
+		},
+	}
+
+	if value, ok := annotations[leaseDurationSecondsAnnotation]; ok {
+		seconds, err := parsePositiveSeconds(annotationsPath.Key(leaseDurationSecondsAnnotation), value)
+		errs = append(errs, err...)
+		if len(err) == 0 {
+			config.leaseDurationSeconds = seconds
+		}
+	}
+
+	config.leaseNamespace = config.InstallationNamespace
+	if value := annotations[leaseNamespaceAnnotation]; len(value) != 0 {
+		if msgs := validation.IsDNS1123Label(value); len(msgs) != 0 {
+			errs = append(errs, field.Invalid(annotationsPath.Key(leaseNamespaceAnnotation), value,
+				strings.Join(msgs, "; ")))
+		} else {
+			config.leaseNamespace = value
+		}
+	}
+
+	if value := annotations[leaseSelectorAnnotation]; len(value) != 0 {
+		selector, err := parseSelector(annotationsPath.Key(leaseSelectorAnnotation), value)
+		errs = append(errs, err...)
+		config.leaseSelector = selector
+	}
+
+	if value := annotations[componentLeaseSelectorAnnotation]; len(value) != 0 {
+		path := annotationsPath.Key(componentLeaseSelectorAnnotation)
+		if len(annotations[leaseSelectorAnnotation]) != 0 {
+			errs = append(errs, field.Forbidden(path,
+				fmt.Sprintf("it cannot be specified with the annotation %q", leaseSelectorAnnotation)))
+		} else {
+			selector, err := parseSelector(path, value)
+			errs = append(errs, err...)
+			config.componentLeaseSelector = selector
+		}
+	}
+
+	if value, ok := annotations[leaseGraceSecondsAnnotation]; ok {
+		seconds, err := parsePositiveSeconds(annotationsPath.Key(leaseGraceSecondsAnnotation), value)
+		errs = append(errs, err...)
+		if len(err) == 0 {
+			config.leaseGraceSeconds = seconds
+		}
+	}
+
+	config.leaseHolderIdentity = annotations[leaseHolderIdentityAnnotation]
+
+	if value, ok := annotations[leaseModeAnnotation]; ok {
+		if value != leaseModeLeaderElection {
+			errs = append(errs, field.NotSupported(annotationsPath.Key(leaseModeAnnotation), value,
+				[]string{leaseModeLeaderElection}))
+		} else {
+			config.leaderElection = true
+		}
+	}
+
+	if value := annotations[heartbeatConfigMapAnnotation]; len(value) != 0 {
+		if msgs := validation.IsDNS1123Subdomain(value); len(msgs) != 0 {
+			errs = append(errs, field.Invalid(annotationsPath.Key(heartbeatConfigMapAnnotation), value,
+				strings.Join(msgs, "; ")))
+		} else {
+			config.heartbeatConfigMap = value
+		}
+	}
+
+	if value := annotations[agentPodSelectorAnnotation]; len(value) != 0 {
+		_, err := parseSelector(annotationsPath.Key(agentPodSelectorAnnotation), value)
+		errs = append(errs, err...)
+	}
+
+	if value, ok := annotations[leaseResyncSecondsAnnotation]; ok {
+		seconds, err := parsePositiveSeconds(annotationsPath.Key(leaseResyncSecondsAnnotation), value)
+		errs = append(errs, err...)
+		if len(err) == 0 {
+			config.resyncInterval = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if value, ok := annotations[forceStatusAnnotation]; ok && value != forceStatusAvailable &&
+		value != forceStatusUnavailable {
+		errs = append(errs, field.NotSupported(annotationsPath.Key(forceStatusAnnotation), value,
+			[]string{forceStatusAvailable, forceStatusUnavailable}))
+	}
+
+	if value, ok := annotations[leaseSuspendAnnotation]; ok && value != "true" && value != "false" {
+		errs = append(errs, field.NotSupported(annotationsPath.Key(leaseSuspendAnnotation), value,
+			[]string{"true", "false"}))
+	}
+
+	if value, ok := annotations[expectedAgentVersionAnnotation]; ok && len(strings.TrimSpace(value)) == 0 {
+		errs = append(errs, field.Required(annotationsPath.Key(expectedAgentVersionAnnotation),
+			"the expected agent version must not be empty"))
+	}
+
+	templates, templateErrs := parseMessageTemplates(annotationsPath, annotations)
+	errs = append(errs, templateErrs...)
+	config.messageTemplates = templates
+
+	if value, ok := annotations[leaseStickyAvailableAnnotation]; ok && value != "true" && value != "false" {
+		errs = append(errs, field.NotSupported(annotationsPath.Key(leaseStickyAvailableAnnotation), value,
+			[]string{"true", "false"}))
+	}
+
+	if value, ok := annotations[dependsOnAnnotation]; ok {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if len(name) == 0 {
+				continue
+			}
+			if msgs := validation.IsDNS1123Subdomain(name); len(msgs) != 0 {
+				errs = append(errs, field.Invalid(annotationsPath.Key(dependsOnAnnotation), name,
+					strings.Join(msgs, "; ")))
+			}
+		}
+	}
+
+	return config, errs
+}
+
+// parsePositiveSeconds parses the seconds of an annotation which must be a positive integer
+func parsePositiveSeconds(path *field.Path, value string) (int, field.ErrorList) {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0, field.ErrorList{field.Invalid(path, value, "must be a positive integer")}
+	}
+	return seconds, nil
+}
+
+// parseSelector parses the label selector of an annotation, nil is returned if the selector is invalid
+func parseSelector(path *field.Path, value string) (labels.Selector, field.ErrorList) {
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, field.ErrorList{field.Invalid(path, value, err.Error())}
+	}
+	return selector, nil
+}
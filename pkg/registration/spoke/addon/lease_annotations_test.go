@@ -0,0 +1,157 @@
+package addon
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestValidateLeaseAnnotations(t *testing.T) {
+	cases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedErrs   []string
+		validateConfig func(t *testing.T, config *leaseConfig)
+	}{
+		{
+			name: "no annotations",
+			validateConfig: func(t *testing.T, config *leaseConfig) {
+				if config.leaseDurationSeconds != AddOnLeaseControllerLeaseDurationSeconds || config.leaseNamespace != "test" {
+					t.Errorf("unexpected config %+v", config)
+				}
+			},
+		},
+		{
+			name:         "invalid lease duration seconds",
+			annotations:  map[string]string{leaseDurationSecondsAnnotation: "abc"},
+			expectedErrs: []string{leaseDurationSecondsAnnotation},
+		},
+		{
+			name:        "lease duration seconds",
+			annotations: map[string]string{leaseDurationSecondsAnnotation: "30"},
+			validateConfig: func(t *testing.T, config *leaseConfig) {
+				if config.leaseDurationSeconds != 30 {
+					t.Errorf("expected lease duration seconds 30, but got %d", config.leaseDurationSeconds)
+				}
+			},
+		},
+		{
+			name:         "invalid lease namespace",
+			annotations:  map[string]string{leaseNamespaceAnnotation: "Invalid_Namespace"},
+			expectedErrs: []string{leaseNamespaceAnnotation},
+		},
+		{
+			name:         "invalid lease selector",
+			annotations:  map[string]string{leaseSelectorAnnotation: "app in (a"},
+			expectedErrs: []string{leaseSelectorAnnotation},
+		},
+		{
+			name: "component lease selector with lease selector",
+			annotations: map[string]string{
+				leaseSelectorAnnotation:          "app=agent",
+				componentLeaseSelectorAnnotation: "component",
+			},
+			expectedErrs: []string{componentLeaseSelectorAnnotation},
+		},
+		{
+			name:         "invalid lease grace seconds",
+			annotations:  map[string]string{leaseGraceSecondsAnnotation: "0"},
+			expectedErrs: []string{leaseGraceSecondsAnnotation},
+		},
+		{
+			name:         "invalid lease mode",
+			annotations:  map[string]string{leaseModeAnnotation: "Replicas"},
+			expectedErrs: []string{leaseModeAnnotation},
+		},
+		{
+			name:         "invalid heartbeat configmap",
+			annotations:  map[string]string{heartbeatConfigMapAnnotation: "Heartbeat!"},
+			expectedErrs: []string{heartbeatConfigMapAnnotation},
+		},
+		{
+			name:         "invalid agent pod selector",
+			annotations:  map[string]string{agentPodSelectorAnnotation: "app in (a"},
+			expectedErrs: []string{agentPodSelectorAnnotation},
+		},
+		{
+			name:        "lease resync seconds",
+			annotations: map[string]string{leaseResyncSecondsAnnotation: "10"},
+			validateConfig: func(t *testing.T, config *leaseConfig) {
+				if config.resyncInterval != 10*time.Second {
+					t.Errorf("expected resync interval 10s, but got %v", config.resyncInterval)
+				}
+			},
+		},
+		{
+			name:         "invalid lease resync seconds",
+			annotations:  map[string]string{leaseResyncSecondsAnnotation: "-1"},
+			expectedErrs: []string{leaseResyncSecondsAnnotation},
+		},
+		{
+			name:         "invalid force status",
+			annotations:  map[string]string{forceStatusAnnotation: "Down"},
+			expectedErrs: []string{forceStatusAnnotation},
+		},
+		{
+			name:         "invalid lease suspend",
+			annotations:  map[string]string{leaseSuspendAnnotation: "yes"},
+			expectedErrs: []string{leaseSuspendAnnotation},
+		},
+		{
+			name:         "invalid message template",
+			annotations:  map[string]string{leaseMessageAnnotationPrefix + "false": "{{addonName}} on {{cluster}}"},
+			expectedErrs: []string{leaseMessageAnnotationPrefix + "false"},
+		},
+		{
+			name:         "invalid lease sticky available",
+			annotations:  map[string]string{leaseStickyAvailableAnnotation: "always"},
+			expectedErrs: []string{leaseStickyAvailableAnnotation},
+		},
+		{
+			name:         "empty expected agent version",
+			annotations:  map[string]string{expectedAgentVersionAnnotation: " "},
+			expectedErrs: []string{expectedAgentVersionAnnotation},
+		},
+		{
+			name:         "invalid dependency",
+			annotations:  map[string]string{dependsOnAnnotation: "addon1, Addon_2"},
+			expectedErrs: []string{dependsOnAnnotation},
+		},
+		{
+			name: "multiple invalid annotations",
+			annotations: map[string]string{
+				leaseDurationSecondsAnnotation: "abc",
+				leaseGraceSecondsAnnotation:    "abc",
+				leaseHolderIdentityAnnotation:  "agent",
+			},
+			expectedErrs: []string{leaseDurationSecondsAnnotation, leaseGraceSecondsAnnotation},
+			validateConfig: func(t *testing.T, config *leaseConfig) {
+				if config.leaseHolderIdentity != "agent" {
+					t.Errorf("expected the valid annotations are resolved, but got %+v", config)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = c.annotations
+
+			config, errs := validateLeaseAnnotations(addOn)
+			if len(errs) != len(c.expectedErrs) {
+				t.Fatalf("expected %d errors, but got %v", len(c.expectedErrs), errs)
+			}
+			for i, expected := range c.expectedErrs {
+				if !strings.Contains(errs[i].Field, expected) {
+					t.Errorf("expected the error of annotation %q, but got %v", expected, errs[i])
+				}
+			}
+			if c.validateConfig != nil {
+				c.validateConfig(t, config)
+			}
+		})
+	}
+}
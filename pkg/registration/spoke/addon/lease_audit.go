@@ -0,0 +1,68 @@
+package addon
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// auditLogger appends the availability changes of the addons to a local file as json lines, so that an audit trail
+// of the addon availability is kept on the disk independent of the hub cluster.
+type auditLogger struct {
+	path string
+	lock sync.Mutex
+}
+
+// newAuditLogger returns an audit logger writing to the file, it returns nil if the path is empty.
+func newAuditLogger(path string) *auditLogger {
+	if len(path) == 0 {
+		return nil
+	}
+	return &auditLogger{path: path}
+}
+
+// write appends the change to the audit log file. The file is opened for each change, so that it can be rotated
+// by an external tool.
+func (l *auditLogger) write(change addOnAvailabilityChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// auditAvailabilityChange writes the availability transition of an addon to the audit log, the failure is logged
+// without blocking the reconciliation. It is a no-op if the audit log is not configured or the status of the addon
+// is unchanged.
+func (c *managedClusterAddOnLeaseController) auditAvailabilityChange(addOnName string,
+	oldStatus, newStatus metav1.ConditionStatus) {
+	if c.auditLogger == nil || oldStatus == newStatus {
+		return
+	}
+
+	change := addOnAvailabilityChange{
+		ClusterName: c.clusterName,
+		AddOnName:   addOnName,
+		OldStatus:   oldStatus,
+		NewStatus:   newStatus,
+		Timestamp:   metav1.NewTime(c.clock.Now()),
+	}
+	if err := c.auditLogger.write(change); err != nil {
+		klog.Warningf("Failed to write the availability change of addon %q of cluster %q to the audit log %q: %v",
+			addOnName, c.clusterName, c.auditLogger.path, err)
+	}
+}
@@ -0,0 +1,84 @@
+package addon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestAuditAvailabilityChange(t *testing.T) {
+	auditLogPath := filepath.Join(t.TempDir(), "audit.log")
+	ctrl, _ := newTestLeaseController(t,
+		[]runtime.Object{
+			testinghelpers.NewFakeAddOn("test1", "test"),
+			testinghelpers.NewFakeAddOn("test2", "test"),
+		},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test1", time.Now())})
+	ctrl.auditLogger = newAuditLogger(auditLogPath)
+
+	for _, name := range []string{"test1", "test2"} {
+		if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/"+name)); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+
+	f, err := os.Open(auditLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	changes := []addOnAvailabilityChange{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		change := addOnAvailabilityChange{}
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			t.Fatal(err)
+		}
+		changes = append(changes, change)
+	}
+
+	expected := map[string]metav1.ConditionStatus{
+		"test1": metav1.ConditionTrue,
+		"test2": metav1.ConditionUnknown,
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d audit records, but got %d", len(expected), len(changes))
+	}
+	for _, change := range changes {
+		if change.ClusterName != testinghelpers.TestManagedClusterName {
+			t.Errorf("unexpected cluster %q", change.ClusterName)
+		}
+		if change.OldStatus != "" || change.NewStatus != expected[change.AddOnName] {
+			t.Errorf("expected addon %s becomes %q, but got %q to %q",
+				change.AddOnName, expected[change.AddOnName], change.OldStatus, change.NewStatus)
+		}
+		if change.Timestamp.IsZero() {
+			t.Errorf("expected the timestamp of the audit record, but failed")
+		}
+	}
+}
+
+func TestAuditAvailabilityChangeWithUnwritableFile(t *testing.T) {
+	ctrl, addOnClient := newTestLeaseController(t,
+		[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+	// the parent directory of the audit log does not exist
+	ctrl.auditLogger = newAuditLogger(filepath.Join(t.TempDir(), "missing", "audit.log"))
+
+	// the reconciliation is not blocked by the audit log
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertActions(t, addOnClient.Actions(), "patch")
+}
@@ -0,0 +1,17 @@
+package addon
+
+import (
+	"time"
+)
+
+// AddOnAvailableCallback is called with the name of an addon and the time once the addon first becomes available
+// after it is installed
+type AddOnAvailableCallback func(addOnName string, availableTime time.Time)
+
+// notifyAddOnAvailable calls the available callback asynchronously, so that a slow callback does not block the sync
+func (c *managedClusterAddOnLeaseController) notifyAddOnAvailable(addOnName string) {
+	if c.availableCallback == nil {
+		return
+	}
+	go c.availableCallback(addOnName, c.clock.Now())
+}
@@ -0,0 +1,48 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithAvailableCallback(t *testing.T) {
+	ctrl, _ := newTestLeaseController(t,
+		[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+
+	availableAddOns := make(chan string, 2)
+	ctrl.availableCallback = func(addOnName string, availableTime time.Time) {
+		if !availableTime.Equal(ctrl.clock.Now()) {
+			t.Errorf("expected the available time %v, but got %v", ctrl.clock.Now(), availableTime)
+		}
+		availableAddOns <- addOnName
+	}
+
+	// the callback is called only once though the addon in the cache is still unknown
+	for i := 0; i < 2; i++ {
+		if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+
+	select {
+	case addOnName := <-availableAddOns:
+		if addOnName != "test" {
+			t.Errorf("expected the callback of addon %q, but got %q", "test", addOnName)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatalf("expected the callback is called")
+	}
+	select {
+	case addOnName := <-availableAddOns:
+		t.Errorf("expected the callback is called once, but it is called again with %q", addOnName)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,60 @@
+package addon
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
+	addonclientv1alpha1 "open-cluster-management.io/api/client/addon/clientset/versioned/typed/addon/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/common/patcher"
+)
+
+// backupHub is a backup hub cluster of the managed cluster, the available conditions of the addons are updated on
+// the backup hub as well, so that they are kept up to date on whichever hub is reachable.
+type backupHub struct {
+	addOnClient addonclientv1alpha1.ManagedClusterAddOnInterface
+	patcher     patcher.Patcher[
+		*addonv1alpha1.ManagedClusterAddOn, addonv1alpha1.ManagedClusterAddOnSpec, addonv1alpha1.ManagedClusterAddOnStatus]
+}
+
+func newBackupHubs(clusterName string, addOnClients []addonclient.Interface) []backupHub {
+	var hubs []backupHub
+	for _, addOnClient := range addOnClients {
+		client := addOnClient.AddonV1alpha1().ManagedClusterAddOns(clusterName)
+		hubs = append(hubs, backupHub{
+			addOnClient: client,
+			patcher: patcher.NewPatcher[
+				*addonv1alpha1.ManagedClusterAddOn, addonv1alpha1.ManagedClusterAddOnSpec, addonv1alpha1.ManagedClusterAddOnStatus](
+				client),
+		})
+	}
+	return hubs
+}
+
+// updateBackupHubs updates the available condition of the addon on each of the backup hubs, and returns the number
+// of the hubs on which the condition is up to date. The addon is read from each hub since it is not cached by the
+// controller, and the failures of the hubs are logged without blocking the others.
+func (c *managedClusterAddOnLeaseController) updateBackupHubs(ctx context.Context,
+	addOnName string, condition metav1.Condition) int {
+	succeeded := 0
+	for i, hub := range c.backupHubs {
+		addOn, err := hub.addOnClient.Get(ctx, addOnName, metav1.GetOptions{})
+		if err == nil {
+			newAddOn := addOn.DeepCopy()
+			meta.SetStatusCondition(&newAddOn.Status.Conditions, condition)
+			_, err = hub.patcher.PatchStatus(ctx, newAddOn, newAddOn.Status, addOn.Status)
+		}
+		if err != nil {
+			klog.Warningf("Failed to update the available condition of addon %q on the backup hub %d of cluster %q: %v",
+				addOnName, i, c.clusterName, err)
+			continue
+		}
+		succeeded++
+	}
+	return succeeded
+}
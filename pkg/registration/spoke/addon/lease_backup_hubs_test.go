@@ -0,0 +1,124 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithBackupHubs(t *testing.T) {
+	unavailable := errors.NewServiceUnavailable("hub is down")
+	cases := []struct {
+		name                  string
+		primaryErr            error
+		backupHubsErr         []error
+		expectErr             bool
+		expectedPatched       bool
+		expectedBackupPatched []bool
+	}{
+		{
+			name:                  "all hubs are updated",
+			backupHubsErr:         []error{nil, nil},
+			expectedPatched:       true,
+			expectedBackupPatched: []bool{true, true},
+		},
+		{
+			name:                  "the hub is unreachable",
+			primaryErr:            unavailable,
+			backupHubsErr:         []error{unavailable, nil},
+			expectedBackupPatched: []bool{false, true},
+		},
+		{
+			name:                  "a backup hub is unreachable",
+			backupHubsErr:         []error{unavailable},
+			expectedPatched:       true,
+			expectedBackupPatched: []bool{false},
+		},
+		{
+			name:                  "all hubs are unreachable",
+			primaryErr:            unavailable,
+			backupHubsErr:         []error{unavailable, unavailable},
+			expectErr:             true,
+			expectedBackupPatched: []bool{false, false},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+			if c.primaryErr != nil {
+				addOnClient.PrependReactor("patch", "managedclusteraddons",
+					func(action clienttesting.Action) (bool, runtime.Object, error) {
+						return true, nil, c.primaryErr
+					})
+			}
+
+			var backupClients []*addonfake.Clientset
+			for _, err := range c.backupHubsErr {
+				backupClient := addonfake.NewSimpleClientset(addOn.DeepCopy())
+				if err != nil {
+					backupClient.PrependReactor("patch", "managedclusteraddons",
+						func(action clienttesting.Action) (bool, runtime.Object, error) {
+							return true, nil, err
+						})
+				}
+				backupClients = append(backupClients, backupClient)
+				ctrl.backupHubs = append(ctrl.backupHubs, newBackupHubs(ctrl.clusterName,
+					[]addonclient.Interface{backupClient})...)
+			}
+
+			err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test"))
+			if c.expectErr && err == nil {
+				t.Errorf("expected an error, but got nil")
+			}
+			if !c.expectErr && err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			if c.expectedPatched {
+				addOn, err := addOnClient.Tracker().Get(addonv1alpha1.SchemeGroupVersion.WithResource("managedclusteraddons"),
+					ctrl.clusterName, "test")
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertAvailable(t, addOn.(*addonv1alpha1.ManagedClusterAddOn))
+			}
+			for i, backupClient := range backupClients {
+				backupAddOn, err := backupClient.AddonV1alpha1().ManagedClusterAddOns(ctrl.clusterName).Get(
+					context.TODO(), "test", metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				patched := len(backupAddOn.Status.Conditions) != 0
+				if patched != c.expectedBackupPatched[i] {
+					t.Errorf("expected the backup hub %d patched %t, but got %t", i, c.expectedBackupPatched[i], patched)
+				}
+				if patched {
+					assertAvailable(t, backupAddOn)
+				}
+			}
+		})
+	}
+}
+
+func assertAvailable(t *testing.T, addOn *addonv1alpha1.ManagedClusterAddOn) {
+	condition := meta.FindStatusCondition(addOn.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		t.Errorf("expected the addon is available, but got %v", condition)
+	}
+}
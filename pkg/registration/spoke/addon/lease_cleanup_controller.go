@@ -0,0 +1,211 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	coordv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
+	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+)
+
+const (
+	// leaseOnSpokeCluster and leaseOnManagementCluster indicate where the addon lease is located
+	leaseOnSpokeCluster      = "spoke"
+	leaseOnManagementCluster = "management"
+)
+
+// AddOnLeaseCleanupOptions is the options of the addon lease cleanup controller
+type AddOnLeaseCleanupOptions struct {
+	// DryRun makes the controller only log the leases that would be deleted.
+	DryRun bool
+
+	// FixedLeaseNamespace is the namespace of the leases of all the addons, it must be the same as the
+	// FixedLeaseNamespace of the addon lease controller, so that the leases read by the lease controller are located.
+	FixedLeaseNamespace string
+
+	// AddOnDeploymentConfigInformer is the AddOnDeploymentConfigInformer of the addon lease controller, so that the
+	// lease of an addon is located in the installation namespace resolved from its AddOnDeploymentConfig.
+	AddOnDeploymentConfigInformer informers.GenericInformer
+}
+
+// addOnLeaseCleanupController deletes the orphaned lease of an addon on the managed/management cluster once
+// the addon is deleted from the hub cluster. Only the lease named after the addon is deleted, the leases of the
+// addons selected by label selectors are kept, as well as the leases still read for the other addons.
+type addOnLeaseCleanupController struct {
+	clusterName           string
+	addOnLister           addonlisterv1alpha1.ManagedClusterAddOnLister
+	managementLeaseClient coordv1client.CoordinationV1Interface
+	spokeLeaseClient      coordv1client.CoordinationV1Interface
+	fixedLeaseNamespace   string
+	dryRun                bool
+	namespaceResolver     installNamespaceResolver
+}
+
+// NewAddOnLeaseCleanupController returns an instance of addOnLeaseCleanupController
+func NewAddOnLeaseCleanupController(clusterName string,
+	addOnInformer addoninformerv1alpha1.ManagedClusterAddOnInformer,
+	managementLeaseClient coordv1client.CoordinationV1Interface,
+	spokeLeaseClient coordv1client.CoordinationV1Interface,
+	options AddOnLeaseCleanupOptions,
+	recorder events.Recorder) factory.Controller {
+	c := &addOnLeaseCleanupController{
+		clusterName:           clusterName,
+		addOnLister:           addOnInformer.Lister(),
+		managementLeaseClient: managementLeaseClient,
+		spokeLeaseClient:      spokeLeaseClient,
+		fixedLeaseNamespace:   options.FixedLeaseNamespace,
+		dryRun:                options.DryRun,
+		namespaceResolver:     newInstallNamespaceResolver(options.AddOnDeploymentConfigInformer),
+	}
+
+	controllerFactory := factory.New().
+		WithInformersQueueKeyFunc(c.queueKeyFunc, addOnInformer.Informer()).
+		WithSync(c.sync)
+	if options.AddOnDeploymentConfigInformer != nil {
+		// the lease of an addon is located only once the AddOnDeploymentConfigs are synced
+		controllerFactory = controllerFactory.WithBareInformers(options.AddOnDeploymentConfigInformer.Informer())
+	}
+	return controllerFactory.ToController("AddOnLeaseCleanupController", recorder)
+}
+
+// queueKeyFunc returns the queue key of an addon in the format of <lease location>/<lease namespace>/<addon name>,
+// so that the lease of an addon can still be located after the addon is deleted.
+func (c *addOnLeaseCleanupController) queueKeyFunc(obj runtime.Object) string {
+	addOn, ok := obj.(*addonv1alpha1.ManagedClusterAddOn)
+	if !ok {
+		return ""
+	}
+
+	leaseConfig, err := c.getAddOnLeaseConfig(addOn)
+	if err != nil {
+		// the addon lease configuration is invalid, ignore it.
+		return ""
+	}
+	if leaseConfig.leaseSelector != nil || leaseConfig.componentLeaseSelector != nil {
+		// the leases selected by the label selectors are not named after the addon, ignore them.
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s/%s", leaseLocation(leaseConfig), leaseConfig.leaseNamespace, addOn.Name)
+}
+
+// getAddOnLeaseConfig returns the lease configuration of the addon, the installation namespace is resolved from the
+// AddOnDeploymentConfig of the addon, and the lease namespace is overridden by the fixed lease namespace if it is set.
+func (c *addOnLeaseCleanupController) getAddOnLeaseConfig(addOn *addonv1alpha1.ManagedClusterAddOn) (*leaseConfig, error) {
+	leaseConfig, err := c.namespaceResolver.getAddOnLeaseConfig(addOn)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.fixedLeaseNamespace) != 0 {
+		leaseConfig.leaseNamespace = c.fixedLeaseNamespace
+	}
+	return leaseConfig, nil
+}
+
+// leaseLocation returns where the lease of the addon is located
+func leaseLocation(leaseConfig *leaseConfig) string {
+	if leaseConfig.AgentRunningOutsideManagedCluster {
+		return leaseOnManagementCluster
+	}
+	return leaseOnSpokeCluster
+}
+
+// isLeaseInUse returns true if the lease is still read for one of the existing addons, e.g. it is selected by the
+// lease selector of another addon.
+func (c *addOnLeaseCleanupController) isLeaseInUse(location string, lease *coordv1.Lease) (bool, error) {
+	addOns, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, addOn := range addOns {
+		leaseConfig, err := c.getAddOnLeaseConfig(addOn)
+		if err != nil {
+			// the lease configuration of the addon is unknown, keep the lease in case it is read for the addon
+			return true, nil
+		}
+		if leaseLocation(leaseConfig) != location || leaseConfig.leaseNamespace != lease.Namespace {
+			continue
+		}
+		if addOn.Name == lease.Name {
+			return true, nil
+		}
+		for _, selector := range []labels.Selector{leaseConfig.leaseSelector, leaseConfig.componentLeaseSelector} {
+			if selector != nil && selector.Matches(labels.Set(lease.Labels)) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (c *addOnLeaseCleanupController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	parts := strings.Split(syncCtx.QueueKey(), "/")
+	if len(parts) != 3 {
+		// queue key is bad format, ignore it.
+		return nil
+	}
+	location, leaseNamespace, addOnName := parts[0], parts[1], parts[2]
+
+	_, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).Get(addOnName)
+	if err == nil {
+		// the addon still exists, keep its lease.
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	leaseClient := c.spokeLeaseClient
+	if location == leaseOnManagementCluster {
+		leaseClient = c.managementLeaseClient
+	}
+
+	lease, err := leaseClient.Leases(leaseNamespace).Get(ctx, addOnName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !lease.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	inUse, err := c.isLeaseInUse(location, lease)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		klog.V(4).Infof("Keep the lease %s/%s of the deleted addon %q, it is read for the other addons",
+			leaseNamespace, addOnName, addOnName)
+		return nil
+	}
+
+	if c.dryRun {
+		klog.Infof("Lease %s/%s of the deleted addon %q would be deleted (dry run)", leaseNamespace, addOnName, addOnName)
+		return nil
+	}
+
+	err = leaseClient.Leases(leaseNamespace).Delete(ctx, addOnName, metav1.DeleteOptions{
+		Preconditions: metav1.NewUIDPreconditions(string(lease.UID)),
+	})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	syncCtx.Recorder().Eventf("ManagedClusterAddOnLeaseDeleted",
+		"The lease %s/%s of the deleted addon %q is deleted", leaseNamespace, addOnName, addOnName)
+	return nil
+}
@@ -0,0 +1,218 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestLeaseCleanupQueueKeyFunc(t *testing.T) {
+	cases := []struct {
+		name                string
+		addOn               runtime.Object
+		fixedLeaseNamespace string
+		expectedQueueKey    string
+	}{
+		{
+			name:             "addon agent runs on the managed cluster",
+			addOn:            testinghelpers.NewFakeAddOn("test", "test"),
+			expectedQueueKey: "spoke/test/test",
+		},
+		{
+			name: "addon agent runs outside of the managed cluster",
+			addOn: &addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   testinghelpers.TestManagedClusterName,
+					Name:        "test",
+					Annotations: map[string]string{hostingClusterNameAnnotation: "cluster1"},
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "test"},
+			},
+			expectedQueueKey: "management/test/test",
+		},
+		{
+			name:                "fixed lease namespace",
+			addOn:               testinghelpers.NewFakeAddOn("test", "test"),
+			fixedLeaseNamespace: "leases",
+			expectedQueueKey:    "spoke/leases/test",
+		},
+		{
+			name: "leases selected by the label selector",
+			addOn: &addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   testinghelpers.TestManagedClusterName,
+					Name:        "test",
+					Annotations: map[string]string{leaseSelectorAnnotation: "app=test"},
+				},
+			},
+			expectedQueueKey: "",
+		},
+		{
+			name: "invalid lease configuration",
+			addOn: &addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   testinghelpers.TestManagedClusterName,
+					Name:        "test",
+					Annotations: map[string]string{leaseDurationSecondsAnnotation: "abc"},
+				},
+			},
+			expectedQueueKey: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := &addOnLeaseCleanupController{
+				clusterName:         testinghelpers.TestManagedClusterName,
+				fixedLeaseNamespace: c.fixedLeaseNamespace,
+			}
+			actualQueueKey := ctrl.queueKeyFunc(c.addOn)
+			if actualQueueKey != c.expectedQueueKey {
+				t.Errorf("expected queue key %q, but got %q", c.expectedQueueKey, actualQueueKey)
+			}
+		})
+	}
+}
+
+func TestLeaseCleanupSync(t *testing.T) {
+	cases := []struct {
+		name                      string
+		queueKey                  string
+		dryRun                    bool
+		addOns                    []runtime.Object
+		spokeLeases               []runtime.Object
+		managementLeases          []runtime.Object
+		validateSpokeActions      func(t *testing.T, actions []clienttesting.Action)
+		validateManagementActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:     "bad queue key",
+			queueKey: "test/test",
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:     "addon exists",
+			queueKey: "spoke/test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: "test"},
+			}},
+			spokeLeases: []runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:        "addon is deleted",
+			queueKey:    "spoke/test/test",
+			spokeLeases: []runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "delete")
+				testingcommon.AssertDelete(t, actions[1], "leases", "test", "test")
+			},
+		},
+		{
+			name:        "addon is deleted in dry run mode",
+			queueKey:    "spoke/test/test",
+			dryRun:      true,
+			spokeLeases: []runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name:     "addon is deleted and its lease is selected by another addon",
+			queueKey: "spoke/test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   testinghelpers.TestManagedClusterName,
+					Name:        "other",
+					Annotations: map[string]string{leaseSelectorAnnotation: "app=test"},
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "test"},
+			}},
+			spokeLeases: []runtime.Object{newLabeledAddOnLease("test", "test", time.Now(), map[string]string{"app": "test"})},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name:     "addon is deleted and another addon in the lease namespace is kept",
+			queueKey: "spoke/test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: "other"},
+				Spec:       addonv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "test"},
+			}},
+			spokeLeases: []runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "delete")
+				testingcommon.AssertDelete(t, actions[1], "leases", "test", "test")
+			},
+		},
+		{
+			name:     "addon is deleted and its lease is not found",
+			queueKey: "spoke/test/test",
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name:             "addon is deleted (on management cluster)",
+			queueKey:         "management/test/test",
+			managementLeases: []runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+			validateManagementActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "delete")
+				testingcommon.AssertDelete(t, actions[1], "leases", "test", "test")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOnClient := addonfake.NewSimpleClientset(c.addOns...)
+			addOnInformerFactory := addoninformers.NewSharedInformerFactory(addOnClient, time.Minute*10)
+			addOnStore := addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore()
+			for _, addOn := range c.addOns {
+				if err := addOnStore.Add(addOn); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			spokeLeaseClient := kubefake.NewSimpleClientset(c.spokeLeases...)
+			managementLeaseClient := kubefake.NewSimpleClientset(c.managementLeases...)
+
+			ctrl := &addOnLeaseCleanupController{
+				clusterName:           testinghelpers.TestManagedClusterName,
+				addOnLister:           addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				managementLeaseClient: managementLeaseClient.CoordinationV1(),
+				spokeLeaseClient:      spokeLeaseClient.CoordinationV1(),
+				dryRun:                c.dryRun,
+			}
+			syncCtx := testingcommon.NewFakeSyncContext(t, c.queueKey)
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			c.validateSpokeActions(t, spokeLeaseClient.Actions())
+			if c.validateManagementActions != nil {
+				c.validateManagementActions(t, managementLeaseClient.Actions())
+			}
+		})
+	}
+}
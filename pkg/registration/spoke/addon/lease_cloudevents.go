@@ -0,0 +1,106 @@
+package addon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// addOnAvailabilityChangedEventType is the type of the cloudevent published once the available condition of
+	// an addon is changed by the addon lease controller
+	addOnAvailabilityChangedEventType = "io.open-cluster-management.addon.availability.changed"
+
+	cloudEventPublishTimeout = 10 * time.Second
+)
+
+// addOnAvailabilityChange is the data of the addon availability changed cloudevent
+type addOnAvailabilityChange struct {
+	ClusterName string                 `json:"clusterName"`
+	AddOnName   string                 `json:"addOnName"`
+	OldStatus   metav1.ConditionStatus `json:"oldStatus,omitempty"`
+	NewStatus   metav1.ConditionStatus `json:"newStatus"`
+	Timestamp   metav1.Time            `json:"timestamp"`
+}
+
+// cloudEventPublisher publishes the cloudevents to a sink with the binary content mode of the cloudevents http
+// protocol binding.
+type cloudEventPublisher struct {
+	sinkURL string
+	source  string
+	client  *http.Client
+}
+
+// newCloudEventPublisher returns a publisher of the cloudevents, it returns nil if the sink url is empty.
+func newCloudEventPublisher(sinkURL, clusterName string) *cloudEventPublisher {
+	if len(sinkURL) == 0 {
+		return nil
+	}
+	return &cloudEventPublisher{
+		sinkURL: sinkURL,
+		source:  fmt.Sprintf("/clusters/%s/addon-lease-controller", clusterName),
+		client:  &http.Client{Timeout: cloudEventPublishTimeout},
+	}
+}
+
+func (p *cloudEventPublisher) publish(ctx context.Context, change addOnAvailabilityChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.sinkURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("ce-specversion", "1.0")
+	req.Header.Set("ce-id", string(uuid.NewUUID()))
+	req.Header.Set("ce-source", p.source)
+	req.Header.Set("ce-type", addOnAvailabilityChangedEventType)
+	req.Header.Set("ce-subject", change.AddOnName)
+	req.Header.Set("ce-time", change.Timestamp.UTC().Format(time.RFC3339))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the cloudevent sink %q responds %s", p.sinkURL, resp.Status)
+	}
+	return nil
+}
+
+// publishAvailabilityChange publishes the availability change of an addon in the background, so that the status
+// update is never blocked by the sink. It is a no-op if the cloudevent sink is not configured.
+func (c *managedClusterAddOnLeaseController) publishAvailabilityChange(addOnName string,
+	oldStatus, newStatus metav1.ConditionStatus) {
+	if c.cloudEventPublisher == nil {
+		return
+	}
+
+	change := addOnAvailabilityChange{
+		ClusterName: c.clusterName,
+		AddOnName:   addOnName,
+		OldStatus:   oldStatus,
+		NewStatus:   newStatus,
+		Timestamp:   metav1.NewTime(c.clock.Now()),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cloudEventPublishTimeout)
+		defer cancel()
+		if err := c.cloudEventPublisher.publish(ctx, change); err != nil {
+			klog.Warningf("Failed to publish the availability change of addon %q of cluster %q: %v",
+				addOnName, c.clusterName, err)
+		}
+	}()
+}
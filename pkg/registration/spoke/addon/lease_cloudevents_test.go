@@ -0,0 +1,58 @@
+package addon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestPublishAvailabilityChange(t *testing.T) {
+	changes := make(chan addOnAvailabilityChange, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("ce-type") != addOnAvailabilityChangedEventType {
+			t.Errorf("unexpected cloudevent type %q", r.Header.Get("ce-type"))
+		}
+		change := addOnAvailabilityChange{}
+		if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+		changes <- change
+	}))
+	defer server.Close()
+
+	ctrl, _ := newTestLeaseController(t,
+		[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+	ctrl.cloudEventPublisher = newCloudEventPublisher(server.URL, ctrl.clusterName)
+
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	select {
+	case change := <-changes:
+		if change.ClusterName != testinghelpers.TestManagedClusterName || change.AddOnName != "test" {
+			t.Errorf("unexpected addon %s/%s", change.ClusterName, change.AddOnName)
+		}
+		if change.OldStatus != "" || change.NewStatus != metav1.ConditionTrue {
+			t.Errorf("expected the addon becomes available, but got %q to %q", change.OldStatus, change.NewStatus)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected a cloudevent is published, but failed")
+	}
+}
+
+func TestPublishAvailabilityChangeWithoutSink(t *testing.T) {
+	if newCloudEventPublisher("", testinghelpers.TestManagedClusterName) != nil {
+		t.Errorf("expected no publisher without sink")
+	}
+}
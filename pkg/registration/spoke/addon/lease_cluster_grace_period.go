@@ -0,0 +1,36 @@
+package addon
+
+import (
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// getClusterGracePeriod returns the default grace period of the addon leases specified by the annotation
+// addon.open-cluster-management.io/lease-grace-seconds of the managed cluster, 0 is returned if the managed cluster
+// cannot be read, or the annotation is absent or invalid.
+func (c *managedClusterAddOnLeaseController) getClusterGracePeriod() time.Duration {
+	if c.clusterLister == nil {
+		return 0
+	}
+
+	cluster, err := c.clusterLister.Get(c.clusterName)
+	if err != nil {
+		klog.V(4).InfoS("Ignore the grace period of the managed cluster", "cluster", c.clusterName,
+			"reason", err.Error())
+		return 0
+	}
+
+	value, ok := cluster.Annotations[leaseGraceSecondsAnnotation]
+	if !ok {
+		return 0
+	}
+	graceSeconds, err := strconv.Atoi(value)
+	if err != nil || graceSeconds <= 0 {
+		klog.Warningf("Ignore the invalid annotation %q of managed cluster %q, the value %q must be a positive integer",
+			leaseGraceSecondsAnnotation, c.clusterName, value)
+		return 0
+	}
+	return time.Duration(graceSeconds) * time.Second
+}
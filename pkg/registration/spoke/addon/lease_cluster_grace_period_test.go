@@ -0,0 +1,96 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithClusterGracePeriod(t *testing.T) {
+	cases := []struct {
+		name               string
+		clusterAnnotations map[string]string
+		addOnAnnotations   map[string]string
+		expectedStatus     metav1.ConditionStatus
+		expectedReason     string
+	}{
+		{
+			name:           "default grace period",
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseUpdateStopped",
+		},
+		{
+			name:               "grace period of the cluster",
+			clusterAnnotations: map[string]string{leaseGraceSecondsAnnotation: "900"},
+			expectedStatus:     metav1.ConditionTrue,
+			expectedReason:     "ManagedClusterAddOnLeaseUpdated",
+		},
+		{
+			name:               "grace period of the addon is preferred",
+			clusterAnnotations: map[string]string{leaseGraceSecondsAnnotation: "900"},
+			addOnAnnotations:   map[string]string{leaseGraceSecondsAnnotation: "120"},
+			expectedStatus:     metav1.ConditionFalse,
+			expectedReason:     "ManagedClusterAddOnLeaseUpdateStopped",
+		},
+		{
+			name:               "lease duration of the addon is preferred to the longer grace period of the cluster",
+			clusterAnnotations: map[string]string{leaseGraceSecondsAnnotation: "900"},
+			addOnAnnotations:   map[string]string{leaseDurationSecondsAnnotation: "30"},
+			expectedStatus:     metav1.ConditionFalse,
+			expectedReason:     "ManagedClusterAddOnLeaseUpdateStopped",
+		},
+		{
+			name:               "lease duration of the addon is preferred to the shorter grace period of the cluster",
+			clusterAnnotations: map[string]string{leaseGraceSecondsAnnotation: "60"},
+			addOnAnnotations:   map[string]string{leaseDurationSecondsAnnotation: "600"},
+			expectedStatus:     metav1.ConditionTrue,
+			expectedReason:     "ManagedClusterAddOnLeaseUpdated",
+		},
+		{
+			name:               "invalid grace period of the cluster",
+			clusterAnnotations: map[string]string{leaseGraceSecondsAnnotation: "abc"},
+			expectedStatus:     metav1.ConditionFalse,
+			expectedReason:     "ManagedClusterAddOnLeaseUpdateStopped",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = c.addOnAnnotations
+			// the lease is stale with the default grace period of 5 minutes
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now().Add(-7*time.Minute))})
+
+			cluster := testinghelpers.NewManagedCluster()
+			cluster.Annotations = c.clusterAnnotations
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterfake.NewSimpleClientset(), 10*time.Minute)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+				t.Fatal(err)
+			}
+			ctrl.clusterLister = clusterInformerFactory.Cluster().V1().ManagedClusters().Lister()
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], c.expectedStatus, c.expectedReason)
+		})
+	}
+}
+
+func TestCachesSyncedWithManagedClusterInformer(t *testing.T) {
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterfake.NewSimpleClientset(), time.Minute*10)
+	assertCachesSyncedWithInformer(t, clusterInformerFactory,
+		AddOnLeaseControllerOptions{ManagedClusterInformer: clusterInformerFactory.Cluster().V1().ManagedClusters()})
+}
@@ -0,0 +1,24 @@
+package addon
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// collapsedUnknownReason is the reason of the false available condition collapsed from an unknown one
+const collapsedUnknownReason = "ManagedClusterAddOnLeaseStatusUnknown"
+
+// collapseUnknownCondition collapses the unknown available condition into a false one for the tooling which cannot
+// distinguish the unknown status, the original reason and message are kept in the message of the false condition.
+func collapseUnknownCondition(condition metav1.Condition) metav1.Condition {
+	if condition.Status != metav1.ConditionUnknown {
+		return condition
+	}
+
+	condition.Status = metav1.ConditionFalse
+	condition.Message = fmt.Sprintf("The availability of the add-on is unknown with the reason %s: %s",
+		condition.Reason, condition.Message)
+	condition.Reason = collapsedUnknownReason
+	return condition
+}
@@ -0,0 +1,93 @@
+package addon
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithCollapseUnknownStatus(t *testing.T) {
+	cases := []struct {
+		name            string
+		collapseUnknown bool
+		annotations     map[string]string
+		leaseRenewTime  time.Time
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+	}{
+		{
+			name:           "unknown condition is kept by default",
+			annotations:    map[string]string{leaseStickyAvailableAnnotation: "always"},
+			leaseRenewTime: time.Now(),
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "ManagedClusterAddOnConfigUnresolvable",
+		},
+		{
+			name:            "unknown condition is collapsed",
+			collapseUnknown: true,
+			annotations:     map[string]string{leaseStickyAvailableAnnotation: "always"},
+			leaseRenewTime:  time.Now(),
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  collapsedUnknownReason,
+		},
+		{
+			name:            "available condition is not collapsed",
+			collapseUnknown: true,
+			leaseRenewTime:  time.Now(),
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "ManagedClusterAddOnLeaseUpdated",
+		},
+		{
+			name:            "unavailable condition is not collapsed",
+			collapseUnknown: true,
+			leaseRenewTime:  time.Now().Add(-10 * time.Minute),
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "ManagedClusterAddOnLeaseUpdateStopped",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = c.annotations
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", c.leaseRenewTime)})
+			ctrl.collapseUnknownStatus = c.collapseUnknown
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], c.expectedStatus, c.expectedReason)
+		})
+	}
+}
+
+func TestCollapseUnknownCondition(t *testing.T) {
+	condition := collapseUnknownCondition(metav1.Condition{
+		Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status:  metav1.ConditionUnknown,
+		Reason:  "ManagedClusterAddOnLeaseNotFound",
+		Message: "The status of test add-on is unknown.",
+	})
+	if condition.Status != metav1.ConditionFalse {
+		t.Errorf("expected status %q, but got %q", metav1.ConditionFalse, condition.Status)
+	}
+	if condition.Reason != collapsedUnknownReason {
+		t.Errorf("expected reason %q, but got %q", collapsedUnknownReason, condition.Reason)
+	}
+	if !strings.Contains(condition.Message, "ManagedClusterAddOnLeaseNotFound") ||
+		!strings.Contains(condition.Message, "The status of test add-on is unknown.") {
+		t.Errorf("expected the original reason and message are kept, but got %q", condition.Message)
+	}
+}
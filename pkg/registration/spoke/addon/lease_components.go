@@ -0,0 +1,83 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	coordv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// listComponentLeases returns the leases of the components of an addon in the lease namespace
+func listComponentLeases(ctx context.Context, leaseClient coordv1client.CoordinationV1Interface,
+	leaseNamespace string, selector labels.Selector) ([]coordv1.Lease, error) {
+	leases, err := leaseClient.Leases(leaseNamespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	return leases.Items, nil
+}
+
+// latestLease returns the lease which is renewed most recently, it is nil if there is no lease.
+func latestLease(leases []coordv1.Lease) *coordv1.Lease {
+	var latest *coordv1.Lease
+	for i := range leases {
+		lease := &leases[i]
+		switch {
+		case latest == nil:
+			latest = lease
+		case lease.Spec.RenewTime == nil:
+		case latest.Spec.RenewTime == nil || lease.Spec.RenewTime.After(latest.Spec.RenewTime.Time):
+			latest = lease
+		}
+	}
+	return latest
+}
+
+// checkComponentLeases checks the lease of each component of an addon with the checker, and aggregates them into the
+// available condition of the addon. The addon is available only if all of its components are available, and it is
+// partially available if some of its components are available, which is distinguished by the condition reason.
+// The components are named after their leases, and the condition is of the given condition type.
+func checkComponentLeases(ctx context.Context, checker AvailabilityChecker, conditionType string,
+	addOn *addonv1alpha1.ManagedClusterAddOn, leases []coordv1.Lease) (metav1.Condition, error) {
+	var available, unavailable []string
+	for i := range leases {
+		condition, err := checker.Check(ctx, addOn, &leases[i])
+		if err != nil {
+			return metav1.Condition{}, err
+		}
+		if condition.Status == metav1.ConditionTrue {
+			available = append(available, leases[i].Name)
+			continue
+		}
+		unavailable = append(unavailable, fmt.Sprintf("%s (%s)", leases[i].Name, condition.Reason))
+	}
+	sort.Strings(available)
+	sort.Strings(unavailable)
+
+	condition := metav1.Condition{Type: conditionType}
+	switch {
+	case len(unavailable) == 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ManagedClusterAddOnComponentsAvailable"
+		condition.Message = fmt.Sprintf("%s add-on is available, all of its %d components are available: %s.",
+			addOn.Name, len(available), strings.Join(available, ", "))
+	case len(available) == 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ManagedClusterAddOnComponentsUnavailable"
+		condition.Message = fmt.Sprintf("%s add-on is not available, none of its %d components is available: %s.",
+			addOn.Name, len(unavailable), strings.Join(unavailable, ", "))
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ManagedClusterAddOnComponentsPartiallyAvailable"
+		condition.Message = fmt.Sprintf("%s add-on is partially available, %d of its %d components are available, "+
+			"the unavailable components: %s.", addOn.Name, len(available), len(leases), strings.Join(unavailable, ", "))
+	}
+	return condition, nil
+}
@@ -0,0 +1,87 @@
+package addon
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func newComponentLease(name string, renewTime time.Time) *coordv1.Lease {
+	lease := testinghelpers.NewAddOnLease("test", name, renewTime)
+	lease.Labels = map[string]string{"addon": "test"}
+	return lease
+}
+
+func TestSyncWithComponentLeases(t *testing.T) {
+	cases := []struct {
+		name            string
+		leases          []runtime.Object
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:           "no component lease",
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "ManagedClusterAddOnLeaseNotFound",
+		},
+		{
+			name: "all components are available",
+			leases: []runtime.Object{
+				newComponentLease("component1", time.Now()),
+				newComponentLease("component2", time.Now()),
+			},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "ManagedClusterAddOnComponentsAvailable",
+			expectedMessage: "component1, component2",
+		},
+		{
+			name: "components are partially available",
+			leases: []runtime.Object{
+				newComponentLease("component1", time.Now()),
+				newComponentLease("component2", time.Now().Add(-10*time.Minute)),
+			},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "ManagedClusterAddOnComponentsPartiallyAvailable",
+			expectedMessage: "component2 (ManagedClusterAddOnLeaseUpdateStopped)",
+		},
+		{
+			name: "none of the components is available",
+			leases: []runtime.Object{
+				newComponentLease("component1", time.Now().Add(-10*time.Minute)),
+				newComponentLease("component2", time.Now().Add(-10*time.Minute)),
+			},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnComponentsUnavailable",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = map[string]string{componentLeaseSelectorAnnotation: "addon=test"}
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, c.leases)
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], c.expectedStatus, c.expectedReason)
+
+			patch := string(actions[0].(clienttesting.PatchAction).GetPatch())
+			if !strings.Contains(patch, c.expectedMessage) {
+				t.Errorf("expected the message contains %q, but got %s", c.expectedMessage, patch)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package addon
+
+import (
+	"regexp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+const (
+	// maxConditionReasonLength and maxConditionMessageLength are the max lengths of the reason and message of a
+	// condition accepted by the API server
+	maxConditionReasonLength  = 1024
+	maxConditionMessageLength = 32768
+)
+
+// conditionReasonRegexp is the format of the reason of a condition accepted by the API server
+var conditionReasonRegexp = regexp.MustCompile(`^[A-Za-z]([A-Za-z0-9_,:]*[A-Za-z0-9_])?$`)
+
+// ConditionMutator transforms the available condition of an addon computed by the controller before it is written,
+// e.g. to inject the environment-specific context like region or tenant into the message.
+type ConditionMutator func(addOn *addonv1alpha1.ManagedClusterAddOn, condition metav1.Condition) metav1.Condition
+
+// mutateCondition applies the condition mutator of the controller to the condition. The mutation is bounded, the
+// type, status and times of the condition are kept as they are determined by the controller, an invalid reason is
+// discarded and a message which is too long is truncated, so that the condition is always accepted by the hub.
+func (c *managedClusterAddOnLeaseController) mutateCondition(addOn *addonv1alpha1.ManagedClusterAddOn,
+	condition metav1.Condition) metav1.Condition {
+	if c.conditionMutator == nil {
+		return condition
+	}
+
+	mutated := c.conditionMutator(addOn.DeepCopy(), condition)
+	mutated.Type = condition.Type
+	mutated.Status = condition.Status
+	mutated.ObservedGeneration = condition.ObservedGeneration
+	mutated.LastTransitionTime = condition.LastTransitionTime
+	if len(mutated.Reason) > maxConditionReasonLength || !conditionReasonRegexp.MatchString(mutated.Reason) {
+		klog.V(4).InfoS("Ignore the invalid reason of the mutated condition", "cluster", c.clusterName,
+			"addon", addOn.Name, "reason", mutated.Reason)
+		mutated.Reason = condition.Reason
+	}
+	if len(mutated.Message) > maxConditionMessageLength {
+		mutated.Message = mutated.Message[:maxConditionMessageLength]
+	}
+	return mutated
+}
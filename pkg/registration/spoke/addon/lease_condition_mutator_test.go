@@ -0,0 +1,95 @@
+package addon
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithConditionMutator(t *testing.T) {
+	cases := []struct {
+		name            string
+		mutator         ConditionMutator
+		expectedReason  string
+		validateMessage func(t *testing.T, message string)
+	}{
+		{
+			name:           "no mutator",
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+			validateMessage: func(t *testing.T, message string) {
+				if strings.Contains(message, "Region") {
+					t.Errorf("unexpected message %q", message)
+				}
+			},
+		},
+		{
+			name: "append the region",
+			mutator: func(_ *addonv1alpha1.ManagedClusterAddOn, condition metav1.Condition) metav1.Condition {
+				condition.Message += " Region: us-east-1."
+				condition.Reason = "AvailableInRegion"
+				return condition
+			},
+			expectedReason: "AvailableInRegion",
+			validateMessage: func(t *testing.T, message string) {
+				if !strings.HasSuffix(message, " Region: us-east-1.") {
+					t.Errorf("unexpected message %q", message)
+				}
+			},
+		},
+		{
+			name: "invalid mutation",
+			mutator: func(_ *addonv1alpha1.ManagedClusterAddOn, condition metav1.Condition) metav1.Condition {
+				return metav1.Condition{
+					Type:    "Other",
+					Status:  "Maybe",
+					Reason:  "not a valid reason",
+					Message: strings.Repeat("a", maxConditionMessageLength+1),
+				}
+			},
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+			validateMessage: func(t *testing.T, message string) {
+				if len(message) != maxConditionMessageLength {
+					t.Errorf("expected the message is truncated, but got %d characters", len(message))
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+			ctrl.conditionMutator = c.mutator
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			// the type and status of the condition are not changed by the mutator
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], metav1.ConditionTrue, c.expectedReason)
+
+			actual, err := addOnClient.AddonV1alpha1().ManagedClusterAddOns(ctrl.clusterName).Get(
+				context.TODO(), "test", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			condition := meta.FindStatusCondition(actual.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable)
+			if condition == nil {
+				t.Fatalf("expected the available condition, but got %v", actual.Status.Conditions)
+			}
+			c.validateMessage(t, condition.Message)
+		})
+	}
+}
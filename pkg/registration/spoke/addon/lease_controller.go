@@ -2,45 +2,464 @@ package addon
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	coordv1 "k8s.io/api/coordination/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	corev1informers "k8s.io/client-go/informers/core/v1"
 	coordv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
 
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
+	addonclientv1alpha1 "open-cluster-management.io/api/client/addon/clientset/versioned/typed/addon/v1alpha1"
 	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
 	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1client "open-cluster-management.io/api/client/cluster/clientset/versioned/typed/cluster/v1"
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
+	"open-cluster-management.io/ocm/pkg/registration/helpers"
 )
 
-const leaseDurationTimes = 5
+// defaultLeaseDurationTimes is the default multiplier of the lease duration seconds to determine the grace period
+// of an addon lease.
+const defaultLeaseDurationTimes = 5
 
-// AddOnLeaseControllerLeaseDurationSeconds is exposed so that integration tests can crank up the lease update speed.
+// leaseControllerName is the name of the addon lease controller, it is the name of the controller queue as well.
+const leaseControllerName = "ManagedClusterAddOnLeaseController"
+
+// defaultShutdownTimeout is the default timeout to drain the controller queue on shutdown.
+const defaultShutdownTimeout = 10 * time.Second
+
+// defaultResyncJitterFactor is the default jitter factor applied to the resync interval of the controller.
+const defaultResyncJitterFactor = 0.1
+
+// defaultWorkers is the default number of the addons synced concurrently by the controller.
+const defaultWorkers = 1
+
+// AddOnLeaseControllerLeaseDurationSeconds is the default lease duration seconds of addons, an addon can adjust its own
+// lease duration seconds with the annotation "addon.open-cluster-management.io/lease-duration-seconds".
+// It is exposed so that integration tests can crank up the lease update speed.
 // TODO we may add this to ManagedClusterAddOn API to allow addon to adjust its own lease duration seconds
 var AddOnLeaseControllerLeaseDurationSeconds = 60
 
+// AddOnLeaseControllerOptions holds the optional configurations of the managedClusterAddOnLeaseController.
+type AddOnLeaseControllerOptions struct {
+	// LeaseDurationTimes is the multiplier of the addon lease duration seconds to determine the grace period of an
+	// addon lease, an addon is considered unavailable if its lease is not updated within the grace period.
+	// Defaults to 5 if it is not set.
+	LeaseDurationTimes int
+
+	// ResyncTimeout is the deadline of a full resync of all the addons, once it is exceeded, the remaining
+	// addons will be skipped until the next resync. No deadline is applied if it is not set.
+	ResyncTimeout time.Duration
+
+	// StatusUpdateBatchInterval is the interval to coalesce the addon status updates, the status updates of an
+	// addon computed within the interval are merged and only the latest one is updated on the hub cluster. The
+	// status is updated immediately if it is not set.
+	StatusUpdateBatchInterval time.Duration
+
+	// ClockSkewTolerance is the tolerated clock skew between the addon agent and the controller, it is added to
+	// the grace period when checking the addon lease, so that an addon whose agent clock is behind will not be
+	// considered unavailable falsely. Defaults to 0, operators with a known clock skew can set it, e.g. 30s.
+	ClockSkewTolerance time.Duration
+
+	// UseLeaseDurationSeconds derives the grace period of an addon lease from the LeaseDurationSeconds declared by
+	// the lease itself times the lease duration times, rather than the lease duration seconds of the addon, so that
+	// the interval declared by the agent is honored. The lease duration seconds of the addon is used if the lease
+	// does not declare one. The grace period specified by the annotations is still preferred.
+	UseLeaseDurationSeconds bool
+
+	// ClockRegressionTolerance enables detecting the clock regression of the managed cluster. If the renew time of
+	// an addon lease is ahead of the current time by more than the tolerance, the available condition of the addon
+	// is set to unknown with the reason ManagedClusterAddOnLeaseClockRegression rather than available, so that the
+	// NTP problems can be noticed. The detection is disabled if it is not set.
+	ClockRegressionTolerance time.Duration
+
+	// ResyncJitterFactor is the max jitter factor applied to the resync interval, e.g. 0.1 results in a random
+	// resync interval in [interval, 1.1*interval), so that the controllers of a large fleet of managed clusters
+	// do not resync against the hub cluster at the same time. Defaults to 0.1 if it is not set, a negative value
+	// disables the jitter.
+	ResyncJitterFactor float64
+
+	// AddOnSelector selects the addons managed by the controller, so that the addon leases on a managed cluster can
+	// be checked by multiple controllers. Defaults to all of the addons if it is not set.
+	AddOnSelector labels.Selector
+
+	// AggregateExcludedAddOns and AggregateExcludedAddOnSelector select the addons excluded from the aggregate
+	// reporting of the addons, i.e. the AllAddOnsAvailable condition of the managed cluster, the ready of the addon
+	// health endpoint and the status summary, e.g. the infrastructure addons which should not count toward the health
+	// of the user-facing dashboards. The excluded addons are still tracked individually. Nothing is excluded if
+	// neither of them is set.
+	AggregateExcludedAddOns        []string
+	AggregateExcludedAddOnSelector labels.Selector
+
+	// StalenessThreshold is the max duration that the addon informer receives no event, once it is exceeded, the
+	// informer cache is considered stale and the status of all of the managed addons is set to unknown. It should be
+	// greater than the interval that the addons are changed, e.g. the grace period of the addon leases. The staleness
+	// is not checked if it is not set.
+	StalenessThreshold time.Duration
+
+	// AvailabilityChecker overrides the built-in availability check of the addons, with which an addon is available
+	// if its lease is constantly renewed. The built-in check is used if it is not set.
+	AvailabilityChecker AvailabilityChecker
+
+	// ShutdownTimeout is the max duration to drain the controller queue once the controller is stopped, the addons
+	// remaining in the queue are checked and the pending status updates are applied within the timeout. Defaults to
+	// 10s if it is not set, a negative value disables the drain.
+	ShutdownTimeout time.Duration
+
+	// ManagedClusterClient is the client of the managed clusters on the hub cluster. If it is set together with the
+	// ManagedClusterInformer, the controller maintains an aggregate condition AllAddOnsAvailable on the managed cluster
+	// once the addons are evaluated, reflecting whether all of the managed addons are available.
+	ManagedClusterClient clusterclientset.Interface
+
+	// ManagedClusterInformer is the informer of the managed cluster on the hub cluster. If it is set, the annotation
+	// addon.open-cluster-management.io/lease-grace-seconds of the managed cluster is the default grace period of the
+	// addon leases on the cluster. The grace period is determined with the precedence: the addon-specific grace period
+	// or lease duration, i.e. the annotations addon.open-cluster-management.io/lease-grace-seconds and
+	// addon.open-cluster-management.io/lease-duration-seconds of the addon; then the annotation of the managed cluster;
+	// then the lease duration defaults of the ConfigMap addon-lease-defaults or the package default. It is honored by
+	// the built-in lease availability checker only.
+	ManagedClusterInformer clusterinformerv1.ManagedClusterInformer
+
+	// StartupPendingWindow is the duration since the creation of an addon, within which the addon lease is not
+	// found, the addon is considered pending rather than its lease is not found, since the addon agent may not
+	// create its lease yet. Defaults to 0.
+	StartupPendingWindow time.Duration
+
+	// CollapseUnknownStatus collapses the unknown available condition of the addons into a false condition with the
+	// reason ManagedClusterAddOnLeaseStatusUnknown when the condition is written, the original reason is kept in the
+	// condition message. It is for the alerting tooling which only understands the available and not available
+	// states and treats an unknown condition as healthy. The trade-off is that an addon whose availability cannot be
+	// determined, e.g. its lease is not created yet or its lease configuration is invalid, is reported as not
+	// available, which may raise false alerts, and the consumers of the status can no longer tell the unknown
+	// addons by the condition status. The events, metrics and callbacks still see the unknown condition.
+	// Defaults to false, which keeps the three condition states.
+	CollapseUnknownStatus bool
+
+	// ObserveOnly makes the controller compute the available condition of the addons, and emit the events and
+	// metrics without updating the status of the addons, so that the detection can be validated safely. The changes
+	// of the conditions are compared with the conditions observed last time, and reported with the event
+	// ManagedClusterAddOnStatusObserved.
+	ObserveOnly bool
+
+	// CloudEventSinkURL is the url of the sink to which a cloudevent is published once the available condition of
+	// an addon is changed. No cloudevent is published if it is empty.
+	CloudEventSinkURL string
+
+	// Workers is the number of the addons synced concurrently by the controller, so that the addons enqueued by a
+	// full resync can be checked in parallel on a managed cluster with many addons. Defaults to 1.
+	Workers int
+
+	// FixedLeaseNamespace is the namespace of the leases of all the addons, so that the addon leases can be managed
+	// in a well-known namespace with simpler RBAC. If it is not set, the lease namespace of an addon is resolved from
+	// its annotation addon.open-cluster-management.io/lease-namespace or its installation namespace.
+	FixedLeaseNamespace string
+
+	// DecodeLeaseHeartbeat enables decoding the heartbeat diagnostics stashed by the addon agent in the annotation
+	// addon.open-cluster-management.io/heartbeat of its lease, the decoded heartbeat is surfaced in the available
+	// condition message of the addon.
+	DecodeLeaseHeartbeat bool
+
+	// WatchdogThreshold is the max duration since the last completed sync, once it is exceeded, the controller is
+	// considered stalled, e.g. a sync is blocked by a hung client call. A stall is reported with a warning event and
+	// by the readiness endpoint of the controller until a sync is completed. The watchdog is disabled if it is not set.
+	WatchdogThreshold time.Duration
+
+	// StatusSummaryInterval is the interval to log a summary of the addons, it lists the counts of the available,
+	// unavailable and unknown addons and the names of the addons which are not available. The summary is disabled
+	// if it is not set.
+	StatusSummaryInterval time.Duration
+
+	// LeaseDefaultsConfigMapInformer is the informer of the ConfigMaps in LeaseDefaultsConfigMapNamespace. If it is
+	// set, the default lease configuration of the addons is read from the ConfigMap addon-lease-defaults, and the
+	// changes of the ConfigMap take effect without restarting the controller. The ConfigMap contains
+	// leaseDurationSeconds, which is used by the addons without their own lease duration seconds, and leaseDurationTimes,
+	// which overrides the LeaseDurationTimes of the options. The defaults are not applied to the AvailabilityChecker.
+	// The ConfigMap may also contain a planned maintenance window with maintenanceWindowStart and
+	// maintenanceWindowEnd, within which the available addons are not turned unavailable, and statusUpdatesDisabled,
+	// which disables all of the status writes of the controller if it is "true".
+	LeaseDefaultsConfigMapInformer corev1informers.ConfigMapInformer
+
+	// AddOnDeploymentConfigInformer is the dynamic informer of the AddOnDeploymentConfigs on the hub cluster. If it
+	// is set, the installation namespace of an addon is resolved from the agentInstallNamespace of the
+	// AddOnDeploymentConfig referenced by the addon, and the namespace reported in the status of the addon is used
+	// only if the config does not specify one. The lease namespace of the addon follows the installation namespace
+	// unless it is specified by the annotation of the addon. The informer requires the access to list and watch the
+	// AddOnDeploymentConfigs on the hub cluster.
+	AddOnDeploymentConfigInformer informers.GenericInformer
+
+	// SpokeNamespaceInformer is the informer of the namespaces on the managed cluster. If it is set, the
+	// installation namespace of an addon whose lease is not found is checked, and the available condition of the
+	// addon is set to unknown with the reason ManagedClusterAddOnNamespaceMissing if the namespace does not exist,
+	// so that an incomplete installation is distinguished from a down agent. The check requires the access to list
+	// and watch the namespaces on the managed cluster, it is disabled if the informer is not set.
+	SpokeNamespaceInformer corev1informers.NamespaceInformer
+
+	// LeaseDefaultsConfigMapNamespace is the namespace of the ConfigMap addon-lease-defaults.
+	LeaseDefaultsConfigMapNamespace string
+
+	// StatusWriterID is the identity of the controller instance, e.g. the name of the agent pod. If it is set, each
+	// status update of an addon also sets the annotation addon.open-cluster-management.io/status-writer on the addon
+	// with the identity and the time of the update, so that multiple controllers updating the same addon can be
+	// diagnosed.
+	StatusWriterID string
+
+	// SoftReasons are the reasons of the available condition which are only applied to an addon once they persist
+	// for SoftReasonDebounce, e.g. ManagedClusterAddOnLeaseNotFound while the lease is briefly absent right after the
+	// controller starts, so that the momentary flaps of the addons are smoothed.
+	SoftReasons []string
+
+	// SoftReasonDebounce is the duration that a soft reason of an addon must persist before it is applied. The soft
+	// reasons are applied immediately if it is not set.
+	SoftReasonDebounce time.Duration
+
+	// LeaseDurationMismatchFactor enables comparing the lease duration declared by the addon agent in its lease with
+	// the lease duration seconds of the addon. If the declared duration is greater than the factor times, or less
+	// than 1/factor of the expected duration, a warning event is emitted and the metric addon_lease_duration_mismatch
+	// is set, the availability of the addon is not affected. The comparison is disabled if it is not greater than 1.
+	LeaseDurationMismatchFactor float64
+
+	// LeaseHolderChurnThreshold enables detecting the churn of the holder identity of the addon leases, e.g. the
+	// leader election of the addon agent replicas is unstable. Once the holder of an addon lease changes the
+	// threshold times within the LeaseHolderChurnWindow, a ManagedClusterAddOnLeaseHolderChurn warning event is
+	// emitted, the availability of the addon is not affected. The detection is disabled if it is not set.
+	LeaseHolderChurnThreshold int
+
+	// LeaseHolderChurnWindow is the window in which the holder changes of an addon lease are counted, it should be
+	// longer than the resync interval since the holder changes are observed when the addon is synced. Defaults to
+	// 10m if it is not set.
+	LeaseHolderChurnWindow time.Duration
+
+	// MaxResyncBackoff is the max interval of the full resync while the hub cluster is unreachable. Once the requests
+	// against the hub cluster fail since it cannot be reached, the resync interval is doubled for each consecutive
+	// failure until the hub cluster is reachable again. Defaults to 30m if it is not set, a negative value
+	// disables the backoff.
+	MaxResyncBackoff time.Duration
+
+	// AuditLogPath is the path of a local file to which a json line is appended once the available condition status
+	// of an addon is changed, including the time, cluster, addon, and the old and new status, so that an audit
+	// trail is kept without any external system. The failure of the file is logged without blocking the
+	// reconciliation. No audit log is written if it is empty.
+	AuditLogPath string
+
+	// EventRecorder is an optional recorder to which the events of the controller are emitted alongside the
+	// recorder of the controller, so that the events can be routed to a custom sink, e.g. structured logging or a
+	// test buffer, without a full event chain.
+	EventRecorder events.Recorder
+
+	// InitialSyncTimeout enables an initial pass over all of the addons before the controller starts, the leases of
+	// the addons are checked and their available conditions are updated in the pass, so that the addons do not
+	// show an outdated status until they are processed by the controller after a restart. The pass is bounded by
+	// the timeout, and it is skipped if the timeout is not set.
+	InitialSyncTimeout time.Duration
+
+	// StartupWarmupWindow is the duration since the controller starts, within which the status updates of the addons
+	// are suppressed while the caches warm up, so that a rapid restart of the agent does not cause a burst of status
+	// writes. The available conditions are still computed in the window, and each addon whose status update is
+	// suppressed is synced again once the window is over, so that its settled status is written once. The window
+	// starts after the initial pass enabled by the InitialSyncTimeout. Defaults to 0, which disables the warmup.
+	StartupWarmupWindow time.Duration
+
+	// SpokeConfigMapClient and ManagementConfigMapClient read the heartbeat configmaps of the addons on the
+	// managed/management cluster, for the addons whose agent reports its heartbeat with a configmap instead of a
+	// lease, see the annotation addon.open-cluster-management.io/heartbeat-configmap.
+	SpokeConfigMapClient      corev1client.ConfigMapsGetter
+	ManagementConfigMapClient corev1client.ConfigMapsGetter
+
+	// Clock is the clock of the controller, it defaults to the real clock. It allows the tests against a running
+	// controller to drive the time deterministically with a fake clock: step the fake clock, e.g. beyond the grace
+	// period of an addon lease, and then call RefreshAddOn, which returns once the addon is checked with the stepped
+	// time and its available condition is updated, instead of waiting for the next resync.
+	Clock clock.Clock
+
+	// ConditionType is the type of the condition which the availability of the addons is reported with, so that the
+	// controller does not collide with another controller maintaining the same condition type in a non-standard
+	// setup. Defaults to ManagedClusterAddOnConditionAvailable if it is not set. The availability of the addons is
+	// read from the condition of this type by the controller as well, e.g. the aggregate condition, the dependencies,
+	// the sticky available and maintenance holds. On the hub cluster, the addon unavailable taint honors the type set
+	// by the flag --addon-available-condition-type of the registration hub, while the other hub controllers, e.g. the
+	// addon discovery and health check controllers, still read the condition ManagedClusterAddOnConditionAvailable.
+	ConditionType string
+
+	// ConditionMutator transforms the available condition of an addon before it is written, e.g. to append the
+	// region or tenant to the message. The type and status of the condition cannot be changed by the mutator, and
+	// the condition is written as it is computed if it is not set.
+	ConditionMutator ConditionMutator
+
+	// BackupAddOnClients are the addon clients of the backup hub clusters that the managed cluster is registered
+	// to besides the hub cluster of the addon informer. The available condition of an addon is updated on each of the
+	// hubs, and the update succeeds if any of the hubs is updated, the failures of the hubs are logged.
+	BackupAddOnClients []addonclient.Interface
+
+	// StatusUpdateStrategy is the strategy to update the available condition of the addons, it defaults to
+	// StatusUpdateStrategyPatch.
+	StatusUpdateStrategy StatusUpdateStrategy
+
+	// Tracer starts the spans around the syncs of the controller, the spans are tagged with the cluster, addon and
+	// the decision on the available condition of the addon, so that the addon status updates can be correlated with
+	// the other activities of the agent. Defaults to a no-op tracer.
+	Tracer trace.Tracer
+
+	// AvailableCallback is called once for each addon when the addon transitions from its initial Unknown into
+	// available, e.g. to sequence the post-install steps of the addon. The callback runs asynchronously without
+	// blocking the sync, and it is best-effort: it is not retried, and it is not called for an addon which is already
+	// available when the controller starts, or if the controller restarts before the callback completes.
+	AvailableCallback AddOnAvailableCallback
+}
+
+// AddOnLeaseController is the controller checking the addon leases, besides the periodic resync, it allows the
+// availability of an addon to be refreshed on demand.
+type AddOnLeaseController interface {
+	factory.Controller
+
+	// Handler responds the observed lease freshness of the addons, see AddOnHealthPath.
+	http.Handler
+
+	// RefreshAddOn checks the lease of the given addon and updates its available condition immediately without
+	// waiting for the next resync. The addon is read from the same informer cache the controller uses.
+	RefreshAddOn(ctx context.Context, addOnName string) error
+
+	// UnavailableAddOns returns the addons which are not available with the reasons of their available condition,
+	// it is read from the state cached by the controller rather than the lister.
+	UnavailableAddOns() map[string]string
+
+	// HasSyncedOnce returns true once the controller has completed its first full resync of the addons, see
+	// AddOnReadyPath.
+	HasSyncedOnce() bool
+
+	// IsStalled returns true if the watchdog of the controller detects no sync is completed within the
+	// WatchdogThreshold of the options, see AddOnReadyPath.
+	IsStalled() bool
+
+	// Pause stops the controller from processing the syncs until Resume is called, the lease and addon events
+	// received during the pause are processed once the controller is resumed.
+	Pause()
+
+	// Resume resumes the controller paused by Pause.
+	Resume()
+}
+
 // managedClusterAddOnLeaseController updates the managed cluster addons status on the hub cluster through checking the add-on
 // lease on the managed/management cluster.
 type managedClusterAddOnLeaseController struct {
-	clusterName string
-	clock       clock.Clock
-	patcher     patcher.Patcher[
+	factory.Controller
+
+	clusterName          string
+	clock                clock.Clock
+	conditionType        string
+	statusUpdateStrategy StatusUpdateStrategy
+	patcher              patcher.Patcher[
 		*addonv1alpha1.ManagedClusterAddOn, addonv1alpha1.ManagedClusterAddOnSpec, addonv1alpha1.ManagedClusterAddOnStatus]
+	addOnClient           addonclientv1alpha1.ManagedClusterAddOnInterface
 	addOnLister           addonlisterv1alpha1.ManagedClusterAddOnLister
+	cachesSynced          []cache.InformerSynced
+	addOnSelector         labels.Selector
 	hubLeaseClient        coordv1client.CoordinationV1Interface
 	managementLeaseClient coordv1client.CoordinationV1Interface
 	spokeLeaseClient      coordv1client.CoordinationV1Interface
+	leaseDurationTimes    int
+
+	spokeConfigMapClient      corev1client.ConfigMapsGetter
+	managementConfigMapClient corev1client.ConfigMapsGetter
+	backupHubs                []backupHub
+	resyncInterval            time.Duration
+	resyncTimeout             time.Duration
+
+	statusUpdateBatchInterval time.Duration
+	pendingStatusUpdates      *pendingStatusUpdates
+	clockSkewTolerance        time.Duration
+	clockRegressionTolerance  time.Duration
+	useLeaseDurationSeconds   bool
+	startupPendingWindow      time.Duration
+	observeOnly               bool
+	collapseUnknownStatus     bool
+	cloudEventPublisher       *cloudEventPublisher
+	auditLogger               *auditLogger
+	statusUpdateBackoff       unauthorizedBackoff
+
+	syncCtx factory.SyncContext
+
+	// establishedAddOns records the addons whose established lease has been reported
+	establishedAddOns     sets.Set[string]
+	establishedAddOnsLock sync.Mutex
+
+	// suspendedAddOns records the addons whose suspension has been reported
+	suspendedAddOns     sets.Set[string]
+	suspendedAddOnsLock sync.Mutex
+
+	// stickyAvailableAddOns records the sticky available addons whose stale lease has been reported
+	stickyAvailableAddOns stickyAvailableAddOns
+	// staleAgentVersions records the stale agent versions of the addons which have been reported
+	staleAgentVersions staleAgentVersions
+	// writtenConditions records the last available condition of each addon written by the controller, so that the
+	// aggregate condition reflects the status updates which are not observed by the addon informer yet.
+	writtenConditions addOnConditions
+	// observedConditions records the last available condition of each addon computed in observe only mode
+	observedConditions addOnConditions
+
+	observedLeases  *observedLeases
+	recoveryTracker recoveryTracker
+
+	stalenessThreshold time.Duration
+	informerActivity   *informerActivity
+
+	availabilityChecker AvailabilityChecker
+	shutdownTimeout     time.Duration
+	initialSyncTimeout  time.Duration
+	warmupWindow        time.Duration
+	warmupDeadline      time.Time
+	workers             int
+	fixedLeaseNamespace string
+	decodeHeartbeat     bool
+	statusWriterID      string
+	softReasonDebouncer *softReasonDebouncer
+	durationValidator   *leaseDurationValidator
+	holderChurnDetector *leaseHolderChurnDetector
+	resyncBackoff       *resyncBackoff
+	watchdog            *syncWatchdog
+	statusSummary       *statusSummary
+	aggregateExclusion  *aggregateExclusion
+	syncedOnce          atomic.Bool
+	pausedQueueKeys     pausedQueueKeys
+	globalDisable       globalDisable
+	conditionMutator    ConditionMutator
+	tracer              trace.Tracer
+	availableCallback   AddOnAvailableCallback
+	clusterLister       clusterlisterv1.ManagedClusterLister
+	leaseDefaultsLister corev1listers.ConfigMapNamespaceLister
+	namespaceLister     corev1listers.NamespaceLister
+	// installNamespaceResolver resolves the installation namespace of the addons from their AddOnDeploymentConfigs
+	installNamespaceResolver
+
+	clusterClient  clusterv1client.ManagedClusterInterface
+	clusterPatcher patcher.Patcher[*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus]
 }
 
 // NewManagedClusterAddOnLeaseController returns an instance of managedClusterAddOnLeaseController
@@ -51,154 +470,813 @@ func NewManagedClusterAddOnLeaseController(clusterName string,
 	managementLeaseClient coordv1client.CoordinationV1Interface,
 	spokeLeaseClient coordv1client.CoordinationV1Interface,
 	resyncInterval time.Duration,
-	recorder events.Recorder) factory.Controller {
+	options AddOnLeaseControllerOptions,
+	recorder events.Recorder) AddOnLeaseController {
+	if options.LeaseDurationTimes <= 0 {
+		options.LeaseDurationTimes = defaultLeaseDurationTimes
+	}
+	if options.ResyncJitterFactor == 0 {
+		options.ResyncJitterFactor = defaultResyncJitterFactor
+	}
+	if options.ShutdownTimeout == 0 {
+		options.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if options.Workers <= 0 {
+		options.Workers = defaultWorkers
+	}
+	if options.MaxResyncBackoff == 0 {
+		options.MaxResyncBackoff = defaultMaxResyncBackoff
+	}
+	if options.AddOnSelector == nil {
+		options.AddOnSelector = labels.Everything()
+	}
+	if options.Clock == nil {
+		options.Clock = clock.RealClock{}
+	}
+	if len(options.ConditionType) == 0 {
+		options.ConditionType = addonv1alpha1.ManagedClusterAddOnConditionAvailable
+	}
+	if len(options.StatusUpdateStrategy) == 0 {
+		options.StatusUpdateStrategy = StatusUpdateStrategyPatch
+	}
+
+	recorder = newTeeRecorder(recorder, options.EventRecorder)
+
+	registerLeaseMetrics()
+
 	c := &managedClusterAddOnLeaseController{
-		clusterName: clusterName,
-		clock:       clock.RealClock{},
+		clusterName:          clusterName,
+		clock:                options.Clock,
+		conditionType:        options.ConditionType,
+		statusUpdateStrategy: options.StatusUpdateStrategy,
 		patcher: patcher.NewPatcher[
 			*addonv1alpha1.ManagedClusterAddOn, addonv1alpha1.ManagedClusterAddOnSpec, addonv1alpha1.ManagedClusterAddOnStatus](
 			addOnClient.AddonV1alpha1().ManagedClusterAddOns(clusterName)),
+		addOnClient:           addOnClient.AddonV1alpha1().ManagedClusterAddOns(clusterName),
 		addOnLister:           addOnInformer.Lister(),
+		cachesSynced:          []cache.InformerSynced{addOnInformer.Informer().HasSynced},
+		addOnSelector:         options.AddOnSelector,
 		hubLeaseClient:        hubLeaseClient,
 		managementLeaseClient: managementLeaseClient,
 		spokeLeaseClient:      spokeLeaseClient,
+		leaseDurationTimes:    options.LeaseDurationTimes,
+
+		spokeConfigMapClient:      options.SpokeConfigMapClient,
+		managementConfigMapClient: options.ManagementConfigMapClient,
+		backupHubs:                newBackupHubs(clusterName, options.BackupAddOnClients),
+		conditionMutator:          options.ConditionMutator,
+		tracer:                    options.Tracer,
+		availableCallback:         options.AvailableCallback,
+		resyncInterval:            resyncInterval,
+		resyncTimeout:             options.ResyncTimeout,
+
+		statusUpdateBatchInterval: options.StatusUpdateBatchInterval,
+		pendingStatusUpdates:      newPendingStatusUpdates(),
+		clockSkewTolerance:        options.ClockSkewTolerance,
+		clockRegressionTolerance:  options.ClockRegressionTolerance,
+		useLeaseDurationSeconds:   options.UseLeaseDurationSeconds,
+		startupPendingWindow:      options.StartupPendingWindow,
+		observeOnly:               options.ObserveOnly,
+		collapseUnknownStatus:     options.CollapseUnknownStatus,
+		cloudEventPublisher:       newCloudEventPublisher(options.CloudEventSinkURL, clusterName),
+		auditLogger:               newAuditLogger(options.AuditLogPath),
+		syncCtx:                   factory.NewSyncContext(leaseControllerName, recorder),
+		establishedAddOns:         sets.New[string](),
+		suspendedAddOns:           sets.New[string](),
+		observedLeases:            newObservedLeases(),
+		stalenessThreshold:        options.StalenessThreshold,
+		availabilityChecker:       options.AvailabilityChecker,
+		shutdownTimeout:           options.ShutdownTimeout,
+		initialSyncTimeout:        options.InitialSyncTimeout,
+		warmupWindow:              options.StartupWarmupWindow,
+		workers:                   options.Workers,
+		fixedLeaseNamespace:       options.FixedLeaseNamespace,
+		decodeHeartbeat:           options.DecodeLeaseHeartbeat,
+		statusWriterID:            options.StatusWriterID,
+	}
+
+	if options.ManagedClusterClient != nil {
+		c.clusterClient = options.ManagedClusterClient.ClusterV1().ManagedClusters()
+		c.clusterPatcher = patcher.NewPatcher[
+			*clusterv1.ManagedCluster, clusterv1.ManagedClusterSpec, clusterv1.ManagedClusterStatus](c.clusterClient)
+	}
+
+	if options.WatchdogThreshold > 0 {
+		c.watchdog = newSyncWatchdog(c.clock, options.WatchdogThreshold, recorder)
+	}
+
+	c.aggregateExclusion = newAggregateExclusion(options.AggregateExcludedAddOns, options.AggregateExcludedAddOnSelector)
+
+	if options.StatusSummaryInterval > 0 {
+		c.statusSummary = newStatusSummary(clusterName, options.StatusSummaryInterval)
+	}
+
+	if options.MaxResyncBackoff > 0 {
+		c.resyncBackoff = newResyncBackoff(resyncInterval, options.MaxResyncBackoff)
+	}
+
+	if options.LeaseDurationMismatchFactor > 1 {
+		c.durationValidator = newLeaseDurationValidator(options.LeaseDurationMismatchFactor)
+	}
+
+	if options.LeaseHolderChurnThreshold > 0 {
+		if options.LeaseHolderChurnWindow <= 0 {
+			options.LeaseHolderChurnWindow = defaultLeaseHolderChurnWindow
+		}
+		c.holderChurnDetector = newLeaseHolderChurnDetector(c.clock, options.LeaseHolderChurnThreshold,
+			options.LeaseHolderChurnWindow)
+	}
+
+	if len(options.SoftReasons) != 0 && options.SoftReasonDebounce > 0 {
+		c.softReasonDebouncer = newSoftReasonDebouncer(c.clock, options.SoftReasons, options.SoftReasonDebounce)
+	}
+
+	// the factory waits for the caches of the bare informers before the workers are started.
+	bareInformers := []factory.Informer{addOnInformer.Informer()}
+	if options.ManagedClusterInformer != nil {
+		c.clusterLister = options.ManagedClusterInformer.Lister()
+		bareInformers = append(bareInformers, options.ManagedClusterInformer.Informer())
+		c.cachesSynced = append(c.cachesSynced, options.ManagedClusterInformer.Informer().HasSynced)
+	}
+	if options.LeaseDefaultsConfigMapInformer != nil {
+		c.leaseDefaultsLister = options.LeaseDefaultsConfigMapInformer.Lister().ConfigMaps(options.LeaseDefaultsConfigMapNamespace)
+		options.LeaseDefaultsConfigMapInformer.Informer().AddEventHandler(
+			c.leaseDefaultsEventHandler(options.LeaseDefaultsConfigMapNamespace))
+		bareInformers = append(bareInformers, options.LeaseDefaultsConfigMapInformer.Informer())
+		c.cachesSynced = append(c.cachesSynced, options.LeaseDefaultsConfigMapInformer.Informer().HasSynced)
+	}
+	if options.SpokeNamespaceInformer != nil {
+		// a namespace is reported missing by the namespace check only once the namespace cache is synced
+		c.namespaceLister = options.SpokeNamespaceInformer.Lister()
+		bareInformers = append(bareInformers, options.SpokeNamespaceInformer.Informer())
+		c.cachesSynced = append(c.cachesSynced, options.SpokeNamespaceInformer.Informer().HasSynced)
+	}
+	if options.AddOnDeploymentConfigInformer != nil {
+		c.installNamespaceResolver = newInstallNamespaceResolver(options.AddOnDeploymentConfigInformer)
+		options.AddOnDeploymentConfigInformer.Informer().AddEventHandler(c.deploymentConfigEventHandler())
+		bareInformers = append(bareInformers, options.AddOnDeploymentConfigInformer.Informer())
+		c.cachesSynced = append(c.cachesSynced, options.AddOnDeploymentConfigInformer.Informer().HasSynced)
+	}
+
+	addOnInformer.Informer().AddEventHandler(c.addOnDeletionHandler(recorder))
+
+	if c.stalenessThreshold > 0 {
+		c.informerActivity = newInformerActivity(c.clock)
+		addOnInformer.Informer().AddEventHandler(c.informerActivity.eventHandler(c.clock))
 	}
 
 	// TODO We do not add leaser informer to support kubernetes version lower than 1.17. Lease v1 api
 	// is introduced in v1.17, hence adding lease informer in this controller will cause the hang of
 	// informer cache sync and result in fatal exit of this controller. The code will be factored
 	// when we no longer support kubernetes version lower than 1.17.
-	return factory.New().
+	// the sync context is shared with RefreshAddOn, so that the status updates of a refresh are batched in the
+	// same queue as the controller.
+	c.Controller = factory.New().
 		WithSync(c.sync).
-		ResyncEvery(resyncInterval).
-		ToController("ManagedClusterAddOnLeaseController", recorder)
+		WithSyncContext(c.syncCtx).
+		WithBareInformers(bareInformers...).
+		ResyncEvery(jitterResyncInterval(resyncInterval, options.ResyncJitterFactor)).
+		ToController(leaseControllerName, recorder)
+	return c
+}
+
+// jitterResyncInterval returns a random interval in [interval, interval*(1+jitterFactor)). The interval is returned
+// without any jitter if the jitter factor is not positive.
+func jitterResyncInterval(interval time.Duration, jitterFactor float64) time.Duration {
+	if jitterFactor <= 0 {
+		return interval
+	}
+	return wait.Jitter(interval, jitterFactor)
+}
+
+// RefreshAddOn recomputes the available condition of an addon by checking its lease and updates it on the hub cluster.
+func (c *managedClusterAddOnLeaseController) RefreshAddOn(ctx context.Context, addOnName string) error {
+	addOn, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).Get(addOnName)
+	if err != nil {
+		return err
+	}
+
+	if !c.addOnSelector.Matches(labels.Set(addOn.Labels)) {
+		return fmt.Errorf("addon %q is not managed by the lease controller of cluster %q", addOnName, c.clusterName)
+	}
+
+	if addOn.Status.HealthCheck.Mode == addonv1alpha1.HealthCheckModeCustomized {
+		klog.V(4).InfoS("Skip refreshing the addon with customized health check",
+			"cluster", c.clusterName, "addon", addOnName, "reason", "health check mode is customized")
+		return nil
+	}
+
+	leaseConfig, err := c.getAddOnLeaseConfig(addOn)
+	if err != nil {
+		return err
+	}
+
+	return c.syncSingle(ctx, c.syncCtx, leaseConfig.leaseNamespace, leaseConfig, addOn)
 }
 
-func (c *managedClusterAddOnLeaseController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+func (c *managedClusterAddOnLeaseController) sync(ctx context.Context, syncCtx factory.SyncContext) (err error) {
+	if c.watchdog != nil {
+		defer c.watchdog.complete()
+	}
+
 	queueKey := syncCtx.QueueKey()
+	if c.pausedQueueKeys.hold(queueKey) {
+		klog.V(4).InfoS("Hold the queue key", "cluster", c.clusterName, "queueKey", queueKey,
+			"reason", "controller is paused")
+		return nil
+	}
+	ctx, span := c.startSpan(ctx, "sync", attribute.String("queueKey", queueKey))
+	defer func() { endSpan(span, err) }()
+	start := c.clock.Now()
+	defer func() {
+		addOnLeaseControllerSyncDuration.WithLabelValues(leaseControllerName, syncType(queueKey)).Observe(
+			c.clock.Since(start).Seconds())
+	}()
+
+	if queueKey == flushStatusQueueKey {
+		return c.flushPendingStatusUpdates(ctx, syncCtx.Recorder())
+	}
+
+	if queueKey == aggregateQueueKey {
+		return c.updateAllAddOnsAvailableCondition(ctx)
+	}
+
 	if queueKey == factory.DefaultQueueKey {
-		addOns, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).List(labels.Everything())
+		if c.resyncBackoff != nil && !c.resyncBackoff.allowResync(c.clock.Now()) {
+			klog.V(4).InfoS("Skip the resync of the addons", "cluster", c.clusterName,
+				"reason", "hub cluster is unreachable")
+			return nil
+		}
+		if c.resyncTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.resyncTimeout)
+			defer cancel()
+		}
+
+		if !c.hasCachesSynced() {
+			// the addons in an unsynced cache are incomplete, retry the resync once the caches are synced.
+			klog.V(4).InfoS("Skip the resync of the addons", "cluster", c.clusterName,
+				"reason", "informer caches are not synced")
+			syncCtx.Queue().AddAfter(factory.DefaultQueueKey, cacheSyncRetryInterval)
+			return nil
+		}
+
+		addOns, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).List(c.addOnSelector)
 		if err != nil {
 			return err
 		}
-		for _, addOn := range addOns {
+		if len(addOns) > 0 && c.isInformerStale() {
+			return c.markAddOnsStale(ctx, addOns, syncCtx.Recorder())
+		}
+		for i, addOn := range addOns {
+			if ctx.Err() != nil {
+				klog.Warningf("Resync of the addons of cluster %q is aborted: %v, %d of %d addons are processed",
+					c.clusterName, ctx.Err(), i, len(addOns))
+				return nil
+			}
+
+			leaseConfig, err := c.getAddOnLeaseConfig(addOn)
+			if isAddOnConfigUnresolvable(err) {
+				// the addon lease configuration is invalid, enqueue the addon with its installation namespace to
+				// surface the error in its status.
+				syncCtx.Queue().Add(fmt.Sprintf("%s/%s", getAddOnInstallationNamespace(addOn), addOn.Name))
+				continue
+			}
+			if err != nil {
+				// the addon lease configuration cannot be read for now, retry the whole resync rather than leave
+				// the addon un-evaluated in this pass.
+				return err
+			}
+			if c.isAvailabilityUnchanged(addOn, leaseConfig) {
+				klog.V(4).InfoS("Skip the addon whose availability cannot be changed",
+					"cluster", c.clusterName, "addon", addOn.Name, "reason", "lease is fresh and condition is unchanged")
+				continue
+			}
 			// enqueue the addon to reconcile
-			syncCtx.Queue().Add(fmt.Sprintf("%s/%s", getAddOnInstallationNamespace(addOn), addOn.Name))
+			syncCtx.Queue().Add(fmt.Sprintf("%s/%s", leaseConfig.leaseNamespace, addOn.Name))
 		}
+		c.syncedOnce.Store(true)
+		// the aggregate condition is computed after the addons enqueued above are synced.
+		c.enqueueAggregate(syncCtx)
 		return nil
 	}
 
 	addOnNamespace, addOnName, err := cache.SplitMetaNamespaceKey(queueKey)
 	if err != nil {
 		// queue key is bad format, ignore it.
+		klog.V(4).InfoS("Skip the addon with bad queue key",
+			"cluster", c.clusterName, "queueKey", queueKey, "reason", err.Error())
 		return nil
 	}
 
 	addOn, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).Get(addOnName)
 	if errors.IsNotFound(err) {
 		// addon is not found, could be deleted, ignore it.
+		klog.V(4).InfoS("Skip the addon which is not found",
+			"cluster", c.clusterName, "addon", addOnName, "reason", "addon is not found")
+		c.forgetAddOn(addOnName)
 		return nil
 	}
 	if err != nil {
 		return err
 	}
 
+	if !c.addOnSelector.Matches(labels.Set(addOn.Labels)) {
+		// addon is not managed by this controller, ignore it.
+		klog.V(4).InfoS("Skip the addon which is not selected",
+			"cluster", c.clusterName, "addon", addOnName, "reason", "addon does not match the selector")
+		return nil
+	}
+
 	// "Customized" mode health check is supposed to delegate the health checking
 	// to the addon manager.
 	if addOn.Status.HealthCheck.Mode == addonv1alpha1.HealthCheckModeCustomized {
+		klog.V(4).InfoS("Skip the addon with customized health check",
+			"cluster", c.clusterName, "addon", addOnName, "reason", "health check mode is customized")
 		return nil
 	}
 
-	return c.syncSingle(ctx, addOnNamespace, addOn, syncCtx.Recorder())
+	leaseConfig, err := c.getAddOnLeaseConfig(addOn)
+	if isAddOnConfigUnresolvable(err) {
+		// the addon lease configuration is invalid, the availability of the addon cannot be determined.
+		klog.V(4).InfoS("The addon has invalid lease configuration",
+			"cluster", c.clusterName, "addon", addOnName, "reason", err.Error())
+		return c.updateAvailableCondition(ctx, addOn, addOnNamespace, metav1.Condition{
+			Type:    c.conditionType,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "ManagedClusterAddOnConfigUnresolvable",
+			Message: fmt.Sprintf("The status of %s add-on is unknown, its lease configuration is invalid: %v", addOnName, err),
+		}, syncCtx.Recorder())
+	}
+	if err != nil {
+		return err
+	}
+	if isInstallationNamespaceEmpty(leaseConfig.addonInstallOption) {
+		klog.V(4).InfoS("The addon has empty installation namespace",
+			"cluster", c.clusterName, "addon", addOnName, "installationNamespace", leaseConfig.InstallationNamespace)
+		return c.updateAvailableCondition(ctx, addOn, addOnNamespace, metav1.Condition{
+			Type:    c.conditionType,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "ManagedClusterAddOnInstallNamespaceEmpty",
+			Message: fmt.Sprintf("The status of %s add-on is unknown, its installation namespace is empty.", addOnName),
+		}, syncCtx.Recorder())
+	}
+
+	if err := c.syncSingle(ctx, syncCtx, addOnNamespace, leaseConfig, addOn); err != nil {
+		return err
+	}
+
+	// requeue the addon with its own resync interval, the addons without the override are rechecked by the
+	// resync of the controller.
+	if leaseConfig.resyncInterval > 0 {
+		syncCtx.Queue().AddAfter(queueKey, leaseConfig.resyncInterval)
+	}
+	return nil
+}
+
+// getAddOnLeaseConfig returns the lease configuration of the addon, the installation namespace is resolved from the
+// AddOnDeploymentConfig of the addon, the lease namespace is overridden by the fixed lease namespace of the
+// controller if it is set, and the lease defaults are applied. Besides the
+// addOnConfigUnresolvableError, a transient error is returned if the lease defaults cannot be read.
+func (c *managedClusterAddOnLeaseController) getAddOnLeaseConfig(addOn *addonv1alpha1.ManagedClusterAddOn) (*leaseConfig, error) {
+	leaseConfig, err := c.installNamespaceResolver.getAddOnLeaseConfig(addOn)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.fixedLeaseNamespace) != 0 {
+		leaseConfig.leaseNamespace = c.fixedLeaseNamespace
+	}
+	defaults, err := c.getLeaseDefaults()
+	if err != nil {
+		return nil, err
+	}
+	applyLeaseDefaults(addOn, leaseConfig, defaults)
+	return leaseConfig, nil
+}
+
+// isAvailabilityUnchanged returns true if the addon was available when it was last checked, its spec, annotations
+// and available condition have not been changed since then, and its last observed lease is still fresh. The lease is
+// not fetched for such an addon in the resync, since its availability cannot be changed until the last observed lease
+// is expired. The addon is always checked once it is changed by others or it is enqueued by its own key.
+func (c *managedClusterAddOnLeaseController) isAvailabilityUnchanged(
+	addOn *addonv1alpha1.ManagedClusterAddOn, leaseConfig *leaseConfig) bool {
+	observed, ok := c.observedLeases.get(addOn.Name)
+	if !ok || observed.Status != metav1.ConditionTrue || observed.RenewTime == nil {
+		// a lease which is not fresh may be renewed at any time
+		return false
+	}
+	if len(getAddOnDependencies(addOn)) != 0 || leaseConfig.componentLeaseSelector != nil {
+		// the availability of the dependencies or the other components may be changed at any time
+		return false
+	}
+	if c.useLeaseDurationSeconds {
+		// the lease duration declared by the last observed lease is not recorded
+		return false
+	}
+	if observed.generation != addOn.Generation || observed.annotationsHash != hashAddOnAnnotations(addOn) {
+		// the annotations may force, suspend or reconfigure the availability of the addon
+		return false
+	}
+
+	condition := meta.FindStatusCondition(addOn.Status.Conditions, c.conditionType)
+	if condition == nil || condition.Status != observed.Status || condition.Reason != observed.Reason {
+		return false
+	}
+
+	checker := c.leaseAvailabilityChecker()
+	now := checker.clock.Now().Add(-checker.clockSkewTolerance)
+	expected := getLeaseAvailableCondition(checker.conditionType, addOn.Name,
+		&coordv1.Lease{Spec: coordv1.LeaseSpec{RenewTime: observed.RenewTime}}, now, checker.gracePeriod(addOn, leaseConfig, nil))
+	return expected.Status == observed.Status && expected.Reason == observed.Reason
+}
+
+// hashAddOnAnnotations returns the hash of the annotations of the addon
+func hashAddOnAnnotations(addOn *addonv1alpha1.ManagedClusterAddOn) string {
+	// the keys of a map are sorted by json, so that the hash is stable
+	data, _ := json.Marshal(addOn.Annotations)
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// leaseAvailabilityChecker returns the built-in lease based availability checker of the controller
+func (c *managedClusterAddOnLeaseController) leaseAvailabilityChecker() *leaseAvailabilityChecker {
+	// the error of the lease defaults has been returned by getAddOnLeaseConfig before the checker is used
+	defaults, _ := c.getLeaseDefaults()
+	return &leaseAvailabilityChecker{
+		clock:                c.clock,
+		leaseDurationTimes:   c.leaseDurationTimes,
+		clockSkewTolerance:   c.clockSkewTolerance,
+		startupPendingWindow: c.startupPendingWindow,
+		leaseDefaults:        defaults,
+		conditionType:        c.conditionType,
+		clusterGracePeriod:   c.getClusterGracePeriod(),
+
+		useLeaseDurationSeconds:  c.useLeaseDurationSeconds,
+		clockRegressionTolerance: c.clockRegressionTolerance,
+	}
 }
 
 func (c *managedClusterAddOnLeaseController) syncSingle(ctx context.Context,
+	syncCtx factory.SyncContext,
 	leaseNamespace string,
-	addOn *addonv1alpha1.ManagedClusterAddOn,
-	recorder events.Recorder) error {
-	now := c.clock.Now()
-	gracePeriod := time.Duration(leaseDurationTimes*AddOnLeaseControllerLeaseDurationSeconds) * time.Second
+	leaseConfig *leaseConfig,
+	addOn *addonv1alpha1.ManagedClusterAddOn) (err error) {
+	if len(c.fixedLeaseNamespace) != 0 {
+		leaseNamespace = c.fixedLeaseNamespace
+	}
+	ctx, span := c.startSpan(ctx, "syncSingle",
+		attribute.String("addon", addOn.Name), attribute.String("leaseNamespace", leaseNamespace))
+	defer func() { endSpan(span, err) }()
 
 	// if the add-on agent is running on the managed cluster, try to fetch the add-on lease on the managed cluster,
 	// otherwise (running outside of the managed cluster), fetch the add-on lease on the management cluster instead.
 	leaseClient := c.spokeLeaseClient
-	if isAddonRunningOutsideManagedCluster(addOn) {
+	if leaseConfig.AgentRunningOutsideManagedCluster {
 		leaseClient = c.managementLeaseClient
 	}
 
-	// addon lease name should be same with the addon name.
-	observedLease, err := leaseClient.Leases(leaseNamespace).Get(ctx, addOn.Name, metav1.GetOptions{})
+	var observedLease *coordv1.Lease
+	var componentLeases []coordv1.Lease
+	switch {
+	case len(leaseConfig.heartbeatConfigMap) != 0:
+		// the addon agent reports its heartbeat with a configmap instead of a lease
+		observedLease, err = c.getHeartbeatConfigMapLease(ctx, leaseNamespace, leaseConfig)
+	case leaseConfig.componentLeaseSelector != nil:
+		// each component of the addon maintains its own lease
+		componentLeases, err = listComponentLeases(ctx, leaseClient, leaseNamespace, leaseConfig.componentLeaseSelector)
+		observedLease = latestLease(componentLeases)
+	default:
+		observedLease, err = getAddOnLease(ctx, leaseClient, leaseNamespace, leaseConfig)
+		if errors.IsNotFound(err) {
+			// for backward compatible, for lower versions kubernetes (less than 1.14), addons update their leases on hub
+			// cluster, so if we cannot find addon lease on managed/management cluster, we will try to use addon hub lease.
+			// TODO remove this after we no longer support lower versions kubernetes (less than 1.14)
+			observedLease, err = c.hubLeaseClient.Leases(addOn.Namespace).Get(ctx, addOn.Name, metav1.GetOptions{})
+			if err != nil {
+				// the addon lease is not found
+				observedLease, err = nil, nil
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
 
+	checker := c.availabilityChecker
+	if checker == nil {
+		checker = c.leaseAvailabilityChecker()
+	}
 	var condition metav1.Condition
+	if len(componentLeases) != 0 {
+		condition, err = checkComponentLeases(ctx, checker, c.conditionType, addOn, componentLeases)
+	} else {
+		condition, err = checker.Check(ctx, addOn, observedLease)
+	}
+	if err != nil {
+		return err
+	}
+	if observedLease == nil && condition.Status == metav1.ConditionUnknown {
+		// the lease may be absent since the addon is not installed completely rather than its agent is down
+		missingCondition, missing, err := c.getNamespaceMissingCondition(addOn, leaseConfig)
+		if err != nil {
+			return err
+		}
+		if missing {
+			condition = missingCondition
+		}
+	}
+	if condition.Status == metav1.ConditionTrue {
+		// the addon is not available if its dependencies are unavailable even if its own lease is fresh
+		if dependencyCondition, ok := c.getDependencyUnavailableCondition(addOn); ok {
+			condition = dependencyCondition
+		}
+	}
+	// the message is customized by the addon, the forced condition keeps the message of the operator
+	condition.Message = renderConditionMessage(leaseConfig, condition, observedLease)
+	if forced, ok := getForcedAvailableCondition(c.conditionType, addOn); ok {
+		// the available condition is pinned by the operator, the lease is still observed for the metrics
+		condition = forced
+	}
+	// the condition of a customized AvailabilityChecker is reported with the condition type of the controller as well
+	condition.Type = c.conditionType
+
+	if c.durationValidator != nil {
+		c.durationValidator.validate(c.clusterName, addOn.Name, observedLease, leaseConfig.leaseDurationSeconds,
+			syncCtx.Recorder())
+	}
+	if c.holderChurnDetector != nil {
+		c.holderChurnDetector.observe(addOn.Name, observedLease, syncCtx.Recorder())
+	}
+
+	agentVersion := getAgentVersion(observedLease)
+	if _, forced := getForcedAvailableCondition(c.conditionType, addOn); !forced && condition.Status == metav1.ConditionTrue {
+		// the available addon is not available if its lease is renewed by a stale agent
+		if staleCondition, stale := c.getStaleAgentVersionCondition(addOn, agentVersion, syncCtx.Recorder()); stale {
+			condition = staleCondition
+		}
+	}
+	if len(agentVersion) != 0 {
+		condition.Message = fmt.Sprintf("%s The version of its agent is %s.", condition.Message, agentVersion)
+	}
+	if c.decodeHeartbeat {
+		heartbeat, err := decodeLeaseHeartbeat(observedLease)
+		if err != nil {
+			// the malformed heartbeat is ignored
+			klog.V(4).InfoS("Ignore the malformed heartbeat of the addon lease",
+				"cluster", c.clusterName, "addon", addOn.Name, "reason", err.Error())
+		}
+		if len(heartbeat) != 0 {
+			condition.Message = fmt.Sprintf("%s The heartbeat of its agent: %s", condition.Message, heartbeat)
+		}
+	}
+	condition = c.mutateCondition(addOn, condition)
+	setConditionAttributes(span, condition)
+
+	c.recordLeaseEstablished(addOn, condition, syncCtx.Recorder())
+	observedHealth := addOnLeaseHealth{Name: addOn.Name, Status: condition.Status, Reason: condition.Reason, Version: agentVersion,
+		generation: addOn.Generation, annotationsHash: hashAddOnAnnotations(addOn)}
+	if observedLease != nil {
+		observedHealth.RenewTime = observedLease.Spec.RenewTime
+	}
+	// the previous renew time of the lease is remembered by the observed leases
+	if previous, ok := c.observedLeases.get(addOn.Name); ok && previous.RenewTime != nil &&
+		observedHealth.RenewTime != nil && observedHealth.RenewTime.After(previous.RenewTime.Time) {
+		addOnLeaseRenewalInterval.WithLabelValues(c.clusterName, addOn.Name).Observe(
+			observedHealth.RenewTime.Sub(previous.RenewTime.Time).Seconds())
+	}
+	c.observedLeases.set(observedHealth)
+	if observedHealth.RenewTime != nil {
+		addOnLeaseAge.WithLabelValues(c.clusterName, addOn.Name).Set(c.clock.Since(observedHealth.RenewTime.Time).Seconds())
+	} else {
+		addOnLeaseAge.DeleteLabelValues(c.clusterName, addOn.Name)
+	}
+	c.recordCurrentStateDuration(addOn, condition.Status)
 	switch {
-	case errors.IsNotFound(err):
-		// for backward compatible, for lower versions kubernetes (less than 1.14), addons update their leases on hub
-		// cluster, so if we cannot find addon lease on managed/management cluster, we will try to use addon hub lease.
-		// TODO remove this after we no longer support lower versions kubernetes (less than 1.14)
-		observedLease, err = c.hubLeaseClient.Leases(addOn.Namespace).Get(ctx, addOn.Name, metav1.GetOptions{})
-		if err == nil {
-			if now.Before(observedLease.Spec.RenewTime.Add(gracePeriod)) {
-				// the lease is constantly updated, update its addon status to available
-				condition = metav1.Condition{
-					Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
-					Status:  metav1.ConditionTrue,
-					Reason:  "ManagedClusterAddOnLeaseUpdated",
-					Message: fmt.Sprintf("%s add-on is available.", addOn.Name),
-				}
-				break
-			}
+	case c.statusSummary == nil:
+	case c.aggregateExclusion.excludes(addOn):
+		// the addon may be excluded after it is recorded
+		c.statusSummary.forget(addOn.Name)
+	default:
+		c.statusSummary.record(addOn.Name, condition.Status)
+	}
 
-			// the lease is not constantly updated, update its addon status to unavailable
-			condition = metav1.Condition{
-				Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
-				Status:  metav1.ConditionFalse,
-				Reason:  "ManagedClusterAddOnLeaseUpdateStopped",
-				Message: fmt.Sprintf("%s add-on is not available.", addOn.Name),
-			}
-			break
+	klog.V(4).InfoS("Addon lease is checked", "cluster", c.clusterName, "addon", addOn.Name,
+		"leaseNamespace", leaseNamespace, "status", condition.Status, "reason", condition.Reason)
+
+	if c.softReasonDebouncer != nil {
+		if remaining := c.softReasonDebouncer.remaining(addOn, condition); remaining > 0 {
+			// the soft reason has not persisted for the debounce duration, recheck the addon once it elapses
+			klog.V(4).InfoS("Defer the addon available condition", "cluster", c.clusterName, "addon", addOn.Name,
+				"reason", condition.Reason, "retryAfter", remaining)
+			syncCtx.Queue().AddAfter(fmt.Sprintf("%s/%s", leaseNamespace, addOn.Name), remaining)
+			span.SetAttributes(attribute.String("decision", syncDecisionDeferred))
+			return nil
 		}
-		condition = metav1.Condition{
-			Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+	}
+
+	if c.holdStickyAvailable(addOn, condition, syncCtx.Recorder()) {
+		// the addon is kept available until the annotation is removed
+		klog.V(4).InfoS("Hold the addon available condition", "cluster", c.clusterName, "addon", addOn.Name,
+			"reason", condition.Reason, "stickyAvailable", true)
+		span.SetAttributes(attribute.String("decision", syncDecisionHeld))
+		return nil
+	}
+
+	if held, remaining := c.holdDuringMaintenance(addOn, condition); held {
+		// the addon is not turned unavailable within the maintenance window, recheck it once the window ends or
+		// after the resync interval if the window has no end
+		klog.V(4).InfoS("Hold the addon available condition", "cluster", c.clusterName, "addon", addOn.Name,
+			"reason", condition.Reason, "maintenanceWindowRemaining", remaining)
+		if remaining > 0 {
+			syncCtx.Queue().AddAfter(fmt.Sprintf("%s/%s", leaseNamespace, addOn.Name), remaining)
+		}
+		span.SetAttributes(attribute.String("decision", syncDecisionHeld))
+		return nil
+	}
+
+	if c.statusUpdateBatchInterval > 0 {
+		// coalesce the status updates within the batch interval, the pending updates will be flushed
+		// once the interval elapses.
+		c.pendingStatusUpdates.add(addOn.Name, pendingStatusUpdate{leaseNamespace: leaseNamespace, condition: condition})
+		syncCtx.Queue().AddAfter(flushStatusQueueKey, c.statusUpdateBatchInterval)
+		span.SetAttributes(attribute.String("decision", syncDecisionBatched))
+		return nil
+	}
+
+	span.SetAttributes(attribute.String("decision", syncDecisionUpdate))
+	return c.updateAvailableCondition(ctx, addOn, leaseNamespace, condition, syncCtx.Recorder())
+}
+
+// recordCurrentStateDuration records how long the addon has been in the observed status. The duration is derived
+// from the last transition time of the existing available condition, and it starts from zero if the addon is
+// transitioning to the observed status.
+func (c *managedClusterAddOnLeaseController) recordCurrentStateDuration(
+	addOn *addonv1alpha1.ManagedClusterAddOn, status metav1.ConditionStatus) {
+	var duration time.Duration
+	existing := meta.FindStatusCondition(addOn.Status.Conditions, c.conditionType)
+	if existing != nil && existing.Status == status {
+		duration = c.clock.Since(existing.LastTransitionTime.Time)
+	}
+
+	for _, s := range []metav1.ConditionStatus{metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionUnknown} {
+		if s != status {
+			addOnCurrentStateDuration.DeleteLabelValues(c.clusterName, addOn.Name, string(s))
+		}
+	}
+	addOnCurrentStateDuration.WithLabelValues(c.clusterName, addOn.Name, string(status)).Set(duration.Seconds())
+}
+
+// getAgentVersion returns the agent version from the label or annotation of the addon lease, the label is preferred.
+// It is empty if the lease is nil or the version is not reported by the agent.
+func getAgentVersion(lease *coordv1.Lease) string {
+	if lease == nil {
+		return ""
+	}
+	if version := lease.Labels[agentVersionKey]; len(version) != 0 {
+		return version
+	}
+	return lease.Annotations[agentVersionKey]
+}
+
+// getLeaseGracePeriod returns the lease grace period overridden by the annotation of the addon, the default grace
+// period is returned if the annotation is absent. An invalid annotation is rejected by validateLeaseAnnotations
+// before the grace period is determined, so that the addon is reported with the reason ConfigUnresolvable.
+func getLeaseGracePeriod(leaseConfig *leaseConfig, defaultGracePeriod time.Duration) time.Duration {
+	if leaseConfig.leaseGraceSeconds > 0 {
+		return time.Duration(leaseConfig.leaseGraceSeconds) * time.Second
+	}
+	return defaultGracePeriod
+}
+
+// recordLeaseEstablished emits an event the first time the fresh lease of an addon whose availability is unknown
+// is observed, which indicates the addon agent starts to update its lease, and the available callback is called.
+func (c *managedClusterAddOnLeaseController) recordLeaseEstablished(addOn *addonv1alpha1.ManagedClusterAddOn,
+	condition metav1.Condition, recorder events.Recorder) {
+	if condition.Status != metav1.ConditionTrue {
+		return
+	}
+
+	existingCondition := meta.FindStatusCondition(addOn.Status.Conditions, condition.Type)
+	if existingCondition != nil && existingCondition.Status != metav1.ConditionUnknown {
+		return
+	}
+
+	c.establishedAddOnsLock.Lock()
+	defer c.establishedAddOnsLock.Unlock()
+	if c.establishedAddOns.Has(addOn.Name) {
+		return
+	}
+	c.establishedAddOns.Insert(addOn.Name)
+	recorder.Eventf("ManagedClusterAddOnLeaseEstablished",
+		"The lease of addon %s on managed cluster %s is established", addOn.Name, c.clusterName)
+	c.notifyAddOnAvailable(addOn.Name)
+}
+
+// forgetLeaseEstablished removes the addon from the reported addons, so that the event is emitted again if the
+// addon is recreated.
+func (c *managedClusterAddOnLeaseController) forgetLeaseEstablished(addOnName string) {
+	c.establishedAddOnsLock.Lock()
+	defer c.establishedAddOnsLock.Unlock()
+	c.establishedAddOns.Delete(addOnName)
+}
+
+// getAddOnLease returns the lease of an addon. If the addon has multiple leases selected by its lease selector, the
+// most recently renewed one is returned, so that the addon is considered available if any of its agent replicas
+// updates its lease constantly.
+func getAddOnLease(ctx context.Context, leaseClient coordv1client.CoordinationV1Interface,
+	leaseNamespace string, leaseConfig *leaseConfig) (*coordv1.Lease, error) {
+	if leaseConfig.leaseSelector == nil {
+		// addon lease name should be same with the addon name.
+		return leaseClient.Leases(leaseNamespace).Get(ctx, leaseConfig.addOnName, metav1.GetOptions{})
+	}
+
+	leases, err := leaseClient.Leases(leaseNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: leaseConfig.leaseSelector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lease := latestLease(leases.Items)
+	if lease == nil {
+		return nil, errors.NewNotFound(coordv1.Resource("leases"), leaseConfig.addOnName)
+	}
+
+	return lease, nil
+}
+
+// EvaluateAddOnAvailability returns the available condition of the addon addOnName by checking whether its lease is
+// renewed within the grace period at the given time, the status of the addon is unknown if the lease is nil. It has
+// no side effect and is the same decision made by the addon lease controller, so that it can be reused by the
+// external tools.
+func EvaluateAddOnAvailability(addOnName string, now time.Time, lease *coordv1.Lease,
+	gracePeriod time.Duration) metav1.Condition {
+	return getLeaseAvailableCondition(addonv1alpha1.ManagedClusterAddOnConditionAvailable, addOnName, lease, now, gracePeriod)
+}
+
+// getLeaseAvailableCondition returns the addon available condition by checking whether the addon lease is updated within
+// the grace period. If the lease has not been updated for more than half of the grace period, the addon is considered
+// degraded, this gives an early warning before the addon becomes unavailable. An unavailable addon whose lease has
+// not been renewed since it was created is distinguished from the one whose lease stops being renewed.
+func getLeaseAvailableCondition(conditionType, addOnName string, lease *coordv1.Lease, now time.Time,
+	gracePeriod time.Duration) metav1.Condition {
+	if lease == nil {
+		return metav1.Condition{
+			Type:    conditionType,
 			Status:  metav1.ConditionUnknown,
 			Reason:  "ManagedClusterAddOnLeaseNotFound",
-			Message: fmt.Sprintf("The status of %s add-on is unknown.", addOn.Name),
+			Message: fmt.Sprintf("The status of %s add-on is unknown.", addOnName),
 		}
-	case err != nil:
-		return err
-	case err == nil:
-		if now.Before(observedLease.Spec.RenewTime.Add(gracePeriod)) {
-			// the lease is constantly updated, update its addon status to available
-			condition = metav1.Condition{
-				Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
-				Status:  metav1.ConditionTrue,
-				Reason:  "ManagedClusterAddOnLeaseUpdated",
-				Message: fmt.Sprintf("%s add-on is available.", addOn.Name),
-			}
-			break
+	}
+
+	if lease.Spec.RenewTime == nil {
+		// the lease may be just created and has not been renewed yet
+		return metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "ManagedClusterAddOnLeaseNotRenewed",
+			Message: fmt.Sprintf("The status of %s add-on is unknown, its lease has not been renewed yet.", addOnName),
 		}
+	}
 
+	renewTime := lease.Spec.RenewTime.Time
+	lastRenewTime := renewTime.UTC().Format(time.RFC3339)
+	// the addon lease is classified by the same helper as the managed cluster lease on the hub
+	_, freshness := helpers.CheckLeaseFreshnessWithGracePeriod(renewTime, now, gracePeriod)
+	switch {
+	case freshness == helpers.LeaseFresh:
+		// the lease is constantly updated, update its addon status to available
+		return metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ManagedClusterAddOnLeaseUpdated",
+			Message: fmt.Sprintf("%s add-on is available, its lease was last renewed at %s.", addOnName, lastRenewTime),
+		}
+	case freshness == helpers.LeaseDegraded:
+		// the lease is not updated for a while, update its addon status to degraded
+		return metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ManagedClusterAddOnLeaseDegraded",
+			Message: fmt.Sprintf("%s add-on is degraded, its lease was last renewed at %s.", addOnName, lastRenewTime),
+		}
+	case renewTime.Truncate(time.Second).Equal(lease.CreationTimestamp.Time):
+		// the lease has not been renewed since it was created, the addon agent may never start heartbeating
+		return metav1.Condition{
+			Type:   conditionType,
+			Status: metav1.ConditionFalse,
+			Reason: "ManagedClusterAddOnLeaseNeverRenewed",
+			Message: fmt.Sprintf("%s add-on is not available, its lease was never renewed after it was created at %s.",
+				addOnName, lastRenewTime),
+		}
+	default:
 		// the lease is not constantly updated, update its addon status to unavailable
-		condition = metav1.Condition{
-			Type:    addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		return metav1.Condition{
+			Type:    conditionType,
 			Status:  metav1.ConditionFalse,
 			Reason:  "ManagedClusterAddOnLeaseUpdateStopped",
-			Message: fmt.Sprintf("%s add-on is not available.", addOn.Name),
+			Message: fmt.Sprintf("%s add-on is not available, its lease was last renewed at %s.", addOnName, lastRenewTime),
 		}
 	}
-
-	newAddon := addOn.DeepCopy()
-	meta.SetStatusCondition(&newAddon.Status.Conditions, condition)
-	updated, err := c.patcher.PatchStatus(ctx, newAddon, newAddon.Status, addOn.Status)
-	if err != nil {
-		return err
-	}
-	if updated {
-		recorder.Eventf("ManagedClusterAddOnStatusUpdated",
-			"update managed cluster addon %q available condition to %q with its lease %q/%q status",
-			addOn.Name, condition.Status, leaseNamespace, addOn.Name)
-	}
-
-	return nil
 }
 
 func (c *managedClusterAddOnLeaseController) queueKeyFunc(lease runtime.Object) string {
@@ -207,14 +1285,53 @@ func (c *managedClusterAddOnLeaseController) queueKeyFunc(lease runtime.Object)
 	name := accessor.GetName()
 	// addon lease name should be same with the addon name.
 	addOn, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).Get(name)
+	if errors.IsNotFound(err) {
+		// the addon is not found, ignore this reconciliation.
+		klog.V(4).InfoS("Ignore the lease whose addon is not found",
+			"cluster", c.clusterName, "addon", name, "reason", err.Error())
+		return ""
+	}
 	if err != nil {
-		// failed to get addon from hub, ignore this reconciliation.
+		// failed to get addon from the lister, e.g. the cache is warming up, requeue all of the addons to
+		// avoid missing the change of the lease.
+		klog.V(4).InfoS("Requeue all of the addons since failed to get the addon of the lease",
+			"cluster", c.clusterName, "addon", name, "reason", err.Error())
+		return factory.DefaultQueueKey
+	}
+
+	if !c.addOnSelector.Matches(labels.Set(addOn.Labels)) {
+		// the addon is not managed by this controller, ignore this reconciliation.
+		klog.V(4).InfoS("Ignore the lease whose addon is not selected",
+			"cluster", c.clusterName, "addon", name, "reason", "addon does not match the selector")
+		return ""
+	}
+
+	leaseConfig, err := c.getAddOnLeaseConfig(addOn)
+	if err != nil && !isAddOnConfigUnresolvable(err) {
+		// the addon lease configuration cannot be read for now, resync all of the addons to retry.
+		klog.V(3).InfoS("Resync the addons since the lease configuration cannot be read",
+			"cluster", c.clusterName, "addon", name, "reason", err.Error())
+		return factory.DefaultQueueKey
+	}
+	if err != nil {
+		// the addon lease configuration is invalid, ignore this reconciliation.
+		klog.V(3).InfoS("Ignore the lease whose addon has invalid lease configuration",
+			"cluster", c.clusterName, "addon", name, "reason", err.Error())
+		return ""
+	}
+	if isInstallationNamespaceEmpty(leaseConfig.addonInstallOption) {
+		// the addon is misconfigured, its status is reported by the resync instead.
+		klog.V(3).InfoS("Ignore the lease whose addon has empty installation namespace",
+			"cluster", c.clusterName, "addon", name)
 		return ""
 	}
 
 	namespace := accessor.GetNamespace()
-	if namespace != getAddOnInstallationNamespace(addOn) {
-		// the lease namesapce is not same with its addon installation namespace, ignore it.
+	if namespace != leaseConfig.leaseNamespace {
+		// the lease namesapce is not same with its addon lease namespace, ignore it.
+		klog.V(4).InfoS("Ignore the lease which is not in the addon lease namespace",
+			"cluster", c.clusterName, "addon", name, "reason",
+			fmt.Sprintf("lease namespace %q is not the expected %q", namespace, leaseConfig.leaseNamespace))
 		return ""
 	}
 
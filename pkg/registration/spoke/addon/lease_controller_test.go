@@ -3,12 +3,21 @@ package addon
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 	clocktesting "k8s.io/utils/clock/testing"
@@ -16,6 +25,7 @@ import (
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
 	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
 
 	"open-cluster-management.io/ocm/pkg/common/patcher"
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
@@ -26,10 +36,12 @@ var now = time.Now()
 
 func TestQueueKeyFunc(t *testing.T) {
 	cases := []struct {
-		name             string
-		addOns           []runtime.Object
-		lease            runtime.Object
-		expectedQueueKey string
+		name                string
+		addOns              []runtime.Object
+		addOnSelector       labels.Selector
+		fixedLeaseNamespace string
+		lease               runtime.Object
+		expectedQueueKey    string
 	}{
 		{
 			name:             "no addons",
@@ -45,6 +57,19 @@ func TestQueueKeyFunc(t *testing.T) {
 			lease:            testinghelpers.NewAddOnLease("test", "test", time.Now()),
 			expectedQueueKey: "",
 		},
+		{
+			name: "empty install namespace",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   testinghelpers.TestManagedClusterName,
+					Name:        "test",
+					Annotations: map[string]string{leaseNamespaceAnnotation: "test"},
+				},
+				Status: addonv1alpha1.ManagedClusterAddOnStatus{Namespace: " "},
+			}},
+			lease:            testinghelpers.NewAddOnLease("test", "test", time.Now()),
+			expectedQueueKey: "",
+		},
 		{
 			name: "different install namespace",
 			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
@@ -73,6 +98,59 @@ func TestQueueKeyFunc(t *testing.T) {
 			lease:            testinghelpers.NewAddOnLease("test", "test", time.Now()),
 			expectedQueueKey: "test/test",
 		},
+		{
+			name: "an addon lease in customized namespace",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+					Annotations: map[string]string{
+						leaseNamespaceAnnotation: "operators",
+					},
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			lease:            testinghelpers.NewAddOnLease("operators", "test", time.Now()),
+			expectedQueueKey: "operators/test",
+		},
+		{
+			name: "an addon lease not selected",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+					Labels:    map[string]string{"shard": "b"},
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			addOnSelector:    labels.SelectorFromSet(labels.Set{"shard": "a"}),
+			lease:            testinghelpers.NewAddOnLease("test", "test", time.Now()),
+			expectedQueueKey: "",
+		},
+		{
+			name: "an addon lease in the fixed lease namespace",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: "test"},
+				Spec:       addonv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "test"},
+			}},
+			fixedLeaseNamespace: "addon-leases",
+			lease:               testinghelpers.NewAddOnLease("addon-leases", "test", time.Now()),
+			expectedQueueKey:    "addon-leases/test",
+		},
+		{
+			name: "an addon lease out of the fixed lease namespace",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: "test"},
+				Spec:       addonv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "test"},
+			}},
+			fixedLeaseNamespace: "addon-leases",
+			lease:               testinghelpers.NewAddOnLease("test", "test", time.Now()),
+			expectedQueueKey:    "",
+		},
 	}
 
 	for _, c := range cases {
@@ -87,8 +165,13 @@ func TestQueueKeyFunc(t *testing.T) {
 			}
 
 			ctrl := &managedClusterAddOnLeaseController{
-				clusterName: testinghelpers.TestManagedClusterName,
-				addOnLister: addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				clusterName:         testinghelpers.TestManagedClusterName,
+				addOnLister:         addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				addOnSelector:       labels.Everything(),
+				fixedLeaseNamespace: c.fixedLeaseNamespace,
+			}
+			if c.addOnSelector != nil {
+				ctrl.addOnSelector = c.addOnSelector
 			}
 			actualQueueKey := ctrl.queueKeyFunc(c.lease)
 			if actualQueueKey != c.expectedQueueKey {
@@ -98,15 +181,42 @@ func TestQueueKeyFunc(t *testing.T) {
 	}
 }
 
+type failedAddOnLister struct{}
+
+func (f *failedAddOnLister) List(_ labels.Selector) ([]*addonv1alpha1.ManagedClusterAddOn, error) {
+	return nil, fmt.Errorf("cache is not synced")
+}
+
+func (f *failedAddOnLister) ManagedClusterAddOns(_ string) addonlisterv1alpha1.ManagedClusterAddOnNamespaceLister {
+	return f
+}
+
+func (f *failedAddOnLister) Get(_ string) (*addonv1alpha1.ManagedClusterAddOn, error) {
+	return nil, fmt.Errorf("cache is not synced")
+}
+
+func TestQueueKeyFuncWithListerError(t *testing.T) {
+	ctrl := &managedClusterAddOnLeaseController{
+		clusterName:   testinghelpers.TestManagedClusterName,
+		addOnLister:   &failedAddOnLister{},
+		addOnSelector: labels.Everything(),
+	}
+	actualQueueKey := ctrl.queueKeyFunc(testinghelpers.NewAddOnLease("test", "test", time.Now()))
+	if actualQueueKey != factory.DefaultQueueKey {
+		t.Errorf("expected queue key %q, but got %q", factory.DefaultQueueKey, actualQueueKey)
+	}
+}
+
 func TestSync(t *testing.T) {
 	cases := []struct {
-		name             string
-		queueKey         string
-		addOns           []runtime.Object
-		hubLeases        []runtime.Object
-		managementLeases []runtime.Object
-		spokeLeases      []runtime.Object
-		validateActions  func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action)
+		name               string
+		queueKey           string
+		addOns             []runtime.Object
+		hubLeases          []runtime.Object
+		managementLeases   []runtime.Object
+		spokeLeases        []runtime.Object
+		leaseDurationTimes int
+		validateActions    func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action)
 	}{
 		{
 			name:        "bad queue key",
@@ -194,6 +304,213 @@ func TestSync(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:     "addon with invalid lease duration seconds",
+			queueKey: "test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+					Annotations: map[string]string{
+						leaseDurationSecondsAnnotation: "abc",
+					},
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			hubLeases:   []runtime.Object{},
+			spokeLeases: []runtime.Object{},
+			validateActions: func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertAvailableCondition(t, actions[0], metav1.ConditionUnknown, "ManagedClusterAddOnConfigUnresolvable")
+			},
+		},
+		{
+			name:     "addon with empty installation namespace",
+			queueKey: " /test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+				},
+				Status: addonv1alpha1.ManagedClusterAddOnStatus{Namespace: " "},
+			}},
+			hubLeases:   []runtime.Object{},
+			spokeLeases: []runtime.Object{testinghelpers.NewAddOnLease(" ", "test", time.Now())},
+			validateActions: func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertAvailableCondition(t, actions[0], metav1.ConditionUnknown, "ManagedClusterAddOnInstallNamespaceEmpty")
+			},
+		},
+		{
+			name:     "addon with customized lease duration seconds",
+			queueKey: "test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+					Annotations: map[string]string{
+						leaseDurationSecondsAnnotation: "180",
+					},
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			hubLeases: []runtime.Object{},
+			spokeLeases: []runtime.Object{
+				testinghelpers.NewAddOnLease("test", "test", now.Add(-5*time.Minute)),
+			},
+			validateActions: func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				patch := actions[0].(clienttesting.PatchAction).GetPatch()
+				addOn := &addonv1alpha1.ManagedClusterAddOn{}
+				err := json.Unmarshal(patch, addOn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				addOnCond := meta.FindStatusCondition(addOn.Status.Conditions, "Available")
+				if addOnCond == nil {
+					t.Errorf("expected addon available condition, but failed")
+					return
+				}
+				if addOnCond.Status != metav1.ConditionTrue {
+					t.Errorf("expected addon available condition is available, but failed")
+				}
+			},
+		},
+		{
+			name:     "addon with customized lease duration times",
+			queueKey: "test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			hubLeases: []runtime.Object{},
+			spokeLeases: []runtime.Object{
+				testinghelpers.NewAddOnLease("test", "test", now.Add(-5*time.Minute)),
+			},
+			leaseDurationTimes: 12,
+			validateActions: func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				patch := actions[0].(clienttesting.PatchAction).GetPatch()
+				addOn := &addonv1alpha1.ManagedClusterAddOn{}
+				err := json.Unmarshal(patch, addOn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				addOnCond := meta.FindStatusCondition(addOn.Status.Conditions, "Available")
+				if addOnCond == nil {
+					t.Errorf("expected addon available condition, but failed")
+					return
+				}
+				if addOnCond.Status != metav1.ConditionTrue {
+					t.Errorf("expected addon available condition is available, but failed")
+				}
+			},
+		},
+		{
+			name:     "addon lease is degraded",
+			queueKey: "test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			hubLeases: []runtime.Object{},
+			spokeLeases: []runtime.Object{
+				testinghelpers.NewAddOnLease("test", "test", now.Add(-3*time.Minute)),
+			},
+			validateActions: func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertAvailableCondition(t, actions[0], metav1.ConditionFalse, "ManagedClusterAddOnLeaseDegraded")
+			},
+		},
+		{
+			name:     "addon lease is not renewed",
+			queueKey: "test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			hubLeases: []runtime.Object{},
+			spokeLeases: []runtime.Object{
+				&coordv1.Lease{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test"},
+				},
+			},
+			validateActions: func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertAvailableCondition(t, actions[0], metav1.ConditionUnknown, "ManagedClusterAddOnLeaseNotRenewed")
+			},
+		},
+		{
+			name:     "one of the addon agent replicas updates its lease constantly",
+			queueKey: "test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+					Annotations: map[string]string{
+						leaseSelectorAnnotation: "app=test",
+					},
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			hubLeases: []runtime.Object{},
+			spokeLeases: []runtime.Object{
+				newLabeledAddOnLease("test", "test-0", now.Add(-5*time.Minute), map[string]string{"app": "test"}),
+				newLabeledAddOnLease("test", "test-1", now, map[string]string{"app": "test"}),
+				newLabeledAddOnLease("test", "other", now.Add(-5*time.Minute), map[string]string{"app": "other"}),
+			},
+			validateActions: func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+			},
+		},
+		{
+			name:     "all of the addon agent replicas stop to update their leases",
+			queueKey: "test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testinghelpers.TestManagedClusterName,
+					Name:      "test",
+					Annotations: map[string]string{
+						leaseSelectorAnnotation: "app=test",
+					},
+				},
+				Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+					InstallNamespace: "test",
+				},
+			}},
+			hubLeases: []runtime.Object{},
+			spokeLeases: []runtime.Object{
+				newLabeledAddOnLease("test", "test-0", now.Add(-5*time.Minute), map[string]string{"app": "test"}),
+				newLabeledAddOnLease("test", "test-1", now.Add(-6*time.Minute), map[string]string{"app": "test"}),
+				newLabeledAddOnLease("test", "other", now, map[string]string{"app": "other"}),
+			},
+			validateActions: func(t *testing.T, ctx *testingcommon.FakeSyncContext, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "patch")
+				assertAvailableCondition(t, actions[0], metav1.ConditionFalse, "ManagedClusterAddOnLeaseUpdateStopped")
+			},
+		},
 		{
 			name:     "addon update its lease constantly",
 			queueKey: "test/test",
@@ -245,7 +562,8 @@ func TestSync(t *testing.T) {
 							Type:    "Available",
 							Status:  metav1.ConditionTrue,
 							Reason:  "ManagedClusterAddOnLeaseUpdated",
-							Message: "test add-on is available.",
+							Message: fmt.Sprintf("test add-on is available, its lease was last renewed at %s.",
+								now.UTC().Format(time.RFC3339)),
 						},
 					},
 				},
@@ -391,16 +709,28 @@ func TestSync(t *testing.T) {
 			managementLeaseClient := kubefake.NewSimpleClientset(c.managementLeases...)
 			spokeLeaseClient := kubefake.NewSimpleClientset(c.spokeLeases...)
 
+			leaseDurationTimes := c.leaseDurationTimes
+			if leaseDurationTimes == 0 {
+				leaseDurationTimes = defaultLeaseDurationTimes
+			}
+
+			registerLeaseMetrics()
 			ctrl := &managedClusterAddOnLeaseController{
 				clusterName:    testinghelpers.TestManagedClusterName,
 				clock:          clocktesting.NewFakeClock(time.Now()),
+				conditionType:  addonv1alpha1.ManagedClusterAddOnConditionAvailable,
 				hubLeaseClient: hubClient.CoordinationV1(),
 				patcher: patcher.NewPatcher[
 					*addonv1alpha1.ManagedClusterAddOn, addonv1alpha1.ManagedClusterAddOnSpec, addonv1alpha1.ManagedClusterAddOnStatus](
 					addOnClient.AddonV1alpha1().ManagedClusterAddOns(testinghelpers.TestManagedClusterName)),
 				addOnLister:           addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				addOnSelector:         labels.Everything(),
 				managementLeaseClient: managementLeaseClient.CoordinationV1(),
 				spokeLeaseClient:      spokeLeaseClient.CoordinationV1(),
+				leaseDurationTimes:    leaseDurationTimes,
+				establishedAddOns:     sets.New[string](),
+				suspendedAddOns:       sets.New[string](),
+				observedLeases:        newObservedLeases(),
 			}
 			syncCtx := testingcommon.NewFakeSyncContext(t, c.queueKey)
 			syncErr := ctrl.sync(context.TODO(), syncCtx)
@@ -412,3 +742,725 @@ func TestSync(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncAbortedByResyncTimeout(t *testing.T) {
+	addOns := []runtime.Object{
+		&addonv1alpha1.ManagedClusterAddOn{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: "test1"},
+		},
+		&addonv1alpha1.ManagedClusterAddOn{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: "test2"},
+		},
+	}
+	ctrl, _ := newTestLeaseController(t, addOns, []runtime.Object{})
+	ctrl.resyncTimeout = time.Minute
+
+	// the resync is aborted once its context is done
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	syncCtx := testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+	if err := ctrl.sync(ctx, syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if syncCtx.Queue().Len() != 0 {
+		t.Errorf("expected no addons in queue, but got %d", syncCtx.Queue().Len())
+	}
+
+	syncCtx = testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if syncCtx.Queue().Len() != 2 {
+		t.Errorf("expected two addons in queue, but got %d", syncCtx.Queue().Len())
+	}
+}
+
+func TestSyncWithClockSkewTolerance(t *testing.T) {
+	cases := []struct {
+		name               string
+		clockSkewTolerance time.Duration
+		expectedStatus     metav1.ConditionStatus
+		expectedReason     string
+	}{
+		{
+			name:           "no clock skew tolerance",
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseDegraded",
+		},
+		{
+			name:               "with clock skew tolerance",
+			clockSkewTolerance: 30 * time.Second,
+			expectedStatus:     metav1.ConditionTrue,
+			expectedReason:     "ManagedClusterAddOnLeaseUpdated",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			renewTime := time.Now()
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", renewTime)})
+			// the clock of the controller is ahead of the addon agent
+			ctrl.clock = clocktesting.NewFakeClock(renewTime.Add(170 * time.Second))
+			ctrl.clockSkewTolerance = c.clockSkewTolerance
+
+			syncCtx := testingcommon.NewFakeSyncContext(t, "test/test")
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], c.expectedStatus, c.expectedReason)
+		})
+	}
+}
+
+func TestRefreshAddOn(t *testing.T) {
+	addOns := []runtime.Object{
+		testinghelpers.NewFakeAddOn("test", "test"),
+		&addonv1alpha1.ManagedClusterAddOn{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: "customized"},
+			Spec:       addonv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "test"},
+			Status: addonv1alpha1.ManagedClusterAddOnStatus{
+				HealthCheck: addonv1alpha1.HealthCheck{Mode: addonv1alpha1.HealthCheckModeCustomized},
+			},
+		},
+	}
+	ctrl, addOnClient := newTestLeaseController(t, addOns,
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+
+	if err := ctrl.RefreshAddOn(context.TODO(), "test"); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+
+	// the addon with customized health check is not refreshed
+	addOnClient.ClearActions()
+	if err := ctrl.RefreshAddOn(context.TODO(), "customized"); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+
+	if err := ctrl.RefreshAddOn(context.TODO(), "nonexistent"); !errors.IsNotFound(err) {
+		t.Errorf("expected not found error, but got %v", err)
+	}
+}
+
+func TestSyncWithAddOnSelector(t *testing.T) {
+	addOns := []runtime.Object{
+		&addonv1alpha1.ManagedClusterAddOn{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testinghelpers.TestManagedClusterName,
+				Name:      "test1",
+				Labels:    map[string]string{"shard": "a"},
+			},
+			Spec: addonv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "test"},
+		},
+		&addonv1alpha1.ManagedClusterAddOn{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testinghelpers.TestManagedClusterName,
+				Name:      "test2",
+				Labels:    map[string]string{"shard": "b"},
+			},
+			Spec: addonv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "test"},
+		},
+	}
+	ctrl, addOnClient := newTestLeaseController(t, addOns, []runtime.Object{
+		testinghelpers.NewAddOnLease("test", "test1", time.Now()),
+		testinghelpers.NewAddOnLease("test", "test2", time.Now()),
+	})
+	ctrl.addOnSelector = labels.SelectorFromSet(labels.Set{"shard": "a"})
+
+	syncCtx := testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if syncCtx.Queue().Len() != 1 {
+		t.Errorf("expected one addon in queue, but got %d", syncCtx.Queue().Len())
+	}
+
+	for _, queueKey := range []string{"test/test1", "test/test2"} {
+		if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, queueKey)); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	if name := actions[0].(clienttesting.PatchAction).GetName(); name != "test1" {
+		t.Errorf("expected addon test1 is patched, but got %s", name)
+	}
+}
+
+func TestResyncSkipsUnchangedAddOns(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	renewTime := time.Now()
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", renewTime)})
+	fakeClock := clocktesting.NewFakeClock(renewTime)
+	ctrl.clock = fakeClock
+
+	// the addon is enqueued if it has not been checked
+	syncCtx := testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if syncCtx.Queue().Len() != 1 {
+		t.Errorf("expected one addon in queue, but got %d", syncCtx.Queue().Len())
+	}
+
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	updated, err := addOnClient.AddonV1alpha1().ManagedClusterAddOns(testinghelpers.TestManagedClusterName).Get(
+		context.TODO(), "test", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ctrl.isAvailabilityUnchanged(updated, &leaseConfig{leaseDurationSeconds: 60}) {
+		t.Errorf("expected the availability of the addon is unchanged, but failed")
+	}
+	// the addon whose condition is changed by others is checked
+	if ctrl.isAvailabilityUnchanged(addOn, &leaseConfig{leaseDurationSeconds: 60}) {
+		t.Errorf("expected the availability of the addon is changed, but failed")
+	}
+	// the addon is checked once its annotations are changed
+	annotated := updated.DeepCopy()
+	annotated.Annotations = map[string]string{forceStatusAnnotation: "Unavailable"}
+	if ctrl.isAvailabilityUnchanged(annotated, &leaseConfig{leaseDurationSeconds: 60}) {
+		t.Errorf("expected the addon with the changed annotations is checked, but failed")
+	}
+	syncCtx = testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+	addOnInformer := addoninformers.NewSharedInformerFactory(addonfake.NewSimpleClientset(), time.Minute).
+		Addon().V1alpha1().ManagedClusterAddOns()
+	if err := addOnInformer.Informer().GetStore().Add(annotated); err != nil {
+		t.Fatal(err)
+	}
+	ctrl.addOnLister = addOnInformer.Lister()
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if syncCtx.Queue().Len() != 1 {
+		t.Errorf("expected the addon with the changed annotations is enqueued, but got %d", syncCtx.Queue().Len())
+	}
+	// the addon is checked once its last observed lease is not fresh
+	fakeClock.Step(3 * time.Minute)
+	if ctrl.isAvailabilityUnchanged(updated, &leaseConfig{leaseDurationSeconds: 60}) {
+		t.Errorf("expected the availability of the addon is changed, but failed")
+	}
+}
+
+func TestJitterResyncInterval(t *testing.T) {
+	interval := 5 * time.Minute
+	if actual := jitterResyncInterval(interval, -1); actual != interval {
+		t.Errorf("expected interval %v without jitter, but got %v", interval, actual)
+	}
+	for i := 0; i < 10; i++ {
+		actual := jitterResyncInterval(interval, defaultResyncJitterFactor)
+		if actual < interval || actual >= interval+interval/10 {
+			t.Errorf("expected interval in [%v, %v), but got %v", interval, interval+interval/10, actual)
+		}
+	}
+}
+
+func TestGetLeaseGracePeriod(t *testing.T) {
+	defaultGracePeriod := 5 * time.Minute
+	cases := []struct {
+		name                string
+		annotations         map[string]string
+		expectedErr         bool
+		expectedGracePeriod time.Duration
+	}{
+		{
+			name:                "no annotation",
+			expectedGracePeriod: defaultGracePeriod,
+		},
+		{
+			name:                "customized grace period",
+			annotations:         map[string]string{leaseGraceSecondsAnnotation: "600"},
+			expectedGracePeriod: 10 * time.Minute,
+		},
+		{
+			name:        "invalid grace period",
+			annotations: map[string]string{leaseGraceSecondsAnnotation: "abc"},
+			expectedErr: true,
+		},
+		{
+			name:        "non-positive grace period",
+			annotations: map[string]string{leaseGraceSecondsAnnotation: "0"},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := &addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: c.annotations},
+			}
+			// the invalid grace period is rejected rather than falling back to the default
+			config, errs := validateLeaseAnnotations(addOn)
+			if c.expectedErr {
+				if len(errs) == 0 {
+					t.Errorf("expected the grace period is rejected, but got %+v", config)
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("unexpected errs: %v", errs)
+			}
+			if actual := getLeaseGracePeriod(config, defaultGracePeriod); actual != c.expectedGracePeriod {
+				t.Errorf("expected grace period %v, but got %v", c.expectedGracePeriod, actual)
+			}
+		})
+	}
+}
+
+func TestRecordLeaseEstablished(t *testing.T) {
+	availableCondition := metav1.Condition{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionTrue,
+		Reason: "ManagedClusterAddOnLeaseUpdated",
+	}
+	newAddOn := func(name string, status metav1.ConditionStatus) *addonv1alpha1.ManagedClusterAddOn {
+		addOn := &addonv1alpha1.ManagedClusterAddOn{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: name},
+		}
+		if len(status) != 0 {
+			addOn.Status.Conditions = []metav1.Condition{
+				{Type: addonv1alpha1.ManagedClusterAddOnConditionAvailable, Status: status},
+			}
+		}
+		return addOn
+	}
+
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{}, []runtime.Object{})
+	recorder := events.NewInMemoryRecorder("test")
+
+	// the event is emitted once for the addon whose status is unknown
+	ctrl.recordLeaseEstablished(newAddOn("test1", ""), availableCondition, recorder)
+	ctrl.recordLeaseEstablished(newAddOn("test1", metav1.ConditionUnknown), availableCondition, recorder)
+	ctrl.recordLeaseEstablished(newAddOn("test2", metav1.ConditionUnknown), availableCondition, recorder)
+	// the event is not emitted for the addon which was available or unavailable
+	ctrl.recordLeaseEstablished(newAddOn("test3", metav1.ConditionFalse), availableCondition, recorder)
+	// the event is not emitted if the lease is not fresh
+	unavailableCondition := availableCondition
+	unavailableCondition.Status = metav1.ConditionFalse
+	ctrl.recordLeaseEstablished(newAddOn("test4", metav1.ConditionUnknown), unavailableCondition, recorder)
+
+	if len(recorder.Events()) != 2 {
+		t.Errorf("expected 2 events, but got %d", len(recorder.Events()))
+	}
+	for _, event := range recorder.Events() {
+		if event.Reason != "ManagedClusterAddOnLeaseEstablished" {
+			t.Errorf("unexpected event %q", event.Reason)
+		}
+	}
+
+	// the event is emitted again after the addon is recreated
+	ctrl.forgetLeaseEstablished("test1")
+	ctrl.recordLeaseEstablished(newAddOn("test1", ""), availableCondition, recorder)
+	if len(recorder.Events()) != 3 {
+		t.Errorf("expected 3 events, but got %d", len(recorder.Events()))
+	}
+}
+
+func assertAvailableCondition(t *testing.T, action clienttesting.Action,
+	expectedStatus metav1.ConditionStatus, expectedReason string) {
+	t.Helper()
+	patch := action.(clienttesting.PatchAction).GetPatch()
+	addOn := &addonv1alpha1.ManagedClusterAddOn{}
+	if err := json.Unmarshal(patch, addOn); err != nil {
+		t.Fatal(err)
+	}
+	addOnCond := meta.FindStatusCondition(addOn.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable)
+	if addOnCond == nil {
+		t.Errorf("expected addon available condition, but failed")
+		return
+	}
+	if addOnCond.Status != expectedStatus {
+		t.Errorf("expected addon available condition status %q, but got %q", expectedStatus, addOnCond.Status)
+	}
+	if addOnCond.Reason != expectedReason {
+		t.Errorf("expected addon available condition reason %q, but got %q", expectedReason, addOnCond.Reason)
+	}
+}
+
+func newLabeledAddOnLease(namespace, name string, renewTime time.Time, labels map[string]string) *coordv1.Lease {
+	lease := testinghelpers.NewAddOnLease(namespace, name, renewTime)
+	lease.Labels = labels
+	return lease
+}
+
+func TestSyncWithResyncOverride(t *testing.T) {
+	cases := []struct {
+		name          string
+		annotations   map[string]string
+		expectedQueue int
+	}{
+		{
+			name:          "default resync",
+			expectedQueue: 0,
+		},
+		{
+			name:          "resync override",
+			annotations:   map[string]string{leaseResyncSecondsAnnotation: "1"},
+			expectedQueue: 1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = c.annotations
+			ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+
+			syncCtx := testingcommon.NewFakeSyncContext(t, "test/test")
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			// wait for the override resync interval to elapse
+			time.Sleep(1500 * time.Millisecond)
+			if syncCtx.Queue().Len() != c.expectedQueue {
+				t.Errorf("expected %d addons in queue, but got %d", c.expectedQueue, syncCtx.Queue().Len())
+			}
+		})
+	}
+}
+
+func newTestLeaseController(t *testing.T, addOns, spokeLeases []runtime.Object) (
+	*managedClusterAddOnLeaseController, *addonfake.Clientset) {
+	registerLeaseMetrics()
+
+	addOnClient := addonfake.NewSimpleClientset(addOns...)
+	addOnInformerFactory := addoninformers.NewSharedInformerFactory(addOnClient, time.Minute*10)
+	addOnStore := addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore()
+	for _, addOn := range addOns {
+		if err := addOnStore.Add(addOn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctrl := &managedClusterAddOnLeaseController{
+		clusterName:    testinghelpers.TestManagedClusterName,
+		clock:          clocktesting.NewFakeClock(time.Now()),
+		conditionType:  addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		hubLeaseClient: kubefake.NewSimpleClientset().CoordinationV1(),
+		patcher: patcher.NewPatcher[
+			*addonv1alpha1.ManagedClusterAddOn, addonv1alpha1.ManagedClusterAddOnSpec, addonv1alpha1.ManagedClusterAddOnStatus](
+			addOnClient.AddonV1alpha1().ManagedClusterAddOns(testinghelpers.TestManagedClusterName)),
+		addOnClient:           addOnClient.AddonV1alpha1().ManagedClusterAddOns(testinghelpers.TestManagedClusterName),
+		addOnLister:           addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+		addOnSelector:         labels.Everything(),
+		managementLeaseClient: kubefake.NewSimpleClientset().CoordinationV1(),
+		spokeLeaseClient:      kubefake.NewSimpleClientset(spokeLeases...).CoordinationV1(),
+		leaseDurationTimes:    defaultLeaseDurationTimes,
+		syncCtx:               testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey),
+		establishedAddOns:     sets.New[string](),
+		suspendedAddOns:       sets.New[string](),
+		observedLeases:        newObservedLeases(),
+	}
+	return ctrl, addOnClient
+}
+
+func TestSyncWithAgentVersion(t *testing.T) {
+	cases := []struct {
+		name            string
+		labels          map[string]string
+		annotations     map[string]string
+		expectedVersion string
+	}{
+		{
+			name: "no version",
+		},
+		{
+			name:            "version in label",
+			labels:          map[string]string{agentVersionKey: "v1.0.0"},
+			annotations:     map[string]string{agentVersionKey: "v0.9.0"},
+			expectedVersion: "v1.0.0",
+		},
+		{
+			name:            "version in annotation",
+			annotations:     map[string]string{agentVersionKey: "v0.9.0"},
+			expectedVersion: "v0.9.0",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			lease := newLabeledAddOnLease("test", "test", time.Now(), c.labels)
+			lease.Annotations = c.annotations
+			ctrl, addOnClient := newTestLeaseController(t,
+				[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")}, []runtime.Object{lease})
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+
+			addOn := &addonv1alpha1.ManagedClusterAddOn{}
+			if err := json.Unmarshal(actions[0].(clienttesting.PatchAction).GetPatch(), addOn); err != nil {
+				t.Fatal(err)
+			}
+			message := meta.FindStatusCondition(addOn.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable).Message
+			hasVersion := strings.Contains(message, "The version of its agent is")
+			if hasVersion != (len(c.expectedVersion) != 0) || !strings.Contains(message, c.expectedVersion) {
+				t.Errorf("expected version %q in message, but got %q", c.expectedVersion, message)
+			}
+
+			health, _ := ctrl.observedLeases.get("test")
+			if health.Version != c.expectedVersion {
+				t.Errorf("expected observed version %q, but got %q", c.expectedVersion, health.Version)
+			}
+		})
+	}
+}
+
+func TestSyncConcurrently(t *testing.T) {
+	var addOns, leases []runtime.Object
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("test%d", i)
+		addOns = append(addOns, testinghelpers.NewFakeAddOn(name, "test"))
+		leases = append(leases, testinghelpers.NewAddOnLease("test", name, time.Now()))
+	}
+	ctrl, addOnClient := newTestLeaseController(t, addOns, leases)
+
+	// the addons are synced by multiple workers at the same time
+	var wg sync.WaitGroup
+	for i := 0; i < len(addOns); i++ {
+		syncCtx := testingcommon.NewFakeSyncContext(t, fmt.Sprintf("test/test%d", i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	patches := 0
+	for _, action := range addOnClient.Actions() {
+		if action.GetVerb() == "patch" {
+			patches++
+		}
+	}
+	if patches != len(addOns) {
+		t.Errorf("expected %d patches, but got %d", len(addOns), patches)
+	}
+	for i := 0; i < len(addOns); i++ {
+		if _, ok := ctrl.observedLeases.get(fmt.Sprintf("test%d", i)); !ok {
+			t.Errorf("expected the lease of addon test%d is observed", i)
+		}
+	}
+}
+
+func TestSyncWithFixedLeaseNamespace(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+		[]runtime.Object{testinghelpers.NewAddOnLease("addon-leases", "test", time.Now())})
+	ctrl.fixedLeaseNamespace = "addon-leases"
+
+	// the addon is enqueued with the fixed lease namespace by the resync
+	syncCtx := testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if syncCtx.Queue().Len() != 1 {
+		t.Fatalf("expected 1 queued addon, but got %d", syncCtx.Queue().Len())
+	}
+	key, _ := syncCtx.Queue().Get()
+	if key != "addon-leases/test" {
+		t.Errorf("expected the addon is queued with the fixed lease namespace, but got %q", key)
+	}
+
+	// the lease is resolved from the fixed lease namespace regardless of the installation namespace
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+}
+
+func TestEvaluateAddOnAvailability(t *testing.T) {
+	now := time.Now()
+	gracePeriod := 5 * time.Minute
+
+	cases := []struct {
+		name            string
+		lease           *coordv1.Lease
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:            "lease is not found",
+			expectedStatus:  metav1.ConditionUnknown,
+			expectedReason:  "ManagedClusterAddOnLeaseNotFound",
+			expectedMessage: "The status of test add-on is unknown.",
+		},
+		{
+			name: "lease is not renewed",
+			lease: func() *coordv1.Lease {
+				lease := testinghelpers.NewAddOnLease("test", "test", now)
+				lease.Spec.RenewTime = nil
+				return lease
+			}(),
+			expectedStatus:  metav1.ConditionUnknown,
+			expectedReason:  "ManagedClusterAddOnLeaseNotRenewed",
+			expectedMessage: "The status of test add-on is unknown, its lease has not been renewed yet.",
+		},
+		{
+			name:           "lease is fresh",
+			lease:          testinghelpers.NewAddOnLease("test", "test", now.Add(-time.Minute)),
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+			expectedMessage: fmt.Sprintf("test add-on is available, its lease was last renewed at %s.",
+				now.Add(-time.Minute).UTC().Format(time.RFC3339)),
+		},
+		{
+			name:           "lease is degraded",
+			lease:          testinghelpers.NewAddOnLease("test", "test", now.Add(-3*time.Minute)),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseDegraded",
+			expectedMessage: fmt.Sprintf("test add-on is degraded, its lease was last renewed at %s.",
+				now.Add(-3*time.Minute).UTC().Format(time.RFC3339)),
+		},
+		{
+			name:           "lease is stale",
+			lease:          testinghelpers.NewAddOnLease("test", "test", now.Add(-10*time.Minute)),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseUpdateStopped",
+			expectedMessage: fmt.Sprintf("test add-on is not available, its lease was last renewed at %s.",
+				now.Add(-10*time.Minute).UTC().Format(time.RFC3339)),
+		},
+		{
+			name: "lease stops renewing after it is created",
+			lease: func() *coordv1.Lease {
+				lease := testinghelpers.NewAddOnLease("test", "test", now.Add(-10*time.Minute))
+				lease.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour).Truncate(time.Second))
+				return lease
+			}(),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseUpdateStopped",
+			expectedMessage: fmt.Sprintf("test add-on is not available, its lease was last renewed at %s.",
+				now.Add(-10*time.Minute).UTC().Format(time.RFC3339)),
+		},
+		{
+			name: "lease is never renewed after it is created",
+			lease: func() *coordv1.Lease {
+				lease := testinghelpers.NewAddOnLease("test", "test", now.Add(-10*time.Minute))
+				lease.CreationTimestamp = metav1.NewTime(now.Add(-10 * time.Minute).Truncate(time.Second))
+				return lease
+			}(),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseNeverRenewed",
+			expectedMessage: fmt.Sprintf("test add-on is not available, its lease was never renewed after it was created at %s.",
+				now.Add(-10*time.Minute).UTC().Format(time.RFC3339)),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			condition := EvaluateAddOnAvailability("test", now, c.lease, gracePeriod)
+			if condition.Type != addonv1alpha1.ManagedClusterAddOnConditionAvailable {
+				t.Errorf("expected condition type %q, but got %q", addonv1alpha1.ManagedClusterAddOnConditionAvailable, condition.Type)
+			}
+			if condition.Status != c.expectedStatus {
+				t.Errorf("expected status %q, but got %q", c.expectedStatus, condition.Status)
+			}
+			if condition.Reason != c.expectedReason {
+				t.Errorf("expected reason %q, but got %q", c.expectedReason, condition.Reason)
+			}
+			if condition.Message != c.expectedMessage {
+				t.Errorf("expected message %q, but got %q", c.expectedMessage, condition.Message)
+			}
+		})
+	}
+}
+
+func TestRefreshAddOnWithFakeClock(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	addOnClient := addonfake.NewSimpleClientset(addOn)
+	addOnInformer := addoninformers.NewSharedInformerFactory(addOnClient, time.Minute*10).
+		Addon().V1alpha1().ManagedClusterAddOns()
+	if err := addOnInformer.Informer().GetStore().Add(addOn); err != nil {
+		t.Fatal(err)
+	}
+	spokeLeaseClient := kubefake.NewSimpleClientset(testinghelpers.NewAddOnLease("test", "test", fakeClock.Now()))
+
+	ctrl := NewManagedClusterAddOnLeaseController(testinghelpers.TestManagedClusterName,
+		addOnClient,
+		addOnInformer,
+		kubefake.NewSimpleClientset().CoordinationV1(),
+		kubefake.NewSimpleClientset().CoordinationV1(),
+		spokeLeaseClient.CoordinationV1(),
+		time.Minute,
+		AddOnLeaseControllerOptions{Clock: fakeClock},
+		events.NewInMemoryRecorder("test"),
+	)
+
+	if err := ctrl.RefreshAddOn(context.TODO(), "test"); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+
+	// the lease expires once the clock is stepped beyond the grace period
+	addOnClient.ClearActions()
+	fakeClock.Step(time.Duration(defaultLeaseDurationTimes*AddOnLeaseControllerLeaseDurationSeconds+1) * time.Second)
+	if err := ctrl.RefreshAddOn(context.TODO(), "test"); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	actions = addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	assertAvailableCondition(t, actions[0], metav1.ConditionFalse, "ManagedClusterAddOnLeaseUpdateStopped")
+}
+
+func TestSyncWithConditionType(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	addOnClient := addonfake.NewSimpleClientset(addOn)
+	addOnInformer := addoninformers.NewSharedInformerFactory(addOnClient, time.Minute*10).
+		Addon().V1alpha1().ManagedClusterAddOns()
+	if err := addOnInformer.Informer().GetStore().Add(addOn); err != nil {
+		t.Fatal(err)
+	}
+	spokeLeaseClient := kubefake.NewSimpleClientset(testinghelpers.NewAddOnLease("test", "test", time.Now()))
+
+	ctrl := NewManagedClusterAddOnLeaseController(testinghelpers.TestManagedClusterName,
+		addOnClient,
+		addOnInformer,
+		kubefake.NewSimpleClientset().CoordinationV1(),
+		kubefake.NewSimpleClientset().CoordinationV1(),
+		spokeLeaseClient.CoordinationV1(),
+		time.Minute,
+		AddOnLeaseControllerOptions{ConditionType: "LeaseAvailable"},
+		events.NewInMemoryRecorder("test"),
+	)
+
+	if err := ctrl.RefreshAddOn(context.TODO(), "test"); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	actual, err := addOnClient.AddonV1alpha1().ManagedClusterAddOns(testinghelpers.TestManagedClusterName).Get(
+		context.TODO(), "test", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.FindStatusCondition(actual.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable) != nil {
+		t.Errorf("expected no %q condition, but got %v",
+			addonv1alpha1.ManagedClusterAddOnConditionAvailable, actual.Status.Conditions)
+	}
+	if !meta.IsStatusConditionTrue(actual.Status.Conditions, "LeaseAvailable") {
+		t.Errorf("expected the addon is available with the condition type %q, but got %v",
+			"LeaseAvailable", actual.Status.Conditions)
+	}
+}
@@ -0,0 +1,110 @@
+package addon
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+const (
+	// LeaseDefaultsConfigMapName is the name of the ConfigMap which contains the default lease configuration of all
+	// the addons on a managed cluster, the configuration of an addon specified by its own annotations is preferred.
+	LeaseDefaultsConfigMapName = "addon-lease-defaults"
+
+	// leaseDurationSecondsKey is the key of the default interval that the addon agents update their leases
+	leaseDurationSecondsKey = "leaseDurationSeconds"
+	// leaseDurationTimesKey is the key of the default times of the lease duration to determine the grace period
+	leaseDurationTimesKey = "leaseDurationTimes"
+)
+
+// leaseDefaults is the default lease configuration of the addons, a zero value means no default is specified.
+type leaseDefaults struct {
+	leaseDurationSeconds int
+	leaseDurationTimes   int
+	maintenanceWindow    maintenanceWindow
+	// statusUpdatesDisabled disables all of the status writes of the controller
+	statusUpdatesDisabled bool
+}
+
+// getLeaseDefaults returns the lease defaults from the ConfigMap addon-lease-defaults, the invalid values in the
+// ConfigMap are ignored. No default is specified if the ConfigMap is absent, while an error is returned if the
+// ConfigMap cannot be read, so that the addons are not evaluated with the built-in defaults by mistake.
+func (c *managedClusterAddOnLeaseController) getLeaseDefaults() (leaseDefaults, error) {
+	if c.leaseDefaultsLister == nil {
+		return leaseDefaults{}, nil
+	}
+
+	configMap, err := c.leaseDefaultsLister.Get(LeaseDefaultsConfigMapName)
+	if errors.IsNotFound(err) {
+		return leaseDefaults{}, nil
+	}
+	if err != nil {
+		return leaseDefaults{}, fmt.Errorf("failed to get the addon lease defaults: %w", err)
+	}
+
+	return leaseDefaults{
+		leaseDurationSeconds:  getPositiveInt(configMap, leaseDurationSecondsKey),
+		leaseDurationTimes:    getPositiveInt(configMap, leaseDurationTimesKey),
+		maintenanceWindow:     getMaintenanceWindow(configMap),
+		statusUpdatesDisabled: configMap.Data[statusUpdatesDisabledKey] == "true",
+	}, nil
+}
+
+// getPositiveInt returns the positive integer of the key in the ConfigMap, 0 is returned if the value is absent or
+// invalid.
+func getPositiveInt(configMap *corev1.ConfigMap, key string) int {
+	value, ok := configMap.Data[key]
+	if !ok {
+		return 0
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i <= 0 {
+		klog.Warningf("Ignore the invalid %q of ConfigMap %s/%s, the value %q must be a positive integer",
+			key, configMap.Namespace, configMap.Name, value)
+		return 0
+	}
+	return i
+}
+
+// applyLeaseDefaults applies the lease defaults to the lease configuration of the addon, the lease duration seconds
+// specified by the annotation of the addon is kept.
+func applyLeaseDefaults(addOn *addonv1alpha1.ManagedClusterAddOn, leaseConfig *leaseConfig, defaults leaseDefaults) {
+	if _, ok := addOn.Annotations[leaseDurationSecondsAnnotation]; !ok && defaults.leaseDurationSeconds > 0 {
+		leaseConfig.leaseDurationSeconds = defaults.leaseDurationSeconds
+	}
+	if defaults.leaseDurationTimes > 0 {
+		leaseConfig.leaseDurationTimes = defaults.leaseDurationTimes
+	}
+}
+
+// leaseDefaultsEventHandler resyncs all of the addons once the ConfigMap addon-lease-defaults is changed, so that
+// the changed defaults take effect without restarting the agent.
+func (c *managedClusterAddOnLeaseController) leaseDefaultsEventHandler(namespace string) cache.ResourceEventHandler {
+	return cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			configMap, ok := obj.(*corev1.ConfigMap)
+			return ok && configMap.Namespace == namespace && configMap.Name == LeaseDefaultsConfigMapName
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(_ interface{}) {
+				c.syncCtx.Queue().Add(factory.DefaultQueueKey)
+			},
+			UpdateFunc: func(_, _ interface{}) {
+				c.syncCtx.Queue().Add(factory.DefaultQueueKey)
+			},
+			DeleteFunc: func(_ interface{}) {
+				c.syncCtx.Queue().Add(factory.DefaultQueueKey)
+			},
+		},
+	}
+}
@@ -0,0 +1,254 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func newLeaseDefaultsConfigMap(namespace, name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data:       data,
+	}
+}
+
+func TestGetAddOnLeaseConfigWithLeaseDefaults(t *testing.T) {
+	cases := []struct {
+		name                         string
+		configMap                    *corev1.ConfigMap
+		annotations                  map[string]string
+		expectedLeaseDurationSeconds int
+		expectedLeaseDurationTimes   int
+	}{
+		{
+			name:                         "no lease defaults",
+			expectedLeaseDurationSeconds: AddOnLeaseControllerLeaseDurationSeconds,
+		},
+		{
+			name: "lease defaults are applied",
+			configMap: newLeaseDefaultsConfigMap("agent", LeaseDefaultsConfigMapName, map[string]string{
+				leaseDurationSecondsKey: "120",
+				leaseDurationTimesKey:   "3",
+			}),
+			expectedLeaseDurationSeconds: 120,
+			expectedLeaseDurationTimes:   3,
+		},
+		{
+			name: "lease duration seconds of the addon is preferred",
+			configMap: newLeaseDefaultsConfigMap("agent", LeaseDefaultsConfigMapName, map[string]string{
+				leaseDurationSecondsKey: "120",
+			}),
+			annotations:                  map[string]string{leaseDurationSecondsAnnotation: "30"},
+			expectedLeaseDurationSeconds: 30,
+		},
+		{
+			name: "invalid lease defaults are ignored",
+			configMap: newLeaseDefaultsConfigMap("agent", LeaseDefaultsConfigMapName, map[string]string{
+				leaseDurationSecondsKey: "abc",
+				leaseDurationTimesKey:   "-1",
+			}),
+			expectedLeaseDurationSeconds: AddOnLeaseControllerLeaseDurationSeconds,
+		},
+		{
+			name: "configmap in another namespace",
+			configMap: newLeaseDefaultsConfigMap("other", LeaseDefaultsConfigMapName, map[string]string{
+				leaseDurationSecondsKey: "120",
+			}),
+			expectedLeaseDurationSeconds: AddOnLeaseControllerLeaseDurationSeconds,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl, _ := newTestLeaseController(t, []runtime.Object{}, []runtime.Object{})
+			configMapInformer := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 10*time.Minute).
+				Core().V1().ConfigMaps()
+			if c.configMap != nil {
+				if err := configMapInformer.Informer().GetStore().Add(c.configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ctrl.leaseDefaultsLister = configMapInformer.Lister().ConfigMaps("agent")
+
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = c.annotations
+			leaseConfig, err := ctrl.getAddOnLeaseConfig(addOn)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if leaseConfig.leaseDurationSeconds != c.expectedLeaseDurationSeconds {
+				t.Errorf("expected lease duration seconds %d, but got %d",
+					c.expectedLeaseDurationSeconds, leaseConfig.leaseDurationSeconds)
+			}
+			if leaseConfig.leaseDurationTimes != c.expectedLeaseDurationTimes {
+				t.Errorf("expected lease duration times %d, but got %d",
+					c.expectedLeaseDurationTimes, leaseConfig.leaseDurationTimes)
+			}
+		})
+	}
+}
+
+func TestSyncWithLeaseDefaults(t *testing.T) {
+	cases := []struct {
+		name           string
+		configMap      *corev1.ConfigMap
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "lease is expired without the lease defaults",
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseUpdateStopped",
+		},
+		{
+			name: "lease is fresh with the lease defaults",
+			configMap: newLeaseDefaultsConfigMap("agent", LeaseDefaultsConfigMapName, map[string]string{
+				leaseDurationSecondsKey: "300",
+			}),
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl, addOnClient := newTestLeaseController(t,
+				[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now().Add(-6*time.Minute))})
+			configMapInformer := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 10*time.Minute).
+				Core().V1().ConfigMaps()
+			if c.configMap != nil {
+				if err := configMapInformer.Informer().GetStore().Add(c.configMap); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ctrl.leaseDefaultsLister = configMapInformer.Lister().ConfigMaps("agent")
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], c.expectedStatus, c.expectedReason)
+		})
+	}
+}
+
+func TestLeaseDefaultsEventHandler(t *testing.T) {
+	cases := []struct {
+		name          string
+		configMap     *corev1.ConfigMap
+		expectedQueue int
+	}{
+		{
+			name:          "lease defaults configmap",
+			configMap:     newLeaseDefaultsConfigMap("agent", LeaseDefaultsConfigMapName, nil),
+			expectedQueue: 1,
+		},
+		{
+			name:      "other configmap",
+			configMap: newLeaseDefaultsConfigMap("agent", "other", nil),
+		},
+		{
+			name:      "configmap in another namespace",
+			configMap: newLeaseDefaultsConfigMap("other", LeaseDefaultsConfigMapName, nil),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl, _ := newTestLeaseController(t, []runtime.Object{}, []runtime.Object{})
+			handler := ctrl.leaseDefaultsEventHandler("agent")
+
+			handler.OnUpdate(c.configMap, c.configMap)
+			if ctrl.syncCtx.Queue().Len() != c.expectedQueue {
+				t.Errorf("expected %d queued keys, but got %d", c.expectedQueue, ctrl.syncCtx.Queue().Len())
+			}
+		})
+	}
+}
+
+// failingConfigMapLister is a ConfigMapNamespaceLister which always fails to read the ConfigMaps
+type failingConfigMapLister struct {
+	err error
+}
+
+func (l *failingConfigMapLister) List(_ labels.Selector) ([]*corev1.ConfigMap, error) {
+	return nil, l.err
+}
+
+func (l *failingConfigMapLister) Get(_ string) (*corev1.ConfigMap, error) {
+	return nil, l.err
+}
+
+var _ corev1listers.ConfigMapNamespaceLister = &failingConfigMapLister{}
+
+func TestResyncWithAddOnConfigErrors(t *testing.T) {
+	invalidAddOn := testinghelpers.NewFakeAddOn("invalid", "test")
+	invalidAddOn.Annotations = map[string]string{leaseDurationSecondsAnnotation: "abc"}
+
+	cases := []struct {
+		name          string
+		lister        corev1listers.ConfigMapNamespaceLister
+		expectErr     bool
+		expectedQueue int
+	}{
+		{
+			name:          "config is invalid",
+			expectedQueue: 2,
+		},
+		{
+			name:      "config cannot be read transiently",
+			lister:    &failingConfigMapLister{err: fmt.Errorf("cache is not ready")},
+			expectErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl, _ := newTestLeaseController(t,
+				[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test"), invalidAddOn},
+				[]runtime.Object{})
+			ctrl.leaseDefaultsLister = c.lister
+			syncCtx := testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+
+			err := ctrl.sync(context.TODO(), syncCtx)
+			if c.expectErr && err == nil {
+				t.Errorf("expected an error, but got nil")
+			}
+			if !c.expectErr && err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if c.expectErr && isAddOnConfigUnresolvable(err) {
+				t.Errorf("expected a transient error, but got %v", err)
+			}
+			// the addons enqueued before the transient error depend on the order of the addons
+			if !c.expectErr && syncCtx.Queue().Len() != c.expectedQueue {
+				t.Errorf("expected %d queued keys, but got %d", c.expectedQueue, syncCtx.Queue().Len())
+			}
+		})
+	}
+}
+
+func TestCachesSyncedWithLeaseDefaultsInformer(t *testing.T) {
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), time.Minute*10)
+	assertCachesSyncedWithInformer(t, kubeInformerFactory, AddOnLeaseControllerOptions{
+		LeaseDefaultsConfigMapInformer:  kubeInformerFactory.Core().V1().ConfigMaps(),
+		LeaseDefaultsConfigMapNamespace: "open-cluster-management-agent",
+	})
+}
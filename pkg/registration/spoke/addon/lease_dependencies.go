@@ -0,0 +1,123 @@
+package addon
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// dependsOnAnnotation is the annotation of an addon for indicating the comma separated names of the addons on the same
+// managed cluster that it depends on, the addon is not available as long as one of its dependencies is unavailable.
+const dependsOnAnnotation = "addon.open-cluster-management.io/depends-on"
+
+// dependentRecheckDelay is the delay to recheck the dependents of an addon after its availability is changed, so
+// that the changed available condition of the addon is observed by the addon informer before its dependents are
+// rechecked.
+const dependentRecheckDelay = 5 * time.Second
+
+// getAddOnDependencies returns the names of the addons that the addon depends on
+func getAddOnDependencies(addOn *addonv1alpha1.ManagedClusterAddOn) []string {
+	var dependencies []string
+	for _, name := range strings.Split(addOn.Annotations[dependsOnAnnotation], ",") {
+		name = strings.TrimSpace(name)
+		if len(name) != 0 && name != addOn.Name {
+			dependencies = append(dependencies, name)
+		}
+	}
+	return dependencies
+}
+
+// getDependencyUnavailableCondition returns the unavailable condition of the addon if one of its dependencies is not
+// available. The availability of a dependency is its available condition in the addon status, which is resolved
+// before the dependent addon is checked, and a dependency which is not found is unavailable. The dependencies
+// are ignored if they depend on the addon in turn, since none of the addons in a dependency cycle could become
+// available again once one of them is unavailable.
+func (c *managedClusterAddOnLeaseController) getDependencyUnavailableCondition(
+	addOn *addonv1alpha1.ManagedClusterAddOn) (metav1.Condition, bool) {
+	dependencies := getAddOnDependencies(addOn)
+	if len(dependencies) == 0 {
+		return metav1.Condition{}, false
+	}
+	if c.hasDependencyCycle(addOn) {
+		klog.Warningf("Ignore the dependencies of addon %q on cluster %q, they depend on the addon in a cycle",
+			addOn.Name, c.clusterName)
+		return metav1.Condition{}, false
+	}
+
+	var unavailable []string
+	for _, name := range dependencies {
+		dependency, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).Get(name)
+		switch {
+		case errors.IsNotFound(err):
+			unavailable = append(unavailable, fmt.Sprintf("%s (not found)", name))
+		case err != nil:
+			unavailable = append(unavailable, fmt.Sprintf("%s (%v)", name, err))
+		case !meta.IsStatusConditionTrue(dependency.Status.Conditions, c.conditionType):
+			unavailable = append(unavailable, name)
+		}
+	}
+	if len(unavailable) == 0 {
+		return metav1.Condition{}, false
+	}
+
+	return metav1.Condition{
+		Type:   c.conditionType,
+		Status: metav1.ConditionFalse,
+		Reason: "ManagedClusterAddOnDependencyUnavailable",
+		Message: fmt.Sprintf("%s add-on is not available, its dependencies are not available: %s.",
+			addOn.Name, strings.Join(unavailable, ", ")),
+	}, true
+}
+
+// hasDependencyCycle returns true if the addon depends on itself through its dependencies
+func (c *managedClusterAddOnLeaseController) hasDependencyCycle(addOn *addonv1alpha1.ManagedClusterAddOn) bool {
+	visited := sets.New[string]()
+	pending := getAddOnDependencies(addOn)
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if name == addOn.Name {
+			return true
+		}
+		if visited.Has(name) {
+			continue
+		}
+		visited.Insert(name)
+
+		dependency, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).Get(name)
+		if err != nil {
+			continue
+		}
+		pending = append(pending, getAddOnDependencies(dependency)...)
+	}
+	return false
+}
+
+// enqueueDependents enqueues the addons which depend on the given addon to recheck their availability
+func (c *managedClusterAddOnLeaseController) enqueueDependents(addOnName string) {
+	addOns, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).List(c.addOnSelector)
+	if err != nil {
+		klog.Warningf("Failed to list the dependents of addon %q on cluster %q: %v", addOnName, c.clusterName, err)
+		return
+	}
+
+	for _, addOn := range addOns {
+		if !sets.New[string](getAddOnDependencies(addOn)...).Has(addOnName) {
+			continue
+		}
+		leaseConfig, err := c.getAddOnLeaseConfig(addOn)
+		if err != nil {
+			// the dependent is rechecked by the resync of the controller
+			continue
+		}
+		c.syncCtx.Queue().AddAfter(fmt.Sprintf("%s/%s", leaseConfig.leaseNamespace, addOn.Name), dependentRecheckDelay)
+	}
+}
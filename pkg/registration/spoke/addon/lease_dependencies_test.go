@@ -0,0 +1,147 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func newDependentAddOn(name, dependsOn string, available metav1.ConditionStatus) *addonv1alpha1.ManagedClusterAddOn {
+	addOn := testinghelpers.NewFakeAddOn(name, "test")
+	if len(dependsOn) != 0 {
+		addOn.Annotations = map[string]string{dependsOnAnnotation: dependsOn}
+	}
+	if len(available) != 0 {
+		addOn.Status.Conditions = []metav1.Condition{{
+			Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+			Status: available,
+			Reason: "ManagedClusterAddOnLeaseUpdated",
+		}}
+	}
+	return addOn
+}
+
+func TestSyncWithDependencies(t *testing.T) {
+	cases := []struct {
+		name           string
+		addOns         []runtime.Object
+		leaseRenewTime time.Time
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name: "dependencies are available",
+			addOns: []runtime.Object{
+				newDependentAddOn("test", "dep1, dep2", ""),
+				newDependentAddOn("dep1", "", metav1.ConditionTrue),
+				newDependentAddOn("dep2", "", metav1.ConditionTrue),
+			},
+			leaseRenewTime: time.Now(),
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+		},
+		{
+			name: "dependency is unavailable",
+			addOns: []runtime.Object{
+				newDependentAddOn("test", "dep1,dep2", ""),
+				newDependentAddOn("dep1", "", metav1.ConditionTrue),
+				newDependentAddOn("dep2", "", metav1.ConditionFalse),
+			},
+			leaseRenewTime: time.Now(),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnDependencyUnavailable",
+		},
+		{
+			name: "dependency is not found",
+			addOns: []runtime.Object{
+				newDependentAddOn("test", "dep1", ""),
+			},
+			leaseRenewTime: time.Now(),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnDependencyUnavailable",
+		},
+		{
+			name: "own lease is expired",
+			addOns: []runtime.Object{
+				newDependentAddOn("test", "dep1", ""),
+				newDependentAddOn("dep1", "", metav1.ConditionFalse),
+			},
+			leaseRenewTime: time.Now().Add(-10 * time.Minute),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnLeaseUpdateStopped",
+		},
+		{
+			name: "dependencies are in a cycle",
+			addOns: []runtime.Object{
+				newDependentAddOn("test", "dep1", ""),
+				newDependentAddOn("dep1", "dep2", metav1.ConditionFalse),
+				newDependentAddOn("dep2", "test", metav1.ConditionFalse),
+			},
+			leaseRenewTime: time.Now(),
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl, addOnClient := newTestLeaseController(t, c.addOns,
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", c.leaseRenewTime)})
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], c.expectedStatus, c.expectedReason)
+		})
+	}
+}
+
+// queueSyncContext is a sync context with the given queue
+type queueSyncContext struct {
+	factory.SyncContext
+	queue workqueue.RateLimitingInterface
+}
+
+func (q queueSyncContext) Queue() workqueue.RateLimitingInterface { return q.queue }
+
+func TestEnqueueDependents(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{
+		newDependentAddOn("test", "", metav1.ConditionTrue),
+		newDependentAddOn("dependent", "test", metav1.ConditionTrue),
+		newDependentAddOn("other", "", metav1.ConditionTrue),
+	}, []runtime.Object{})
+	queue := workqueue.NewRateLimitingQueueWithConfig(workqueue.DefaultControllerRateLimiter(),
+		workqueue.RateLimitingQueueConfig{Clock: fakeClock})
+	defer queue.ShutDown()
+	ctrl.syncCtx = queueSyncContext{SyncContext: ctrl.syncCtx, queue: queue}
+
+	ctrl.enqueueDependents("test")
+	if queue.Len() != 0 {
+		t.Errorf("expected the dependents are rechecked after a delay, but got %d queued", queue.Len())
+	}
+
+	fakeClock.Step(dependentRecheckDelay)
+	if err := wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		return queue.Len() == 1, nil
+	}); err != nil {
+		t.Fatalf("expected the dependent is queued, but got %d queued", queue.Len())
+	}
+	if key, _ := queue.Get(); key != "test/dependent" {
+		t.Errorf("expected the dependent is queued, but got %v", key)
+	}
+}
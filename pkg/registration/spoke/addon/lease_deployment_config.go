@@ -0,0 +1,122 @@
+package addon
+
+import (
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// AddOnDeploymentConfigGVR is the resource of the AddOnDeploymentConfigs. The configs are read with a dynamic
+// informer, since the agent install namespace of a config is not known by the typed config of the api in use.
+var AddOnDeploymentConfigGVR = schema.GroupVersionResource{
+	Group:    addonv1alpha1.GroupName,
+	Version:  "v1alpha1",
+	Resource: "addondeploymentconfigs",
+}
+
+// installNamespaceResolver resolves the installation namespace of an addon with the agent install namespace of its
+// AddOnDeploymentConfig, it is shared by the lease controller, the lease cleanup controller and the pod availability
+// checker, so that all of them locate the same namespace of an addon.
+type installNamespaceResolver struct {
+	// deploymentConfigLister reads the AddOnDeploymentConfigs on the hub cluster, the namespace of the addon spec
+	// or status is used if it is nil
+	deploymentConfigLister cache.GenericLister
+}
+
+func newInstallNamespaceResolver(deploymentConfigInformer informers.GenericInformer) installNamespaceResolver {
+	if deploymentConfigInformer == nil {
+		return installNamespaceResolver{}
+	}
+	return installNamespaceResolver{deploymentConfigLister: deploymentConfigInformer.Lister()}
+}
+
+// getDeploymentConfigInstallNamespace returns the agent install namespace of the AddOnDeploymentConfig referenced
+// by the addon, an empty string is returned if the configs are not read by the controller, the addon references no
+// AddOnDeploymentConfig, or the referenced config does not specify the namespace.
+func (r installNamespaceResolver) getDeploymentConfigInstallNamespace(
+	addOn *addonv1alpha1.ManagedClusterAddOn) string {
+	if r.deploymentConfigLister == nil {
+		return ""
+	}
+
+	for _, configReference := range addOn.Status.ConfigReferences {
+		if configReference.Group != AddOnDeploymentConfigGVR.Group ||
+			configReference.Resource != AddOnDeploymentConfigGVR.Resource {
+			continue
+		}
+
+		referent := configReference.ConfigReferent
+		if configReference.DesiredConfig != nil && len(configReference.DesiredConfig.Name) != 0 {
+			referent = configReference.DesiredConfig.ConfigReferent
+		}
+		obj, err := r.deploymentConfigLister.ByNamespace(referent.Namespace).Get(referent.Name)
+		if err != nil {
+			klog.V(4).InfoS("Failed to get the addon deployment config", "cluster", addOn.Namespace, "addon", addOn.Name,
+				"config", referent.Namespace+"/"+referent.Name, "err", err)
+			return ""
+		}
+		config, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return ""
+		}
+		namespace, _, _ := unstructured.NestedString(config.Object, "spec", "agentInstallNamespace")
+		return namespace
+	}
+	return ""
+}
+
+// applyDeploymentConfigInstallNamespace overrides the installation namespace of the addon with the agent install
+// namespace of its AddOnDeploymentConfig, the lease namespace follows the installation namespace unless it is
+// specified by the annotation of the addon.
+func (r installNamespaceResolver) applyDeploymentConfigInstallNamespace(
+	addOn *addonv1alpha1.ManagedClusterAddOn, leaseConfig *leaseConfig) {
+	namespace := r.getDeploymentConfigInstallNamespace(addOn)
+	if len(namespace) == 0 {
+		return
+	}
+	if len(addOn.Annotations[leaseNamespaceAnnotation]) == 0 {
+		leaseConfig.leaseNamespace = namespace
+	}
+	leaseConfig.InstallationNamespace = namespace
+}
+
+// getAddOnInstallationNamespace returns the agent install namespace of the AddOnDeploymentConfig of the addon if it
+// is specified, otherwise the installation namespace of the addon spec or status.
+func (r installNamespaceResolver) getAddOnInstallationNamespace(addOn *addonv1alpha1.ManagedClusterAddOn) string {
+	if namespace := r.getDeploymentConfigInstallNamespace(addOn); len(namespace) != 0 {
+		return namespace
+	}
+	return getAddOnInstallationNamespace(addOn)
+}
+
+// getAddOnLeaseConfig returns the lease configuration of the addon with the installation namespace resolved from
+// its AddOnDeploymentConfig.
+func (r installNamespaceResolver) getAddOnLeaseConfig(addOn *addonv1alpha1.ManagedClusterAddOn) (*leaseConfig, error) {
+	leaseConfig, err := getAddOnLeaseConfig(addOn)
+	if err != nil {
+		return nil, err
+	}
+	r.applyDeploymentConfigInstallNamespace(addOn, leaseConfig)
+	return leaseConfig, nil
+}
+
+// deploymentConfigEventHandler resyncs all of the addons once an AddOnDeploymentConfig is changed, so that the
+// addons follow the changed install namespace of their configs.
+func (c *managedClusterAddOnLeaseController) deploymentConfigEventHandler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(_ interface{}) {
+			c.syncCtx.Queue().Add(factory.DefaultQueueKey)
+		},
+		UpdateFunc: func(_, _ interface{}) {
+			c.syncCtx.Queue().Add(factory.DefaultQueueKey)
+		},
+		DeleteFunc: func(_ interface{}) {
+			c.syncCtx.Queue().Add(factory.DefaultQueueKey)
+		},
+	}
+}
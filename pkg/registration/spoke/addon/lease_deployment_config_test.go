@@ -0,0 +1,198 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func newDeploymentConfig(namespace, name, agentInstallNamespace string) *unstructured.Unstructured {
+	config := &unstructured.Unstructured{}
+	config.SetAPIVersion("addon.open-cluster-management.io/v1alpha1")
+	config.SetKind("AddOnDeploymentConfig")
+	config.SetNamespace(namespace)
+	config.SetName(name)
+	if len(agentInstallNamespace) != 0 {
+		_ = unstructured.SetNestedField(config.Object, agentInstallNamespace, "spec", "agentInstallNamespace")
+	}
+	return config
+}
+
+func newDeploymentConfigLister(t *testing.T, configs ...*unstructured.Unstructured) cache.GenericLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, config := range configs {
+		if err := indexer.Add(config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cache.NewGenericLister(indexer, AddOnDeploymentConfigGVR.GroupResource())
+}
+
+func newConfigReferencedAddOn(statusNamespace string) *addonv1alpha1.ManagedClusterAddOn {
+	addOn := testinghelpers.NewFakeAddOn("test", "inline")
+	addOn.Status.Namespace = statusNamespace
+	addOn.Status.ConfigReferences = []addonv1alpha1.ConfigReference{{
+		ConfigGroupResource: addonv1alpha1.ConfigGroupResource{
+			Group:    AddOnDeploymentConfigGVR.Group,
+			Resource: AddOnDeploymentConfigGVR.Resource,
+		},
+		DesiredConfig: &addonv1alpha1.ConfigSpecHash{
+			ConfigReferent: addonv1alpha1.ConfigReferent{Namespace: "configs", Name: "test"},
+		},
+	}}
+	return addOn
+}
+
+func TestGetAddOnLeaseConfigWithDeploymentConfig(t *testing.T) {
+	cases := []struct {
+		name                          string
+		statusNamespace               string
+		annotations                   map[string]string
+		configs                       []*unstructured.Unstructured
+		expectedInstallationNamespace string
+		expectedLeaseNamespace        string
+	}{
+		{
+			name:                          "agent install namespace of the config wins",
+			statusNamespace:               "status",
+			configs:                       []*unstructured.Unstructured{newDeploymentConfig("configs", "test", "config")},
+			expectedInstallationNamespace: "config",
+			expectedLeaseNamespace:        "config",
+		},
+		{
+			name:                          "agent install namespace of the config with the lease namespace annotation",
+			statusNamespace:               "status",
+			annotations:                   map[string]string{leaseNamespaceAnnotation: "lease"},
+			configs:                       []*unstructured.Unstructured{newDeploymentConfig("configs", "test", "config")},
+			expectedInstallationNamespace: "config",
+			expectedLeaseNamespace:        "lease",
+		},
+		{
+			name:                          "status namespace is used if the config specifies no namespace",
+			statusNamespace:               "status",
+			configs:                       []*unstructured.Unstructured{newDeploymentConfig("configs", "test", "")},
+			expectedInstallationNamespace: "status",
+			expectedLeaseNamespace:        "status",
+		},
+		{
+			name:                          "status namespace is used if the config is not found",
+			statusNamespace:               "status",
+			expectedInstallationNamespace: "status",
+			expectedLeaseNamespace:        "status",
+		},
+		{
+			name:                          "inline namespace is used without the status namespace",
+			expectedInstallationNamespace: "inline",
+			expectedLeaseNamespace:        "inline",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := newConfigReferencedAddOn(c.statusNamespace)
+			addOn.Annotations = c.annotations
+			ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+			ctrl.deploymentConfigLister = newDeploymentConfigLister(t, c.configs...)
+
+			leaseConfig, err := ctrl.getAddOnLeaseConfig(addOn)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if leaseConfig.InstallationNamespace != c.expectedInstallationNamespace {
+				t.Errorf("expected installation namespace %q, but got %q",
+					c.expectedInstallationNamespace, leaseConfig.InstallationNamespace)
+			}
+			if leaseConfig.leaseNamespace != c.expectedLeaseNamespace {
+				t.Errorf("expected lease namespace %q, but got %q", c.expectedLeaseNamespace, leaseConfig.leaseNamespace)
+			}
+		})
+	}
+}
+
+func TestQueueKeyFuncWithDeploymentConfig(t *testing.T) {
+	addOn := newConfigReferencedAddOn("status")
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+	ctrl.deploymentConfigLister = newDeploymentConfigLister(t, newDeploymentConfig("configs", "test", "config"))
+
+	if queueKey := ctrl.queueKeyFunc(testinghelpers.NewAddOnLease("config", "test", time.Now())); queueKey != "config/test" {
+		t.Errorf("expected queue key %q, but got %q", "config/test", queueKey)
+	}
+	// the lease in the status namespace is not the lease of the addon
+	if queueKey := ctrl.queueKeyFunc(testinghelpers.NewAddOnLease("status", "test", time.Now())); queueKey != "" {
+		t.Errorf("expected no queue key, but got %q", queueKey)
+	}
+}
+
+func TestSyncWithDeploymentConfig(t *testing.T) {
+	addOn := newConfigReferencedAddOn("status")
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+		[]runtime.Object{testinghelpers.NewAddOnLease("config", "test", time.Now())})
+	ctrl.deploymentConfigLister = newDeploymentConfigLister(t, newDeploymentConfig("configs", "test", "config"))
+
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "config/test")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+}
+
+func TestLeaseCleanupQueueKeyFuncWithDeploymentConfig(t *testing.T) {
+	ctrl := &addOnLeaseCleanupController{
+		clusterName: testinghelpers.TestManagedClusterName,
+		namespaceResolver: installNamespaceResolver{
+			deploymentConfigLister: newDeploymentConfigLister(t, newDeploymentConfig("configs", "test", "config")),
+		},
+	}
+
+	if queueKey := ctrl.queueKeyFunc(newConfigReferencedAddOn("status")); queueKey != "spoke/config/test" {
+		t.Errorf("expected queue key %q, but got %q", "spoke/config/test", queueKey)
+	}
+}
+
+func TestPodAvailabilityCheckerWithDeploymentConfig(t *testing.T) {
+	podInformer := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 10*time.Minute).Core().V1().Pods()
+	for _, namespace := range []string{"status", "config"} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: namespace, Labels: map[string]string{"app": "agent"}},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+			},
+		}
+		if namespace == "config" {
+			pod.Status.Conditions[0].Status = corev1.ConditionTrue
+		}
+		if err := podInformer.Informer().GetStore().Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checker := NewPodAvailabilityChecker(AddOnLeaseControllerOptions{}, podInformer).(*podAvailabilityChecker)
+	checker.namespaceResolver = installNamespaceResolver{
+		deploymentConfigLister: newDeploymentConfigLister(t, newDeploymentConfig("configs", "test", "config")),
+	}
+
+	addOn := newConfigReferencedAddOn("status")
+	addOn.Annotations = map[string]string{agentPodSelectorAnnotation: "app=agent"}
+	condition, err := checker.Check(context.TODO(), addOn, nil)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	// the agent pod in the agent install namespace of the config is checked rather than the status namespace
+	if condition.Reason != "ManagedClusterAddOnAgentPodReady" {
+		t.Errorf("expected reason %q, but got %q", "ManagedClusterAddOnAgentPodReady", condition.Reason)
+	}
+}
@@ -0,0 +1,65 @@
+package addon
+
+import (
+	"sync"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// leaseDurationValidator compares the lease duration declared by the addon agent in its lease with the lease
+// duration expected by the controller, a significant divergence indicates the agent is misconfigured, which distorts
+// the availability of the addon silently.
+type leaseDurationValidator struct {
+	// factor is the max ratio between the declared and the expected lease duration
+	factor float64
+
+	lock       sync.Mutex
+	mismatched sets.Set[string]
+}
+
+func newLeaseDurationValidator(factor float64) *leaseDurationValidator {
+	return &leaseDurationValidator{
+		factor:     factor,
+		mismatched: sets.New[string](),
+	}
+}
+
+// validate records whether the declared lease duration of the addon diverges from the expected one, an event is
+// emitted once the divergence is found, and emitted again only after the divergence is resolved and found again.
+// The leases without the declared duration are ignored.
+func (v *leaseDurationValidator) validate(clusterName, addOnName string, lease *coordv1.Lease,
+	expectedSeconds int, recorder events.Recorder) {
+	if lease == nil || lease.Spec.LeaseDurationSeconds == nil || expectedSeconds <= 0 {
+		return
+	}
+
+	declaredSeconds := *lease.Spec.LeaseDurationSeconds
+	ratio := float64(declaredSeconds) / float64(expectedSeconds)
+	mismatched := ratio > v.factor || ratio*v.factor < 1
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if !mismatched {
+		addOnLeaseDurationMismatch.WithLabelValues(clusterName, addOnName).Set(0)
+		v.mismatched.Delete(addOnName)
+		return
+	}
+
+	addOnLeaseDurationMismatch.WithLabelValues(clusterName, addOnName).Set(1)
+	if v.mismatched.Has(addOnName) {
+		return
+	}
+	v.mismatched.Insert(addOnName)
+	recorder.Warningf("ManagedClusterAddOnLeaseDurationMismatch",
+		"The lease duration %ds declared by the agent of addon %s diverges from the expected %ds",
+		declaredSeconds, addOnName, expectedSeconds)
+}
+
+// forget removes the divergence record of the addon
+func (v *leaseDurationValidator) forget(addOnName string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.mismatched.Delete(addOnName)
+}
@@ -0,0 +1,100 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/utils/pointer"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithLeaseDurationMismatch(t *testing.T) {
+	cases := []struct {
+		name             string
+		declaredSeconds  *int32
+		expectedMismatch float64
+	}{
+		{
+			name:             "lease duration is not declared",
+			expectedMismatch: 0,
+		},
+		{
+			name:             "lease duration is close to the expected one",
+			declaredSeconds:  pointer.Int32(90),
+			expectedMismatch: 0,
+		},
+		{
+			name:             "lease duration is much shorter than the expected one",
+			declaredSeconds:  pointer.Int32(10),
+			expectedMismatch: 1,
+		},
+		{
+			name:             "lease duration is much longer than the expected one",
+			declaredSeconds:  pointer.Int32(300),
+			expectedMismatch: 1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			lease := testinghelpers.NewAddOnLease("test", "duration", time.Now())
+			lease.Spec.LeaseDurationSeconds = c.declaredSeconds
+			ctrl, addOnClient := newTestLeaseController(t,
+				[]runtime.Object{testinghelpers.NewFakeAddOn("duration", "test")}, []runtime.Object{lease})
+			ctrl.durationValidator = newLeaseDurationValidator(2)
+			addOnLeaseDurationMismatch.DeleteLabelValues(testinghelpers.TestManagedClusterName, "duration")
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/duration")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			// the availability of the addon is not affected
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+
+			if c.declaredSeconds == nil {
+				return
+			}
+			mismatch, err := testutil.GetGaugeMetricValue(
+				addOnLeaseDurationMismatch.WithLabelValues(testinghelpers.TestManagedClusterName, "duration"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mismatch != c.expectedMismatch {
+				t.Errorf("expected mismatch metric %v, but got %v", c.expectedMismatch, mismatch)
+			}
+		})
+	}
+}
+
+func TestLeaseDurationValidatorEvents(t *testing.T) {
+	validator := newLeaseDurationValidator(2)
+	recorder := events.NewInMemoryRecorder("test")
+	lease := testinghelpers.NewAddOnLease("test", "events", time.Now())
+	lease.Spec.LeaseDurationSeconds = pointer.Int32(10)
+
+	// the event is emitted only once for the divergence
+	for i := 0; i < 2; i++ {
+		validator.validate(testinghelpers.TestManagedClusterName, "events", lease, 60, recorder)
+	}
+	if len(recorder.Events()) != 1 || recorder.Events()[0].Reason != "ManagedClusterAddOnLeaseDurationMismatch" {
+		t.Errorf("expected one mismatch event, but got %v", recorder.Events())
+	}
+
+	// the divergence is resolved and found again
+	lease.Spec.LeaseDurationSeconds = pointer.Int32(60)
+	validator.validate(testinghelpers.TestManagedClusterName, "events", lease, 60, recorder)
+	lease.Spec.LeaseDurationSeconds = pointer.Int32(10)
+	validator.validate(testinghelpers.TestManagedClusterName, "events", lease, 60, recorder)
+	if len(recorder.Events()) != 2 {
+		t.Errorf("expected 2 mismatch events, but got %d", len(recorder.Events()))
+	}
+}
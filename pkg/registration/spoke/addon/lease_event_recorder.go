@@ -0,0 +1,73 @@
+package addon
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// teeRecorder emits each event to the primary recorder and a secondary recorder, so that the events of the addon
+// lease controller can be routed to a custom sink, e.g. structured logging or a test buffer, besides the
+// kubernetes events. The component name is the one of the primary recorder.
+type teeRecorder struct {
+	primary   events.Recorder
+	secondary events.Recorder
+}
+
+// newTeeRecorder returns the primary recorder if the secondary recorder is nil.
+func newTeeRecorder(primary, secondary events.Recorder) events.Recorder {
+	if secondary == nil {
+		return primary
+	}
+	return &teeRecorder{primary: primary, secondary: secondary}
+}
+
+func (r *teeRecorder) Event(reason, message string) {
+	r.primary.Event(reason, message)
+	r.secondary.Event(reason, message)
+}
+
+func (r *teeRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	r.primary.Eventf(reason, messageFmt, args...)
+	r.secondary.Eventf(reason, messageFmt, args...)
+}
+
+func (r *teeRecorder) Warning(reason, message string) {
+	r.primary.Warning(reason, message)
+	r.secondary.Warning(reason, message)
+}
+
+func (r *teeRecorder) Warningf(reason, messageFmt string, args ...interface{}) {
+	r.primary.Warningf(reason, messageFmt, args...)
+	r.secondary.Warningf(reason, messageFmt, args...)
+}
+
+func (r *teeRecorder) ForComponent(componentName string) events.Recorder {
+	return &teeRecorder{
+		primary:   r.primary.ForComponent(componentName),
+		secondary: r.secondary.ForComponent(componentName),
+	}
+}
+
+func (r *teeRecorder) WithComponentSuffix(componentNameSuffix string) events.Recorder {
+	return &teeRecorder{
+		primary:   r.primary.WithComponentSuffix(componentNameSuffix),
+		secondary: r.secondary.WithComponentSuffix(componentNameSuffix),
+	}
+}
+
+func (r *teeRecorder) WithContext(ctx context.Context) events.Recorder {
+	return &teeRecorder{
+		primary:   r.primary.WithContext(ctx),
+		secondary: r.secondary.WithContext(ctx),
+	}
+}
+
+func (r *teeRecorder) ComponentName() string {
+	return r.primary.ComponentName()
+}
+
+func (r *teeRecorder) Shutdown() {
+	r.primary.Shutdown()
+	r.secondary.Shutdown()
+}
@@ -0,0 +1,36 @@
+package addon
+
+import (
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestTeeRecorder(t *testing.T) {
+	primary := events.NewInMemoryRecorder("primary")
+	if recorder := newTeeRecorder(primary, nil); recorder != primary {
+		t.Errorf("expected the primary recorder is returned without a secondary recorder")
+	}
+
+	secondary := events.NewInMemoryRecorder("secondary")
+	recorder := newTeeRecorder(primary, secondary).WithComponentSuffix("test")
+	recorder.Eventf("ManagedClusterAddOnStatusUpdated", "addon %s is updated", "test")
+	recorder.Warning("ManagedClusterAddOnLeaseDurationMismatch", "lease duration mismatch")
+
+	if recorder.ComponentName() != "primary-test" {
+		t.Errorf("expected the component name of the primary recorder, but got %q", recorder.ComponentName())
+	}
+	for _, r := range []events.InMemoryRecorder{primary, secondary} {
+		recorded := r.Events()
+		if len(recorded) != 2 {
+			t.Fatalf("expected 2 events emitted to %s, but got %d", r.ComponentName(), len(recorded))
+		}
+		if recorded[0].Reason != "ManagedClusterAddOnStatusUpdated" || recorded[0].Message != "addon test is updated" {
+			t.Errorf("unexpected event %v", recorded[0])
+		}
+		if recorded[1].Type != corev1.EventTypeWarning {
+			t.Errorf("expected a warning event, but got %v", recorded[1])
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package addon
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+const (
+	// forceStatusAnnotation is the annotation of an addon to pin its available condition regardless of its lease,
+	// e.g. an operator forces an addon unavailable to drain its traffic during an incident. The lease of the addon
+	// is evaluated again once the annotation is removed.
+	forceStatusAnnotation = "addon.open-cluster-management.io/force-status"
+	// forceStatusAvailable and forceStatusUnavailable are the values of the forceStatusAnnotation
+	forceStatusAvailable   = "Available"
+	forceStatusUnavailable = "Unavailable"
+)
+
+// getForcedAvailableCondition returns the available condition of the condition type forced by the annotation of the
+// addon, false is returned if the addon has no or an invalid annotation.
+func getForcedAvailableCondition(conditionType string, addOn *addonv1alpha1.ManagedClusterAddOn) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:   conditionType,
+		Reason: "ManagedClusterAddOnStatusForced",
+	}
+
+	switch addOn.Annotations[forceStatusAnnotation] {
+	case forceStatusAvailable:
+		condition.Status = metav1.ConditionTrue
+		condition.Message = fmt.Sprintf("%s add-on is forced to be available by the annotation %s.",
+			addOn.Name, forceStatusAnnotation)
+	case forceStatusUnavailable:
+		condition.Status = metav1.ConditionFalse
+		condition.Message = fmt.Sprintf("%s add-on is forced to be unavailable by the annotation %s.",
+			addOn.Name, forceStatusAnnotation)
+	default:
+		return metav1.Condition{}, false
+	}
+	return condition, true
+}
@@ -0,0 +1,67 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithForcedStatus(t *testing.T) {
+	cases := []struct {
+		name           string
+		forceStatus    string
+		leases         []runtime.Object
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "forced to be unavailable with a fresh lease",
+			forceStatus:    forceStatusUnavailable,
+			leases:         []runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ManagedClusterAddOnStatusForced",
+		},
+		{
+			name:           "forced to be available without lease",
+			forceStatus:    forceStatusAvailable,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnStatusForced",
+		},
+		{
+			name:           "invalid forced status",
+			forceStatus:    "Down",
+			leases:         []runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "ManagedClusterAddOnConfigUnresolvable",
+		},
+		{
+			name:           "no forced status",
+			leases:         []runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ManagedClusterAddOnLeaseUpdated",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			if len(c.forceStatus) != 0 {
+				addOn.Annotations = map[string]string{forceStatusAnnotation: c.forceStatus}
+			}
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, c.leases)
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], c.expectedStatus, c.expectedReason)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package addon
+
+import (
+	"sync"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// statusUpdatesDisabledKey is the key of the ConfigMap addon-lease-defaults to disable all of the status writes of
+// the lease controller if its value is "true", e.g. the ConfigMap is synced from the hub cluster to all of the
+// managed clusters as a fleet-wide break-glass in an emergency. The conditions of the addons are still computed and
+// logged while the writes are disabled.
+const statusUpdatesDisabledKey = "statusUpdatesDisabled"
+
+// globalDisable tracks whether the globally disabled status writes have been reported
+type globalDisable struct {
+	lock     sync.Mutex
+	reported bool
+}
+
+// isStatusUpdateDisabled returns true if the status writes are disabled by the ConfigMap addon-lease-defaults, an
+// event is emitted the first time the writes are found disabled, and emitted again only after the writes are enabled
+// and disabled again.
+func (c *managedClusterAddOnLeaseController) isStatusUpdateDisabled(recorder events.Recorder) bool {
+	// the error of the lease defaults has been returned by getAddOnLeaseConfig before the status is updated
+	defaults, _ := c.getLeaseDefaults()
+
+	c.globalDisable.lock.Lock()
+	defer c.globalDisable.lock.Unlock()
+	if !defaults.statusUpdatesDisabled {
+		c.globalDisable.reported = false
+		return false
+	}
+
+	if !c.globalDisable.reported {
+		c.globalDisable.reported = true
+		recorder.Warningf("ManagedClusterAddOnLeaseGloballyDisabled",
+			"The status updates of the addons on managed cluster %s are disabled by %s of ConfigMap %s",
+			c.clusterName, statusUpdatesDisabledKey, LeaseDefaultsConfigMapName)
+	}
+	return true
+}
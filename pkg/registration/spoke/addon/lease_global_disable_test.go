@@ -0,0 +1,63 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestUpdateAvailableConditionGloballyDisabled(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+
+	configMapInformer := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 10*time.Minute).
+		Core().V1().ConfigMaps()
+	store := configMapInformer.Informer().GetStore()
+	if err := store.Add(newLeaseDefaultsConfigMap("agent", LeaseDefaultsConfigMapName,
+		map[string]string{statusUpdatesDisabledKey: "true"})); err != nil {
+		t.Fatal(err)
+	}
+	ctrl.leaseDefaultsLister = configMapInformer.Lister().ConfigMaps("agent")
+
+	recorder := events.NewInMemoryRecorder("test")
+	condition := metav1.Condition{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionFalse,
+		Reason: "ManagedClusterAddOnLeaseUpdateStopped",
+	}
+
+	// the disabled event is emitted only once
+	for i := 0; i < 2; i++ {
+		if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+	if len(recorder.Events()) != 1 || recorder.Events()[0].Reason != "ManagedClusterAddOnLeaseGloballyDisabled" {
+		t.Errorf("expected the disabled event, but got %v", recorder.Events())
+	}
+
+	// the update is resumed once the flag is removed
+	if err := store.Update(newLeaseDefaultsConfigMap("agent", LeaseDefaultsConfigMapName,
+		map[string]string{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertActions(t, addOnClient.Actions(), "patch")
+	if ctrl.globalDisable.reported {
+		t.Errorf("expected the status updates are enabled")
+	}
+}
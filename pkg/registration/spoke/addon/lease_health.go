@@ -0,0 +1,126 @@
+package addon
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// AddOnHealthPath is the http path of the addon lease health endpoint
+const AddOnHealthPath = "/addons/health"
+
+// addOnLeaseHealth is the lease freshness of an addon observed by the lease controller
+type addOnLeaseHealth struct {
+	Name      string                 `json:"name"`
+	RenewTime *metav1.MicroTime      `json:"renewTime,omitempty"`
+	Status    metav1.ConditionStatus `json:"status"`
+	Reason    string                 `json:"reason,omitempty"`
+	Version   string                 `json:"version,omitempty"`
+
+	// generation and annotationsHash are the addon spec observed with the lease, the availability of the addon may be
+	// changed by its spec or annotations regardless of its lease.
+	generation      int64
+	annotationsHash string
+}
+
+// addOnsHealth is the response of the addon lease health endpoint, ready is true only if all of the known
+// addons are available, the addons excluded from the aggregate reporting are ignored.
+type addOnsHealth struct {
+	Ready  bool               `json:"ready"`
+	AddOns []addOnLeaseHealth `json:"addOns"`
+}
+
+// observedLeases records the latest lease freshness of each addon computed by the lease controller
+type observedLeases struct {
+	lock   sync.RWMutex
+	leases map[string]addOnLeaseHealth
+}
+
+func newObservedLeases() *observedLeases {
+	return &observedLeases{
+		leases: map[string]addOnLeaseHealth{},
+	}
+}
+
+func (o *observedLeases) set(health addOnLeaseHealth) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.leases[health.Name] = health
+}
+
+func (o *observedLeases) get(addOnName string) (addOnLeaseHealth, bool) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	health, ok := o.leases[addOnName]
+	return health, ok
+}
+
+func (o *observedLeases) remove(addOnName string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	delete(o.leases, addOnName)
+}
+
+// unavailable returns the reasons of the addons whose observed lease freshness is not available
+func (o *observedLeases) unavailable() map[string]string {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	reasons := map[string]string{}
+	for name, health := range o.leases {
+		if health.Status != metav1.ConditionTrue {
+			reasons[name] = health.Reason
+		}
+	}
+	return reasons
+}
+
+// UnavailableAddOns returns the addons whose available condition last computed by the controller is not true, keyed
+// by the addon name with the reason of the condition. The addons whose lease has not been checked yet are not
+// included. The returned map is a copy and can be modified by the caller.
+func (c *managedClusterAddOnLeaseController) UnavailableAddOns() map[string]string {
+	return c.observedLeases.unavailable()
+}
+
+// ServeHTTP responds the lease freshness of the addons on the managed cluster in json. The addons selected by the
+// controller are read from the addon lister of the controller, an addon whose lease has not been checked yet is
+// reported as unknown.
+func (c *managedClusterAddOnLeaseController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	addOns, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).List(c.addOnSelector)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	health := addOnsHealth{Ready: true, AddOns: []addOnLeaseHealth{}}
+	for _, addOn := range addOns {
+		// the health of the addon with customized health check is not determined by its lease
+		if addOn.Status.HealthCheck.Mode == addonv1alpha1.HealthCheckModeCustomized {
+			continue
+		}
+
+		addOnHealth, ok := c.observedLeases.get(addOn.Name)
+		if !ok {
+			addOnHealth = addOnLeaseHealth{Name: addOn.Name, Status: metav1.ConditionUnknown}
+		}
+		if addOnHealth.Status != metav1.ConditionTrue && !c.aggregateExclusion.excludes(addOn) {
+			health.Ready = false
+		}
+		health.AddOns = append(health.AddOns, addOnHealth)
+	}
+	sort.Slice(health.AddOns, func(i, j int) bool {
+		return health.AddOns[i].Name < health.AddOns[j].Name
+	})
+
+	data, err := json.Marshal(health)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,151 @@
+package addon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestServeAddOnsHealth(t *testing.T) {
+	newAddOn := func(name string) *addonv1alpha1.ManagedClusterAddOn {
+		return &addonv1alpha1.ManagedClusterAddOn{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: name},
+			Spec:       addonv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "test"},
+		}
+	}
+
+	cases := []struct {
+		name           string
+		leases         []runtime.Object
+		expectedReady  bool
+		expectedStatus map[string]metav1.ConditionStatus
+	}{
+		{
+			name: "all addons are available",
+			leases: []runtime.Object{
+				testinghelpers.NewAddOnLease("test", "test1", time.Now()),
+				testinghelpers.NewAddOnLease("test", "test2", time.Now()),
+			},
+			expectedReady: true,
+			expectedStatus: map[string]metav1.ConditionStatus{
+				"test1": metav1.ConditionTrue,
+				"test2": metav1.ConditionTrue,
+			},
+		},
+		{
+			name: "one addon is not available",
+			leases: []runtime.Object{
+				testinghelpers.NewAddOnLease("test", "test1", time.Now()),
+				testinghelpers.NewAddOnLease("test", "test2", time.Now().Add(-10*time.Minute)),
+			},
+			expectedReady: false,
+			expectedStatus: map[string]metav1.ConditionStatus{
+				"test1": metav1.ConditionTrue,
+				"test2": metav1.ConditionFalse,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOns := []runtime.Object{newAddOn("test1"), newAddOn("test2"), newAddOn("test3")}
+			ctrl, _ := newTestLeaseController(t, addOns, c.leases)
+			for _, name := range []string{"test1", "test2"} {
+				syncCtx := testingcommon.NewFakeSyncContext(t, "test/"+name)
+				if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+					t.Errorf("unexpected err: %v", err)
+				}
+			}
+
+			recorder := httptest.NewRecorder()
+			ctrl.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, AddOnHealthPath, nil))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected status code 200, but got %d", recorder.Code)
+			}
+
+			health := &addOnsHealth{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), health); err != nil {
+				t.Fatal(err)
+			}
+			// the addon test3 is not synced yet
+			if health.Ready {
+				t.Errorf("expected not ready with unknown addon, but got ready")
+			}
+			if len(health.AddOns) != 3 {
+				t.Fatalf("expected 3 addons, but got %d", len(health.AddOns))
+			}
+			if health.AddOns[2].Status != metav1.ConditionUnknown {
+				t.Errorf("expected unknown status of addon test3, but got %q", health.AddOns[2].Status)
+			}
+			for _, addOnHealth := range health.AddOns[:2] {
+				if addOnHealth.Status != c.expectedStatus[addOnHealth.Name] {
+					t.Errorf("expected status %q of addon %s, but got %q",
+						c.expectedStatus[addOnHealth.Name], addOnHealth.Name, addOnHealth.Status)
+				}
+				if addOnHealth.RenewTime == nil {
+					t.Errorf("expected renew time of addon %s, but failed", addOnHealth.Name)
+				}
+			}
+
+			// the readiness is reported once all of the known addons are synced
+			ctrl.observedLeases.set(addOnLeaseHealth{Name: "test3", Status: metav1.ConditionTrue})
+			recorder = httptest.NewRecorder()
+			ctrl.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, AddOnHealthPath, nil))
+			health = &addOnsHealth{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), health); err != nil {
+				t.Fatal(err)
+			}
+			if health.Ready != c.expectedReady {
+				t.Errorf("expected ready %v, but got %v", c.expectedReady, health.Ready)
+			}
+		})
+	}
+}
+
+func TestUnavailableAddOns(t *testing.T) {
+	addOns := []runtime.Object{
+		testinghelpers.NewFakeAddOn("test1", "test"),
+		testinghelpers.NewFakeAddOn("test2", "test"),
+		testinghelpers.NewFakeAddOn("test3", "test"),
+		testinghelpers.NewFakeAddOn("test4", "test"),
+	}
+	leases := []runtime.Object{
+		testinghelpers.NewAddOnLease("test", "test1", time.Now()),
+		testinghelpers.NewAddOnLease("test", "test2", time.Now().Add(-10*time.Minute)),
+	}
+	ctrl, _ := newTestLeaseController(t, addOns, leases)
+	// the addon test4 is not synced yet
+	for _, name := range []string{"test1", "test2", "test3"} {
+		syncCtx := testingcommon.NewFakeSyncContext(t, "test/"+name)
+		if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		"test2": "ManagedClusterAddOnLeaseUpdateStopped",
+		"test3": "ManagedClusterAddOnLeaseNotFound",
+	}
+	unavailable := ctrl.UnavailableAddOns()
+	if !reflect.DeepEqual(unavailable, expected) {
+		t.Errorf("expected unavailable addons %v, but got %v", expected, unavailable)
+	}
+
+	// the returned map is a copy
+	delete(unavailable, "test2")
+	if len(ctrl.UnavailableAddOns()) != 2 {
+		t.Errorf("expected the cached state is not modified by the caller")
+	}
+}
@@ -0,0 +1,71 @@
+package addon
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	coordv1 "k8s.io/api/coordination/v1"
+)
+
+const (
+	// leaseHeartbeatAnnotation is the annotation of the addon lease in which the addon agent stashes its heartbeat
+	// diagnostics, e.g. the last reconcile error. The value is base64 encoded and may be gzip compressed.
+	leaseHeartbeatAnnotation = "addon.open-cluster-management.io/heartbeat"
+
+	// maxHeartbeatLength is the max length of the heartbeat surfaced in the addon condition message, a longer
+	// heartbeat is truncated.
+	maxHeartbeatLength = 256
+
+	// maxDecompressedHeartbeatSize is the max size of a decompressed heartbeat, so that a malicious payload cannot
+	// exhaust the memory of the agent.
+	maxDecompressedHeartbeatSize = 4096
+)
+
+// gzipMagic is the header of the gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decodeLeaseHeartbeat returns the heartbeat stashed in the annotation of the addon lease. It is empty if the lease
+// is nil or has no heartbeat, and an error is returned if the heartbeat is malformed.
+func decodeLeaseHeartbeat(lease *coordv1.Lease) (string, error) {
+	if lease == nil {
+		return "", nil
+	}
+	value := lease.Annotations[leaseHeartbeatAnnotation]
+	if len(value) == 0 {
+		return "", nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode the heartbeat: %v", err)
+	}
+
+	if bytes.HasPrefix(data, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress the heartbeat: %v", err)
+		}
+		defer reader.Close()
+
+		data, err = io.ReadAll(io.LimitReader(reader, maxDecompressedHeartbeatSize))
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress the heartbeat: %v", err)
+		}
+	}
+
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("the heartbeat is not a valid utf-8 string")
+	}
+
+	// the heartbeat is surfaced in a single line
+	heartbeat := strings.Join(strings.Fields(string(data)), " ")
+	if runes := []rune(heartbeat); len(runes) > maxHeartbeatLength {
+		heartbeat = string(runes[:maxHeartbeatLength]) + "..."
+	}
+	return heartbeat, nil
+}
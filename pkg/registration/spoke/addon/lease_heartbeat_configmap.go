@@ -0,0 +1,59 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// heartbeatConfigMapKey is the key of the heartbeat configmap whose value is the time in RFC3339 format when the
+// addon agent last reported its heartbeat
+const heartbeatConfigMapKey = "heartbeat"
+
+// getHeartbeatConfigMapLease reads the heartbeat of the addon agent from the heartbeat configmap in the lease
+// namespace, and returns it as a lease renewed at the heartbeat time, so that the availability of the addon is
+// determined in the same way as its lease. The labels and annotations of the configmap are kept on the lease, e.g.
+// the agent version. The lease is nil if the configmap is not found or it has no valid heartbeat.
+func (c *managedClusterAddOnLeaseController) getHeartbeatConfigMapLease(ctx context.Context,
+	leaseNamespace string, leaseConfig *leaseConfig) (*coordv1.Lease, error) {
+	configMapClient := c.spokeConfigMapClient
+	if leaseConfig.AgentRunningOutsideManagedCluster {
+		configMapClient = c.managementConfigMapClient
+	}
+	if configMapClient == nil {
+		return nil, fmt.Errorf("the heartbeat configmap of addon %q cannot be read, no configmap client is configured",
+			leaseConfig.addOnName)
+	}
+
+	configMap, err := configMapClient.ConfigMaps(leaseNamespace).Get(ctx, leaseConfig.heartbeatConfigMap, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	heartbeat, err := time.Parse(time.RFC3339, configMap.Data[heartbeatConfigMapKey])
+	if err != nil {
+		// the malformed heartbeat is considered as not reported
+		klog.V(4).InfoS("Ignore the malformed heartbeat of the addon configmap", "cluster", c.clusterName,
+			"addon", leaseConfig.addOnName, "configMap", leaseConfig.heartbeatConfigMap, "reason", err.Error())
+		return nil, nil
+	}
+
+	renewTime := metav1.NewMicroTime(heartbeat)
+	return &coordv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        configMap.Name,
+			Namespace:   configMap.Namespace,
+			Labels:      configMap.Labels,
+			Annotations: configMap.Annotations,
+		},
+		Spec: coordv1.LeaseSpec{RenewTime: &renewTime},
+	}, nil
+}
@@ -0,0 +1,96 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func newHeartbeatConfigMap(heartbeat string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "test-heartbeat",
+			Labels:    map[string]string{agentVersionKey: "v1.0.0"},
+		},
+		Data: map[string]string{heartbeatConfigMapKey: heartbeat},
+	}
+}
+
+func TestSyncWithHeartbeatConfigMap(t *testing.T) {
+	cases := []struct {
+		name            string
+		configMaps      []runtime.Object
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+		expectedVersion string
+	}{
+		{
+			name:            "heartbeat is fresh",
+			configMaps:      []runtime.Object{newHeartbeatConfigMap(time.Now().UTC().Format(time.RFC3339))},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "ManagedClusterAddOnLeaseUpdated",
+			expectedVersion: "v1.0.0",
+		},
+		{
+			name: "heartbeat is expired",
+			configMaps: []runtime.Object{
+				newHeartbeatConfigMap(time.Now().Add(-10 * time.Minute).UTC().Format(time.RFC3339))},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "ManagedClusterAddOnLeaseUpdateStopped",
+			expectedVersion: "v1.0.0",
+		},
+		{
+			name:           "heartbeat is malformed",
+			configMaps:     []runtime.Object{newHeartbeatConfigMap("yesterday")},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "ManagedClusterAddOnLeaseNotFound",
+		},
+		{
+			name:           "heartbeat configmap is not found",
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "ManagedClusterAddOnLeaseNotFound",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = map[string]string{heartbeatConfigMapAnnotation: "test-heartbeat"}
+			// the addon lease is ignored once the heartbeat configmap is used
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+			ctrl.spokeConfigMapClient = kubefake.NewSimpleClientset(c.configMaps...).CoreV1()
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], c.expectedStatus, c.expectedReason)
+			if health, _ := ctrl.observedLeases.get("test"); health.Version != c.expectedVersion {
+				t.Errorf("expected the agent version %q is read from the configmap, but got %q",
+					c.expectedVersion, health.Version)
+			}
+		})
+	}
+}
+
+func TestSyncWithHeartbeatConfigMapWithoutClient(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	addOn.Annotations = map[string]string{heartbeatConfigMapAnnotation: "test-heartbeat"}
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err == nil {
+		t.Errorf("expected an error without the configmap client")
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+}
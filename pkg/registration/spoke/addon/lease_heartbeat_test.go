@@ -0,0 +1,156 @@
+package addon
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestDecodeLeaseHeartbeat(t *testing.T) {
+	gzipped := func(data string) string {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+
+	cases := []struct {
+		name              string
+		lease             *coordv1.Lease
+		heartbeat         string
+		expectedHeartbeat string
+		expectedErr       bool
+	}{
+		{
+			name: "lease is not found",
+		},
+		{
+			name:  "no heartbeat",
+			lease: testinghelpers.NewAddOnLease("test", "test", time.Now()),
+		},
+		{
+			name:              "encoded heartbeat",
+			lease:             testinghelpers.NewAddOnLease("test", "test", time.Now()),
+			heartbeat:         base64.StdEncoding.EncodeToString([]byte("last reconcile failed:\n  timeout")),
+			expectedHeartbeat: "last reconcile failed: timeout",
+		},
+		{
+			name:              "compressed heartbeat",
+			lease:             testinghelpers.NewAddOnLease("test", "test", time.Now()),
+			heartbeat:         gzipped("last reconcile failed"),
+			expectedHeartbeat: "last reconcile failed",
+		},
+		{
+			name:              "truncated heartbeat",
+			lease:             testinghelpers.NewAddOnLease("test", "test", time.Now()),
+			heartbeat:         gzipped(strings.Repeat("a", 1000)),
+			expectedHeartbeat: strings.Repeat("a", maxHeartbeatLength) + "...",
+		},
+		{
+			name:        "malformed encoding",
+			lease:       testinghelpers.NewAddOnLease("test", "test", time.Now()),
+			heartbeat:   "not base64!",
+			expectedErr: true,
+		},
+		{
+			name:        "malformed compression",
+			lease:       testinghelpers.NewAddOnLease("test", "test", time.Now()),
+			heartbeat:   base64.StdEncoding.EncodeToString([]byte{0x1f, 0x8b, 0x00}),
+			expectedErr: true,
+		},
+		{
+			name:        "invalid utf-8",
+			lease:       testinghelpers.NewAddOnLease("test", "test", time.Now()),
+			heartbeat:   base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe}),
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.lease != nil && len(c.heartbeat) != 0 {
+				c.lease.Annotations = map[string]string{leaseHeartbeatAnnotation: c.heartbeat}
+			}
+			heartbeat, err := decodeLeaseHeartbeat(c.lease)
+			if c.expectedErr != (err != nil) {
+				t.Errorf("expected error %t, but got %v", c.expectedErr, err)
+			}
+			if heartbeat != c.expectedHeartbeat {
+				t.Errorf("expected heartbeat %q, but got %q", c.expectedHeartbeat, heartbeat)
+			}
+		})
+	}
+}
+
+func TestSyncWithLeaseHeartbeat(t *testing.T) {
+	cases := []struct {
+		name            string
+		decodeHeartbeat bool
+		heartbeat       string
+		expectedMessage string
+	}{
+		{
+			name:      "decoding is disabled",
+			heartbeat: base64.StdEncoding.EncodeToString([]byte("reconcile failed")),
+		},
+		{
+			name:            "decoding is enabled",
+			decodeHeartbeat: true,
+			heartbeat:       base64.StdEncoding.EncodeToString([]byte("reconcile failed")),
+			expectedMessage: "The heartbeat of its agent: reconcile failed",
+		},
+		{
+			name:            "malformed heartbeat is ignored",
+			decodeHeartbeat: true,
+			heartbeat:       "not base64!",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			lease := testinghelpers.NewAddOnLease("test", "test", time.Now())
+			lease.Annotations = map[string]string{leaseHeartbeatAnnotation: c.heartbeat}
+			ctrl, addOnClient := newTestLeaseController(t,
+				[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")}, []runtime.Object{lease})
+			ctrl.decodeHeartbeat = c.decodeHeartbeat
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+
+			addOn := &addonv1alpha1.ManagedClusterAddOn{}
+			if err := json.Unmarshal(actions[0].(clienttesting.PatchAction).GetPatch(), addOn); err != nil {
+				t.Fatal(err)
+			}
+			message := meta.FindStatusCondition(addOn.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable).Message
+			hasHeartbeat := strings.Contains(message, "heartbeat")
+			if hasHeartbeat != (len(c.expectedMessage) != 0) || !strings.Contains(message, c.expectedMessage) {
+				t.Errorf("expected message contains %q, but got %q", c.expectedMessage, message)
+			}
+		})
+	}
+}
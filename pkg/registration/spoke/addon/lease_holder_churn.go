@@ -0,0 +1,89 @@
+package addon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	coordv1 "k8s.io/api/coordination/v1"
+	"k8s.io/utils/clock"
+)
+
+// defaultLeaseHolderChurnWindow is the default window in which the holder changes of an addon lease are counted
+const defaultLeaseHolderChurnWindow = 10 * time.Minute
+
+// leaseHolderChurnDetector detects the rapid changes of the holder identity of the addon leases, which indicates
+// the leader election of the addon agent replicas is unstable. The holder changes are observed when the addons are
+// synced, so the changes between two syncs of an addon are counted once.
+type leaseHolderChurnDetector struct {
+	clock clock.Clock
+	// threshold is the number of the holder changes within the window for a lease to be considered churning
+	threshold int
+	window    time.Duration
+
+	lock      sync.Mutex
+	histories map[string]*leaseHolderHistory
+}
+
+// leaseHolderHistory is the holder changes of an addon lease within the window
+type leaseHolderHistory struct {
+	holder   string
+	changes  []time.Time
+	churning bool
+}
+
+func newLeaseHolderChurnDetector(clock clock.Clock, threshold int, window time.Duration) *leaseHolderChurnDetector {
+	return &leaseHolderChurnDetector{
+		clock:     clock,
+		threshold: threshold,
+		window:    window,
+		histories: map[string]*leaseHolderHistory{},
+	}
+}
+
+// observe records the holder of the addon lease, a warning event is emitted once the holder changes reach the
+// threshold within the window, and emitted again only after the holder is stable and churns again.
+// The leases without holder identity are ignored.
+func (d *leaseHolderChurnDetector) observe(addOnName string, lease *coordv1.Lease, recorder events.Recorder) {
+	if lease == nil || lease.Spec.HolderIdentity == nil {
+		return
+	}
+	holder := *lease.Spec.HolderIdentity
+	now := d.clock.Now()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	history, ok := d.histories[addOnName]
+	if !ok {
+		d.histories[addOnName] = &leaseHolderHistory{holder: holder}
+		return
+	}
+	if history.holder != holder {
+		history.holder = holder
+		history.changes = append(history.changes, now)
+	}
+
+	// only the changes within the window are kept
+	for len(history.changes) > 0 && now.Sub(history.changes[0]) > d.window {
+		history.changes = history.changes[1:]
+	}
+
+	if len(history.changes) < d.threshold {
+		history.churning = false
+		return
+	}
+	if history.churning {
+		return
+	}
+	history.churning = true
+	recorder.Warningf("ManagedClusterAddOnLeaseHolderChurn",
+		"The holder of the lease of addon %s changed %d times within %s, the current holder is %s",
+		addOnName, len(history.changes), d.window, holder)
+}
+
+// forget removes the holder history of the addon
+func (d *leaseHolderChurnDetector) forget(addOnName string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.histories, addOnName)
+}
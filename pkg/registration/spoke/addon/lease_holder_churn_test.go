@@ -0,0 +1,95 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/pointer"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestLeaseHolderChurnDetector(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	detector := newLeaseHolderChurnDetector(fakeClock, 3, 10*time.Minute)
+	recorder := events.NewInMemoryRecorder("test")
+	lease := testinghelpers.NewAddOnLease("test", "test", time.Now())
+
+	// the leases without holder identity are ignored
+	detector.observe("test", lease, recorder)
+	if len(detector.histories) != 0 {
+		t.Errorf("expected no holder history, but got %v", detector.histories)
+	}
+
+	observe := func(holder string) {
+		lease.Spec.HolderIdentity = pointer.String(holder)
+		detector.observe("test", lease, recorder)
+		fakeClock.Step(time.Minute)
+	}
+
+	// the holder changes 2 times and the holder is stable afterwards
+	for _, holder := range []string{"agent-1", "agent-2", "agent-1", "agent-1"} {
+		observe(holder)
+	}
+	if len(recorder.Events()) != 0 {
+		t.Errorf("expected no event, but got %v", recorder.Events())
+	}
+
+	// the event is emitted only once while the holder churns
+	for _, holder := range []string{"agent-2", "agent-1", "agent-2"} {
+		observe(holder)
+	}
+	if len(recorder.Events()) != 1 || recorder.Events()[0].Reason != "ManagedClusterAddOnLeaseHolderChurn" {
+		t.Errorf("expected one churn event, but got %v", recorder.Events())
+	}
+
+	// the changes out of the window are not counted, and the event is emitted again once the holder churns again
+	fakeClock.Step(10 * time.Minute)
+	observe("agent-2")
+	for _, holder := range []string{"agent-1", "agent-2", "agent-1"} {
+		observe(holder)
+	}
+	if len(recorder.Events()) != 2 {
+		t.Errorf("expected 2 churn events, but got %v", recorder.Events())
+	}
+
+	detector.forget("test")
+	if len(detector.histories) != 0 {
+		t.Errorf("expected the holder history is forgotten, but got %v", detector.histories)
+	}
+}
+
+func TestSyncWithLeaseHolderChurn(t *testing.T) {
+	lease := testinghelpers.NewAddOnLease("test", "test", time.Now())
+	lease.Spec.HolderIdentity = pointer.String("agent-1")
+	ctrl, addOnClient := newTestLeaseController(t,
+		[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")}, []runtime.Object{lease})
+	ctrl.holderChurnDetector = newLeaseHolderChurnDetector(ctrl.clock, 1, time.Minute)
+	syncCtx := testingcommon.NewFakeSyncContext(t, "test/test")
+
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	lease.Spec.HolderIdentity = pointer.String("agent-2")
+	if _, err := ctrl.spokeLeaseClient.Leases("test").Update(context.TODO(), lease, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	addOnClient.ClearActions()
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	// the availability of the addon is not affected
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+	if len(ctrl.holderChurnDetector.histories["test"].changes) != 1 {
+		t.Errorf("expected the holder change is observed, but got %v", ctrl.holderChurnDetector.histories["test"])
+	}
+}
@@ -0,0 +1,75 @@
+package addon
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// defaultMaxResyncBackoff is the default max interval of the resync once the hub cluster is unreachable
+const defaultMaxResyncBackoff = 30 * time.Minute
+
+// resyncBackoff backs off the full resync of the addons exponentially while the hub cluster is unreachable, so that
+// the resyncs failing against the hub cluster will not generate noise during the outage of the hub cluster. The
+// consecutive failures are counted once per backoff window, and reset once the hub cluster is reachable again.
+type resyncBackoff struct {
+	interval   time.Duration
+	maxBackoff time.Duration
+
+	lock       sync.Mutex
+	failures   int
+	nextResync time.Time
+}
+
+func newResyncBackoff(interval, maxBackoff time.Duration) *resyncBackoff {
+	return &resyncBackoff{
+		interval:   interval,
+		maxBackoff: maxBackoff,
+	}
+}
+
+// record records the result of a request against the hub cluster, the errors unrelated to the connectivity of the
+// hub cluster are ignored.
+func (b *resyncBackoff) record(now time.Time, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		b.nextResync = time.Time{}
+		return
+	}
+	if !isHubUnreachable(err) {
+		return
+	}
+	if b.failures > 0 && now.Before(b.nextResync) {
+		// the failure is in the current backoff window
+		return
+	}
+
+	b.failures++
+	backoff := b.interval
+	for i := 1; i < b.failures && backoff < b.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > b.maxBackoff {
+		backoff = b.maxBackoff
+	}
+	b.nextResync = now.Add(backoff)
+}
+
+// allowResync returns true if the full resync is not backed off
+func (b *resyncBackoff) allowResync(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.failures == 0 || !now.Before(b.nextResync)
+}
+
+// isHubUnreachable returns true if the error indicates the hub cluster cannot be reached
+func isHubUnreachable(err error) bool {
+	return errors.IsServiceUnavailable(err) || errors.IsServerTimeout(err) || errors.IsTimeout(err) ||
+		utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err) ||
+		utilnet.IsTimeout(err)
+}
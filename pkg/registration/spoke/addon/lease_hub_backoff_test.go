@@ -0,0 +1,92 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestResyncBackoff(t *testing.T) {
+	now := time.Now()
+	unavailable := errors.NewServiceUnavailable("hub is down")
+	backoff := newResyncBackoff(time.Minute, 5*time.Minute)
+
+	if !backoff.allowResync(now) {
+		t.Errorf("expected the resync is allowed without failures")
+	}
+
+	// the errors unrelated to the connectivity are ignored
+	backoff.record(now, errors.NewConflict(schema.GroupResource{}, "test", fmt.Errorf("conflict")))
+	if backoff.failures != 0 {
+		t.Errorf("expected no failures, but got %d", backoff.failures)
+	}
+
+	// the backoff is doubled for each consecutive failure and bounded by the max backoff
+	expectedBackoffs := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, expected := range expectedBackoffs {
+		backoff.record(now, unavailable)
+		// the failures in the same backoff window are counted once
+		backoff.record(now.Add(time.Second), unavailable)
+		if backoff.failures != i+1 {
+			t.Errorf("expected %d failures, but got %d", i+1, backoff.failures)
+		}
+		if backoff.allowResync(now.Add(expected - time.Second)) {
+			t.Errorf("expected the resync is backed off for %v", expected)
+		}
+		now = now.Add(expected)
+		if !backoff.allowResync(now) {
+			t.Errorf("expected the resync is allowed after %v", expected)
+		}
+	}
+
+	// the backoff is reset once the hub cluster is reachable
+	backoff.record(now, nil)
+	if backoff.failures != 0 || !backoff.allowResync(now) {
+		t.Errorf("expected the backoff is reset")
+	}
+}
+
+func TestSyncWithResyncBackoff(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+	fakeClock := ctrl.clock.(*clocktesting.FakeClock)
+	ctrl.resyncBackoff = newResyncBackoff(time.Minute, 5*time.Minute)
+	addOnClient.PrependReactor("patch", "managedclusteraddons",
+		func(action clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.NewServiceUnavailable("hub is down")
+		})
+
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err == nil {
+		t.Errorf("expected an error, but got nil")
+	}
+
+	// the resync is skipped while the hub cluster is unreachable
+	syncCtx := testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if syncCtx.Queue().Len() != 0 {
+		t.Errorf("expected the resync is skipped, but got %d queued keys", syncCtx.Queue().Len())
+	}
+
+	// the resync is resumed once the backoff elapses
+	fakeClock.Step(time.Minute)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if syncCtx.Queue().Len() != 1 {
+		t.Errorf("expected the addon is enqueued by the resync, but got %d queued keys", syncCtx.Queue().Len())
+	}
+}
@@ -0,0 +1,60 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// initialSync checks the leases of all of the addons in one pass before the controller starts, so that the first
+// available condition of each addon after a restart reflects its lease rather than waiting for the queue to be
+// processed. The addons are checked in the same way as they are synced by the controller, and the pass is aborted
+// once the timeout is exceeded, the remaining addons are left to the controller.
+func (c *managedClusterAddOnLeaseController) initialSync(ctx context.Context, timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := c.clock.Now()
+	if !cache.WaitForCacheSync(ctx.Done(), c.cachesSynced...) {
+		klog.Warningf("Skip the initial sync of the addons of cluster %q, the informer caches are not synced in %v",
+			c.clusterName, timeout)
+		return
+	}
+
+	addOns, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).List(c.addOnSelector)
+	if err != nil {
+		klog.Warningf("Skip the initial sync of the addons of cluster %q: %v", c.clusterName, err)
+		return
+	}
+
+	for i, addOn := range addOns {
+		if ctx.Err() != nil {
+			klog.Warningf("Initial sync of the addons of cluster %q is aborted: %v, %d of %d addons are processed",
+				c.clusterName, ctx.Err(), i, len(addOns))
+			break
+		}
+
+		leaseNamespace := getAddOnInstallationNamespace(addOn)
+		if leaseConfig, err := c.getAddOnLeaseConfig(addOn); err == nil {
+			leaseNamespace = leaseConfig.leaseNamespace
+		}
+		queueKey := fmt.Sprintf("%s/%s", leaseNamespace, addOn.Name)
+		if err := c.sync(ctx, drainSyncContext{SyncContext: c.syncCtx, queueKey: queueKey}); err != nil {
+			// the addon is retried by the resync of the controller
+			klog.Warningf("Failed to sync addon %q on startup: %v", queueKey, err)
+		}
+	}
+
+	if err := c.flushPendingStatusUpdates(ctx, c.syncCtx.Recorder()); err != nil {
+		klog.Warningf("Failed to flush the pending addon status updates on startup: %v", err)
+	}
+	klog.V(4).InfoS("Initial sync of the addons is completed", "cluster", c.clusterName,
+		"addons", len(addOns), "duration", c.clock.Since(start))
+}
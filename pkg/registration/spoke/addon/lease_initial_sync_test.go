@@ -0,0 +1,78 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestInitialSync(t *testing.T) {
+	addOns := []runtime.Object{
+		testinghelpers.NewFakeAddOn("test1", "test"),
+		testinghelpers.NewFakeAddOn("test2", "test"),
+	}
+	leases := []runtime.Object{
+		testinghelpers.NewAddOnLease("test", "test1", time.Now()),
+	}
+
+	cases := []struct {
+		name            string
+		timeout         time.Duration
+		synced          bool
+		batchInterval   time.Duration
+		expectedActions []string
+	}{
+		{
+			name: "initial sync is disabled",
+		},
+		{
+			name:            "addons are checked",
+			timeout:         time.Second,
+			synced:          true,
+			expectedActions: []string{"patch", "patch"},
+		},
+		{
+			name:            "pending status updates are flushed",
+			timeout:         time.Second,
+			synced:          true,
+			batchInterval:   time.Minute,
+			expectedActions: []string{"patch", "patch"},
+		},
+		{
+			name:    "addon cache is not synced",
+			timeout: 100 * time.Millisecond,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl, addOnClient := newTestLeaseController(t, addOns, leases)
+			ctrl.cachesSynced = []cache.InformerSynced{func() bool { return c.synced }}
+			ctrl.statusUpdateBatchInterval = c.batchInterval
+			ctrl.pendingStatusUpdates = newPendingStatusUpdates()
+
+			ctrl.initialSync(context.TODO(), c.timeout)
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, c.expectedActions...)
+			if len(actions) == 0 {
+				return
+			}
+
+			for name, expected := range map[string]metav1.ConditionStatus{
+				"test1": metav1.ConditionTrue,
+				"test2": metav1.ConditionUnknown,
+			} {
+				if health, ok := ctrl.observedLeases.get(name); !ok || health.Status != expected {
+					t.Errorf("expected addon %s is %q, but got %v", name, expected, health)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package addon
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+const (
+	// maintenanceWindowStartKey and maintenanceWindowEndKey are the keys of the ConfigMap addon-lease-defaults for
+	// the start and end time in RFC3339 format of a planned maintenance window, e.g. of the hub cluster. The window
+	// starts immediately if the start is absent, and lasts until it is removed if the end is absent.
+	maintenanceWindowStartKey = "maintenanceWindowStart"
+	maintenanceWindowEndKey   = "maintenanceWindowEnd"
+)
+
+// maintenanceWindow is a planned maintenance window, during which the available addons are not turned unavailable.
+type maintenanceWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// getMaintenanceWindow returns the maintenance window from the ConfigMap, the invalid times are ignored.
+func getMaintenanceWindow(configMap *corev1.ConfigMap) maintenanceWindow {
+	return maintenanceWindow{
+		start: getTime(configMap, maintenanceWindowStartKey),
+		end:   getTime(configMap, maintenanceWindowEndKey),
+	}
+}
+
+// getTime returns the time of the key in RFC3339 format in the ConfigMap, the zero time is returned if the value
+// is absent or invalid.
+func getTime(configMap *corev1.ConfigMap, key string) time.Time {
+	value, ok := configMap.Data[key]
+	if !ok {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		klog.Warningf("Ignore the invalid %q of ConfigMap %s/%s, the value %q must be a time in RFC3339 format",
+			key, configMap.Namespace, configMap.Name, value)
+		return time.Time{}
+	}
+	return t
+}
+
+// active returns true if the time is within the maintenance window, and the remaining duration of the window which
+// is zero if the window has no end.
+func (w maintenanceWindow) active(now time.Time) (bool, time.Duration) {
+	if w.start.IsZero() && w.end.IsZero() {
+		return false, 0
+	}
+	if !w.start.IsZero() && now.Before(w.start) {
+		return false, 0
+	}
+	if w.end.IsZero() {
+		return true, 0
+	}
+	if !now.Before(w.end) {
+		return false, 0
+	}
+	return true, w.end.Sub(now)
+}
+
+// holdDuringMaintenance returns true if the addon is available and the observed condition which turns it
+// unavailable is held since it is within the maintenance window, and the duration after which the addon should be
+// rechecked, which is the resync interval of the controller if the window has no end. The other transitions of the addon are not held, neither is the condition forced by the annotation
+// addon.open-cluster-management.io/force-status.
+func (c *managedClusterAddOnLeaseController) holdDuringMaintenance(addOn *addonv1alpha1.ManagedClusterAddOn,
+	condition metav1.Condition) (bool, time.Duration) {
+	if _, forced := getForcedAvailableCondition(c.conditionType, addOn); forced || condition.Status == metav1.ConditionTrue {
+		return false, 0
+	}
+	existing := meta.FindStatusCondition(addOn.Status.Conditions, c.conditionType)
+	if existing == nil || existing.Status != metav1.ConditionTrue {
+		return false, 0
+	}
+
+	// the error of the lease defaults has been returned by getAddOnLeaseConfig before the addon is checked
+	defaults, _ := c.getLeaseDefaults()
+	active, remaining := defaults.maintenanceWindow.active(c.clock.Now())
+	if active && remaining == 0 {
+		// the window lasts until it is removed, the addon is rechecked periodically rather than only on the resync
+		// of the controller which skips the addons whose availability is unchanged
+		remaining = c.resyncInterval
+	}
+	return active, remaining
+}
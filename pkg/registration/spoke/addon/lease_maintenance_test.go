@@ -0,0 +1,225 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestMaintenanceWindowActive(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name              string
+		window            maintenanceWindow
+		expectedActive    bool
+		expectedRemaining time.Duration
+	}{
+		{
+			name: "no window",
+		},
+		{
+			name:   "window is not started",
+			window: maintenanceWindow{start: now.Add(time.Hour), end: now.Add(2 * time.Hour)},
+		},
+		{
+			name:              "within the window",
+			window:            maintenanceWindow{start: now.Add(-time.Hour), end: now.Add(time.Hour)},
+			expectedActive:    true,
+			expectedRemaining: time.Hour,
+		},
+		{
+			name:           "window has no end",
+			window:         maintenanceWindow{start: now.Add(-time.Hour)},
+			expectedActive: true,
+		},
+		{
+			name:              "window has no start",
+			window:            maintenanceWindow{end: now.Add(time.Minute)},
+			expectedActive:    true,
+			expectedRemaining: time.Minute,
+		},
+		{
+			name:   "window is ended",
+			window: maintenanceWindow{start: now.Add(-2 * time.Hour), end: now.Add(-time.Hour)},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			active, remaining := c.window.active(now)
+			if active != c.expectedActive || remaining != c.expectedRemaining {
+				t.Errorf("expected %v/%v, but got %v/%v", c.expectedActive, c.expectedRemaining, active, remaining)
+			}
+		})
+	}
+}
+
+func TestSyncWithinMaintenanceWindow(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name            string
+		existingStatus  metav1.ConditionStatus
+		annotations     map[string]string
+		windowStart     string
+		windowEnd       string
+		expectedActions []string
+		expectedReason  string
+	}{
+		{
+			name:            "available addon is turned unavailable without the window",
+			existingStatus:  metav1.ConditionTrue,
+			expectedActions: []string{"patch"},
+		},
+		{
+			name:           "available addon is held within the window",
+			existingStatus: metav1.ConditionTrue,
+			windowStart:    now.Add(-time.Hour).Format(time.RFC3339),
+			windowEnd:      now.Add(time.Hour).Format(time.RFC3339),
+		},
+		{
+			name:           "available addon is held within the window without end",
+			existingStatus: metav1.ConditionTrue,
+			windowStart:    now.Add(-time.Hour).Format(time.RFC3339),
+		},
+		{
+			name:            "available addon is turned unavailable after the window",
+			existingStatus:  metav1.ConditionTrue,
+			windowStart:     now.Add(-2 * time.Hour).Format(time.RFC3339),
+			windowEnd:       now.Add(-time.Hour).Format(time.RFC3339),
+			expectedActions: []string{"patch"},
+		},
+		{
+			name:            "forced unavailable addon is not held within the window",
+			existingStatus:  metav1.ConditionTrue,
+			annotations:     map[string]string{forceStatusAnnotation: forceStatusUnavailable},
+			windowStart:     now.Add(-time.Hour).Format(time.RFC3339),
+			windowEnd:       now.Add(time.Hour).Format(time.RFC3339),
+			expectedActions: []string{"patch"},
+			expectedReason:  "ManagedClusterAddOnStatusForced",
+		},
+		{
+			name:            "unknown addon is not held within the window",
+			existingStatus:  metav1.ConditionUnknown,
+			windowStart:     now.Add(-time.Hour).Format(time.RFC3339),
+			windowEnd:       now.Add(time.Hour).Format(time.RFC3339),
+			expectedActions: []string{"patch"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = c.annotations
+			addOn.Status.Conditions = []metav1.Condition{{
+				Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+				Status: c.existingStatus,
+				Reason: "ManagedClusterAddOnLeaseUpdated",
+			}}
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", now.Add(-10*time.Minute))})
+
+			data := map[string]string{}
+			if len(c.windowStart) != 0 {
+				data[maintenanceWindowStartKey] = c.windowStart
+			}
+			if len(c.windowEnd) != 0 {
+				data[maintenanceWindowEndKey] = c.windowEnd
+			}
+			configMapInformer := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 10*time.Minute).
+				Core().V1().ConfigMaps()
+			if err := configMapInformer.Informer().GetStore().Add(
+				newLeaseDefaultsConfigMap("agent", LeaseDefaultsConfigMapName, data)); err != nil {
+				t.Fatal(err)
+			}
+			ctrl.leaseDefaultsLister = configMapInformer.Lister().ConfigMaps("agent")
+
+			syncCtx := testingcommon.NewFakeSyncContext(t, "test/test")
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, c.expectedActions...)
+			expectedReason := c.expectedReason
+			if len(expectedReason) == 0 {
+				expectedReason = "ManagedClusterAddOnLeaseUpdateStopped"
+			}
+			if len(actions) != 0 {
+				assertAvailableCondition(t, actions[0], metav1.ConditionFalse, expectedReason)
+			}
+
+			// the held addon is rechecked once the window ends rather than immediately
+			if syncCtx.Queue().Len() != 0 {
+				t.Errorf("expected the addon is not queued immediately, but got %d", syncCtx.Queue().Len())
+			}
+		})
+	}
+}
+
+func TestHoldDuringMaintenanceRecheck(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name              string
+		windowEnd         string
+		expectedRemaining time.Duration
+	}{
+		{
+			name:              "addon is rechecked once the window ends",
+			windowEnd:         now.Add(time.Hour).Format(time.RFC3339),
+			expectedRemaining: time.Hour,
+		},
+		{
+			name:              "addon is rechecked after the resync interval within the window without end",
+			expectedRemaining: 5 * time.Minute,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Status.Conditions = []metav1.Condition{{
+				Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+				Status: metav1.ConditionTrue,
+				Reason: "ManagedClusterAddOnLeaseUpdated",
+			}}
+			ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+			ctrl.clock = clocktesting.NewFakeClock(now)
+			ctrl.resyncInterval = 5 * time.Minute
+
+			data := map[string]string{maintenanceWindowStartKey: now.Add(-time.Hour).Format(time.RFC3339)}
+			if len(c.windowEnd) != 0 {
+				data[maintenanceWindowEndKey] = c.windowEnd
+			}
+			configMapInformer := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 10*time.Minute).
+				Core().V1().ConfigMaps()
+			if err := configMapInformer.Informer().GetStore().Add(
+				newLeaseDefaultsConfigMap("agent", LeaseDefaultsConfigMapName, data)); err != nil {
+				t.Fatal(err)
+			}
+			ctrl.leaseDefaultsLister = configMapInformer.Lister().ConfigMaps("agent")
+
+			held, remaining := ctrl.holdDuringMaintenance(addOn, metav1.Condition{
+				Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+				Status: metav1.ConditionFalse,
+				Reason: "ManagedClusterAddOnLeaseUpdateStopped",
+			})
+			if !held {
+				t.Errorf("expected the addon is held within the window")
+			}
+			// the window end is truncated to seconds in RFC3339 format
+			if remaining > c.expectedRemaining || remaining <= c.expectedRemaining-time.Second {
+				t.Errorf("expected the addon is rechecked after %s, but got %s", c.expectedRemaining, remaining)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package addon
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasttemplate"
+	coordv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// leaseMessageAnnotationPrefix is the prefix of the annotations of an addon to customize the message of its available
+// condition, the annotation is keyed by the lowercase condition status, e.g.
+// addon.open-cluster-management.io/lease-message-false: "{{addonName}} is down, see https://runbooks/{{addonName}}".
+// The templates may interpolate the addon name with {{addonName}}, the last renew time of the lease in RFC3339 with
+// {{renewTime}} and the built-in message with {{message}}, the built-in message is used if no template is specified.
+const leaseMessageAnnotationPrefix = "addon.open-cluster-management.io/lease-message-"
+
+// the tags which can be interpolated in the message templates
+const (
+	messageTagAddOnName = "addonName"
+	messageTagRenewTime = "renewTime"
+	messageTagMessage   = "message"
+)
+
+// parseMessageTemplates parses the message templates of the addon annotations, an error is returned for the template
+// which is malformed or interpolates an unknown tag.
+func parseMessageTemplates(annotationsPath *field.Path,
+	annotations map[string]string) (map[metav1.ConditionStatus]*fasttemplate.Template, field.ErrorList) {
+	var errs field.ErrorList
+	var templates map[metav1.ConditionStatus]*fasttemplate.Template
+	for _, status := range []metav1.ConditionStatus{metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionUnknown} {
+		key := leaseMessageAnnotationPrefix + strings.ToLower(string(status))
+		value, ok := annotations[key]
+		if !ok {
+			continue
+		}
+
+		template, err := fasttemplate.NewTemplate(value, "{{", "}}")
+		if err == nil {
+			// render the template once to find the unknown tags
+			_, err = template.ExecuteFuncStringWithErr(func(w io.Writer, tag string) (int, error) {
+				switch strings.TrimSpace(tag) {
+				case messageTagAddOnName, messageTagRenewTime, messageTagMessage:
+					return 0, nil
+				default:
+					return 0, fmt.Errorf("unknown tag %q, it must be one of %s, %s and %s", tag,
+						messageTagAddOnName, messageTagRenewTime, messageTagMessage)
+				}
+			})
+		}
+		if err != nil {
+			errs = append(errs, field.Invalid(annotationsPath.Key(key), value, err.Error()))
+			continue
+		}
+
+		if templates == nil {
+			templates = map[metav1.ConditionStatus]*fasttemplate.Template{}
+		}
+		templates[status] = template
+	}
+	return templates, errs
+}
+
+// renderConditionMessage returns the message of the condition rendered with the message template of its status, the
+// message of the condition is returned as it is if the addon has no template of the status.
+func renderConditionMessage(leaseConfig *leaseConfig, condition metav1.Condition, lease *coordv1.Lease) string {
+	template, ok := leaseConfig.messageTemplates[condition.Status]
+	if !ok {
+		return condition.Message
+	}
+
+	var renewTime string
+	if lease != nil && lease.Spec.RenewTime != nil {
+		renewTime = lease.Spec.RenewTime.UTC().Format(time.RFC3339)
+	}
+	return template.ExecuteFuncString(func(w io.Writer, tag string) (int, error) {
+		switch strings.TrimSpace(tag) {
+		case messageTagAddOnName:
+			return w.Write([]byte(leaseConfig.addOnName))
+		case messageTagRenewTime:
+			return w.Write([]byte(renewTime))
+		default:
+			return w.Write([]byte(condition.Message))
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package addon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestParseMessageTemplates(t *testing.T) {
+	cases := []struct {
+		name              string
+		annotations       map[string]string
+		expectedTemplates []metav1.ConditionStatus
+		expectedErrs      int
+	}{
+		{
+			name: "no templates",
+		},
+		{
+			name: "valid templates",
+			annotations: map[string]string{
+				leaseMessageAnnotationPrefix + "true":    "{{addonName}} is up since {{ renewTime }}.",
+				leaseMessageAnnotationPrefix + "unknown": "{{message}} See https://runbooks/{{addonName}}.",
+			},
+			expectedTemplates: []metav1.ConditionStatus{metav1.ConditionTrue, metav1.ConditionUnknown},
+		},
+		{
+			name: "unknown tag",
+			annotations: map[string]string{
+				leaseMessageAnnotationPrefix + "false": "{{addonName}} is down on {{cluster}}.",
+			},
+			expectedErrs: 1,
+		},
+		{
+			name: "unclosed tag",
+			annotations: map[string]string{
+				leaseMessageAnnotationPrefix + "false": "{{addonName is down.",
+				leaseMessageAnnotationPrefix + "true":  "{{addonName}} is up.",
+			},
+			expectedTemplates: []metav1.ConditionStatus{metav1.ConditionTrue},
+			expectedErrs:      1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			templates, errs := parseMessageTemplates(field.NewPath("metadata", "annotations"), c.annotations)
+			if len(errs) != c.expectedErrs {
+				t.Errorf("expected %d errors, but got %v", c.expectedErrs, errs)
+			}
+			if len(templates) != len(c.expectedTemplates) {
+				t.Errorf("expected templates of %v, but got %v", c.expectedTemplates, templates)
+			}
+			for _, status := range c.expectedTemplates {
+				if _, ok := templates[status]; !ok {
+					t.Errorf("expected the template of status %q", status)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncWithMessageTemplates(t *testing.T) {
+	renewTime := time.Now().Add(-10 * time.Minute)
+	cases := []struct {
+		name            string
+		annotations     map[string]string
+		expectedMessage string
+	}{
+		{
+			name: "built-in message",
+			expectedMessage: fmt.Sprintf("test add-on is not available, its lease was last renewed at %s.",
+				renewTime.UTC().Format(time.RFC3339)),
+		},
+		{
+			name: "templated message",
+			annotations: map[string]string{
+				leaseMessageAnnotationPrefix + "false": "{{addonName}} is down since {{renewTime}}, see https://runbooks/{{addonName}}.",
+			},
+			expectedMessage: fmt.Sprintf("test is down since %s, see https://runbooks/test.",
+				renewTime.UTC().Format(time.RFC3339)),
+		},
+		{
+			name: "template of the other status",
+			annotations: map[string]string{
+				leaseMessageAnnotationPrefix + "true": "{{addonName}} is up.",
+			},
+			expectedMessage: fmt.Sprintf("test add-on is not available, its lease was last renewed at %s.",
+				renewTime.UTC().Format(time.RFC3339)),
+		},
+		{
+			name: "template with the built-in message",
+			annotations: map[string]string{
+				leaseMessageAnnotationPrefix + "false": "{{message}} Contact the addon vendor.",
+			},
+			expectedMessage: fmt.Sprintf("test add-on is not available, its lease was last renewed at %s. "+
+				"Contact the addon vendor.", renewTime.UTC().Format(time.RFC3339)),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = c.annotations
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", renewTime)})
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+
+			patched := &addonv1alpha1.ManagedClusterAddOn{}
+			if err := json.Unmarshal(actions[0].(clienttesting.PatchAction).GetPatch(), patched); err != nil {
+				t.Fatal(err)
+			}
+			condition := meta.FindStatusCondition(patched.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable)
+			if condition == nil {
+				t.Fatalf("expected addon available condition, but failed")
+			}
+			if condition.Message != c.expectedMessage {
+				t.Errorf("expected message %q, but got %q", c.expectedMessage, condition.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package addon
+
+import (
+	"sync"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	// the depth, adds, latency and work duration of the named controller queues, including the queue of the addon
+	// lease controller, are exported by the workqueue metrics provider
+	_ "k8s.io/component-base/metrics/prometheus/workqueue"
+)
+
+var (
+	// addOnLeaseStatusTransitions counts the transitions of the addon available condition which are
+	// updated by the addon lease controller.
+	addOnLeaseStatusTransitions = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Name:           "addon_lease_status_transitions_total",
+			Help:           "Number of transitions of the managed cluster addon available condition updated by the addon lease controller.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster", "addon", "to_status"},
+	)
+
+	// addOnLeaseAge is the duration since the addon lease was last renewed when it is observed by the addon
+	// lease controller.
+	addOnLeaseAge = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "addon_lease_age_seconds",
+			Help:           "Seconds since the managed cluster addon lease was last renewed when it is observed by the addon lease controller.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster", "addon"},
+	)
+
+	// addOnLeaseRenewalInterval is the interval between the successive renew times of the addon lease observed by
+	// the addon lease controller, a drifting renewal can be found before the lease expires.
+	addOnLeaseRenewalInterval = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Name:           "addon_lease_renewal_interval_seconds",
+			Help:           "Seconds between the successive renew times of the managed cluster addon lease observed by the addon lease controller.",
+			Buckets:        []float64{10, 30, 60, 90, 120, 180, 300, 600},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster", "addon"},
+	)
+
+	// addOnLeaseUnmanaged counts the addons which are deleted and no longer managed by the addon lease controller.
+	addOnLeaseUnmanaged = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Name:           "addon_lease_unmanaged_total",
+			Help:           "Number of managed cluster addons which are deleted and no longer managed by the addon lease controller.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster"},
+	)
+
+	// addOnLeaseDurationMismatch indicates whether the lease duration declared by the addon agent in its lease
+	// diverges from the lease duration expected by the addon lease controller.
+	addOnLeaseDurationMismatch = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "addon_lease_duration_mismatch",
+			Help:           "Whether the lease duration declared by the managed cluster addon agent diverges from the one expected by the addon lease controller.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster", "addon"},
+	)
+
+	// addOnCurrentStateDuration is the duration since the addon available condition transitioned to its
+	// current status, it is derived from the last transition time of the condition.
+	addOnCurrentStateDuration = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "addon_current_state_duration_seconds",
+			Help:           "Seconds since the managed cluster addon available condition transitioned to its current status.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster", "addon", "status"},
+	)
+
+	// addOnRecoveries counts the recoveries of the addons, an addon recovers once its available condition turns
+	// true after it has been false.
+	addOnRecoveries = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Name:           "addon_recovery_total",
+			Help:           "Number of times the managed cluster addon turned available after it had been unavailable.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster", "addon"},
+	)
+
+	// addOnStickyAvailableHolds counts the times that a sticky available addon is kept available though its lease
+	// is stale, see the annotation addon.open-cluster-management.io/lease-sticky-available.
+	addOnStickyAvailableHolds = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Name:           "addon_lease_sticky_available_total",
+			Help:           "Number of times the managed cluster addon was kept available though its lease was stale.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster", "addon"},
+	)
+
+	// addOnLeaseControllerSyncDuration is the duration of each sync of the addon lease controller, a resync checks all
+	// of the addons while the other syncs check a single addon or flush the pending status updates.
+	addOnLeaseControllerSyncDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Name:           "addon_lease_controller_sync_duration_seconds",
+			Help:           "Seconds taken by each sync of the addon lease controller.",
+			Buckets:        []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"controller", "sync"},
+	)
+
+	registerLeaseMetricsOnce sync.Once
+)
+
+// registerLeaseMetrics registers the metrics of the addon lease controller, so that they can be exported
+// on the metrics endpoint of the agent.
+func registerLeaseMetrics() {
+	registerLeaseMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(addOnLeaseStatusTransitions)
+		legacyregistry.MustRegister(addOnLeaseAge)
+		legacyregistry.MustRegister(addOnLeaseRenewalInterval)
+		legacyregistry.MustRegister(addOnLeaseUnmanaged)
+		legacyregistry.MustRegister(addOnLeaseDurationMismatch)
+		legacyregistry.MustRegister(addOnCurrentStateDuration)
+		legacyregistry.MustRegister(addOnLeaseControllerSyncDuration)
+		legacyregistry.MustRegister(addOnRecoveries)
+		legacyregistry.MustRegister(addOnStickyAvailableHolds)
+	})
+}
+
+// syncType returns the type of the sync with the queue key, it is one of resync, flush and addon.
+func syncType(queueKey string) string {
+	switch queueKey {
+	case factory.DefaultQueueKey:
+		return "resync"
+	case flushStatusQueueKey:
+		return "flush"
+	case aggregateQueueKey:
+		return "aggregate"
+	default:
+		return "addon"
+	}
+}
@@ -0,0 +1,247 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/metrics/testutil"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestAddOnLeaseStatusTransitionsMetric(t *testing.T) {
+	registerLeaseMetrics()
+
+	addOn := &addonv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testinghelpers.TestManagedClusterName,
+			Name:      "metrics",
+		},
+		Spec: addonv1alpha1.ManagedClusterAddOnSpec{
+			InstallNamespace: "test",
+		},
+	}
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "metrics", time.Now())})
+
+	counter := addOnLeaseStatusTransitions.WithLabelValues(
+		testinghelpers.TestManagedClusterName, "metrics", string(metav1.ConditionTrue))
+	before, err := testutil.GetCounterMetricValue(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	syncCtx := testingcommon.NewFakeSyncContext(t, "test/metrics")
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	after, err := testutil.GetCounterMetricValue(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after-before != 1 {
+		t.Errorf("expected the transitions metric is increased by 1, but got %v", after-before)
+	}
+}
+
+func TestAddOnLeaseAgeMetric(t *testing.T) {
+	registerLeaseMetrics()
+
+	addOn := testinghelpers.NewFakeAddOn("age", "test")
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	ctrl.clock = fakeClock
+	ctrl.spokeLeaseClient = kubefake.NewSimpleClientset(
+		testinghelpers.NewAddOnLease("test", "age", fakeClock.Now().Add(-90*time.Second))).CoordinationV1()
+
+	syncCtx := testingcommon.NewFakeSyncContext(t, "test/age")
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	age, err := testutil.GetGaugeMetricValue(addOnLeaseAge.WithLabelValues(testinghelpers.TestManagedClusterName, "age"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if age != 90 {
+		t.Errorf("expected the lease age is 90 seconds, but got %v", age)
+	}
+
+	// the series is deleted once the addon is removed
+	ctrl.addOnLister = addoninformers.NewSharedInformerFactory(addonfake.NewSimpleClientset(), time.Minute).
+		Addon().V1alpha1().ManagedClusterAddOns().Lister()
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if addOnLeaseAge.DeleteLabelValues(testinghelpers.TestManagedClusterName, "age") {
+		t.Errorf("expected the lease age series is deleted, but failed")
+	}
+}
+
+func TestAddOnLeaseRenewalIntervalMetric(t *testing.T) {
+	registerLeaseMetrics()
+
+	addOn := testinghelpers.NewFakeAddOn("renewal", "test")
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	ctrl.clock = fakeClock
+
+	syncCtx := testingcommon.NewFakeSyncContext(t, "test/renewal")
+	for _, renewTime := range []time.Time{fakeClock.Now(), fakeClock.Now().Add(90 * time.Second)} {
+		ctrl.spokeLeaseClient = kubefake.NewSimpleClientset(
+			testinghelpers.NewAddOnLease("test", "renewal", renewTime)).CoordinationV1()
+		if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+
+	histogram := addOnLeaseRenewalInterval.WithLabelValues(testinghelpers.TestManagedClusterName, "renewal")
+	count, err := testutil.GetHistogramMetricCount(histogram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := testutil.GetHistogramMetricValue(histogram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || sum != 90 {
+		t.Errorf("expected one renewal interval of 90 seconds, but got %d observations with sum %v", count, sum)
+	}
+}
+
+func TestAddOnCurrentStateDurationMetric(t *testing.T) {
+	registerLeaseMetrics()
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	addOn := testinghelpers.NewFakeAddOn("duration", "test")
+	addOn.Status.Conditions = []metav1.Condition{
+		{
+			Type:               addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+			Status:             metav1.ConditionTrue,
+			Reason:             "ManagedClusterAddOnLeaseUpdated",
+			LastTransitionTime: metav1.NewTime(fakeClock.Now().Add(-2 * time.Hour)),
+		},
+	}
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+	ctrl.clock = fakeClock
+	ctrl.spokeLeaseClient = kubefake.NewSimpleClientset(
+		testinghelpers.NewAddOnLease("test", "duration", fakeClock.Now())).CoordinationV1()
+
+	// the addon stays available, the duration is derived from the last transition time
+	ctrl.recordCurrentStateDuration(addOn, metav1.ConditionTrue)
+	duration, err := testutil.GetGaugeMetricValue(
+		addOnCurrentStateDuration.WithLabelValues(testinghelpers.TestManagedClusterName, "duration", "True"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duration != 7200 {
+		t.Errorf("expected the addon is available for 7200 seconds, but got %v", duration)
+	}
+
+	// the addon is transitioning to unavailable, the duration starts from zero
+	ctrl.recordCurrentStateDuration(addOn, metav1.ConditionFalse)
+	duration, err = testutil.GetGaugeMetricValue(
+		addOnCurrentStateDuration.WithLabelValues(testinghelpers.TestManagedClusterName, "duration", "False"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duration != 0 {
+		t.Errorf("expected the duration starts from zero, but got %v", duration)
+	}
+	if addOnCurrentStateDuration.DeleteLabelValues(testinghelpers.TestManagedClusterName, "duration", "True") {
+		t.Errorf("expected the series of the previous status is deleted, but failed")
+	}
+
+	// the series is deleted once the addon is removed
+	ctrl.addOnLister = addoninformers.NewSharedInformerFactory(addonfake.NewSimpleClientset(), time.Minute).
+		Addon().V1alpha1().ManagedClusterAddOns().Lister()
+	syncCtx := testingcommon.NewFakeSyncContext(t, "test/duration")
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if addOnCurrentStateDuration.DeleteLabelValues(testinghelpers.TestManagedClusterName, "duration", "False") {
+		t.Errorf("expected the duration series is deleted, but failed")
+	}
+}
+
+func TestAddOnLeaseControllerSyncDurationMetric(t *testing.T) {
+	registerLeaseMetrics()
+
+	ctrl, _ := newTestLeaseController(t,
+		[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+	ctrl.pendingStatusUpdates = newPendingStatusUpdates()
+
+	for _, queueKey := range []string{factory.DefaultQueueKey, "test/test", flushStatusQueueKey} {
+		histogram := addOnLeaseControllerSyncDuration.WithLabelValues(leaseControllerName, syncType(queueKey))
+		before, err := testutil.GetHistogramMetricCount(histogram)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, queueKey)); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+
+		after, err := testutil.GetHistogramMetricCount(histogram)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if after-before != 1 {
+			t.Errorf("expected one %s sync is observed, but got %d", syncType(queueKey), after-before)
+		}
+	}
+}
+
+func TestAddOnRecoveriesMetric(t *testing.T) {
+	registerLeaseMetrics()
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{}, []runtime.Object{})
+	addOnRecoveries.DeleteLabelValues(testinghelpers.TestManagedClusterName, "recovery")
+
+	transitions := []struct {
+		oldStatus          metav1.ConditionStatus
+		newStatus          metav1.ConditionStatus
+		expectedRecoveries float64
+	}{
+		{oldStatus: "", newStatus: metav1.ConditionTrue, expectedRecoveries: 0},
+		{oldStatus: metav1.ConditionTrue, newStatus: metav1.ConditionFalse, expectedRecoveries: 0},
+		{oldStatus: metav1.ConditionFalse, newStatus: metav1.ConditionTrue, expectedRecoveries: 1},
+		{oldStatus: metav1.ConditionTrue, newStatus: metav1.ConditionUnknown, expectedRecoveries: 1},
+		// the addon is not considered to recover if it has not been unavailable
+		{oldStatus: metav1.ConditionUnknown, newStatus: metav1.ConditionTrue, expectedRecoveries: 1},
+		{oldStatus: metav1.ConditionTrue, newStatus: metav1.ConditionFalse, expectedRecoveries: 1},
+		{oldStatus: metav1.ConditionFalse, newStatus: metav1.ConditionUnknown, expectedRecoveries: 1},
+		// the addon recovers through unknown
+		{oldStatus: metav1.ConditionUnknown, newStatus: metav1.ConditionTrue, expectedRecoveries: 2},
+	}
+	for i, transition := range transitions {
+		ctrl.recoveryTracker.record(testinghelpers.TestManagedClusterName, "recovery",
+			transition.oldStatus, transition.newStatus)
+		recoveries, err := testutil.GetCounterMetricValue(
+			addOnRecoveries.WithLabelValues(testinghelpers.TestManagedClusterName, "recovery"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if recoveries != transition.expectedRecoveries {
+			t.Errorf("expected %v recoveries after transition %d, but got %v", transition.expectedRecoveries, i, recoveries)
+		}
+	}
+
+	// the count is reset once the addon is deleted
+	ctrl.forgetAddOn("recovery")
+	if addOnRecoveries.DeleteLabelValues(testinghelpers.TestManagedClusterName, "recovery") {
+		t.Errorf("expected the series is deleted once the addon is deleted, but failed")
+	}
+}
@@ -0,0 +1,33 @@
+package addon
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// getNamespaceMissingCondition returns the available condition of an addon whose lease is not found since its
+// installation namespace does not exist on the managed cluster yet, which means the installation of the addon is
+// incomplete rather than its agent is down. False is returned if the namespace check is disabled, the addon agent
+// runs outside of the managed cluster, or the namespace exists.
+func (c *managedClusterAddOnLeaseController) getNamespaceMissingCondition(
+	addOn *addonv1alpha1.ManagedClusterAddOn, leaseConfig *leaseConfig) (metav1.Condition, bool, error) {
+	if c.namespaceLister == nil || leaseConfig.AgentRunningOutsideManagedCluster {
+		return metav1.Condition{}, false, nil
+	}
+
+	_, err := c.namespaceLister.Get(leaseConfig.InstallationNamespace)
+	if errors.IsNotFound(err) {
+		return metav1.Condition{
+			Type:   c.conditionType,
+			Status: metav1.ConditionUnknown,
+			Reason: "ManagedClusterAddOnNamespaceMissing",
+			Message: fmt.Sprintf("The status of %s add-on is unknown, its installation namespace %s does not exist.",
+				addOn.Name, leaseConfig.InstallationNamespace),
+		}, true, nil
+	}
+	return metav1.Condition{}, false, err
+}
@@ -0,0 +1,72 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithNamespaceCheck(t *testing.T) {
+	cases := []struct {
+		name           string
+		namespaces     []string
+		hosted         bool
+		expectedReason string
+	}{
+		{
+			name:           "installation namespace exists",
+			namespaces:     []string{"test"},
+			expectedReason: "ManagedClusterAddOnLeaseNotFound",
+		},
+		{
+			name:           "installation namespace is missing",
+			expectedReason: "ManagedClusterAddOnNamespaceMissing",
+		},
+		{
+			name:           "addon agent runs outside of the managed cluster",
+			hosted:         true,
+			expectedReason: "ManagedClusterAddOnLeaseNotFound",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			if c.hosted {
+				addOn.Annotations = map[string]string{hostingClusterNameAnnotation: "hosting"}
+			}
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+
+			namespaceInformer := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0).Core().V1().Namespaces()
+			for _, name := range c.namespaces {
+				namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+				if err := namespaceInformer.Informer().GetStore().Add(namespace); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ctrl.namespaceLister = namespaceInformer.Lister()
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			assertAvailableCondition(t, actions[0], metav1.ConditionUnknown, c.expectedReason)
+		})
+	}
+}
+
+func TestCachesSyncedWithNamespaceInformer(t *testing.T) {
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), time.Minute*10)
+	assertCachesSyncedWithInformer(t, kubeInformerFactory,
+		AddOnLeaseControllerOptions{SpokeNamespaceInformer: kubeInformerFactory.Core().V1().Namespaces()})
+}
@@ -0,0 +1,58 @@
+package addon
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+// pausedQueueKeys holds the queue keys of the controller while it is paused, the lease and addon events are still
+// observed and their queue keys are held, so that the controller catches up on them once it is resumed.
+type pausedQueueKeys struct {
+	lock   sync.Mutex
+	paused bool
+	keys   sets.Set[string]
+}
+
+// hold holds the queue key and returns true if the controller is paused
+func (p *pausedQueueKeys) hold(queueKey string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.paused {
+		return false
+	}
+	p.keys.Insert(queueKey)
+	return true
+}
+
+// Pause stops the controller from processing the syncs without draining its queue, the queue keys of the lease and
+// addon events received while the controller is paused are held until it is resumed, e.g. during a coordinated
+// upgrade of multiple controllers. The available conditions of the addons are not updated while the controller is
+// paused, so a long pause may lead to the stale status of the addons. RefreshAddOn is not affected by the pause.
+func (c *managedClusterAddOnLeaseController) Pause() {
+	c.pausedQueueKeys.lock.Lock()
+	defer c.pausedQueueKeys.lock.Unlock()
+	if c.pausedQueueKeys.paused {
+		return
+	}
+	c.pausedQueueKeys.paused = true
+	c.pausedQueueKeys.keys = sets.New[string]()
+	klog.Infof("The addon lease controller of cluster %q is paused", c.clusterName)
+}
+
+// Resume resumes the controller paused by Pause, the queue keys held during the pause are processed again.
+func (c *managedClusterAddOnLeaseController) Resume() {
+	c.pausedQueueKeys.lock.Lock()
+	defer c.pausedQueueKeys.lock.Unlock()
+	if !c.pausedQueueKeys.paused {
+		return
+	}
+	c.pausedQueueKeys.paused = false
+	for _, queueKey := range sets.List(c.pausedQueueKeys.keys) {
+		c.syncCtx.Queue().Add(queueKey)
+	}
+	klog.Infof("The addon lease controller of cluster %q is resumed, %d queue keys held during the pause are requeued",
+		c.clusterName, c.pausedQueueKeys.keys.Len())
+	c.pausedQueueKeys.keys = nil
+}
@@ -0,0 +1,49 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestPauseAndResume(t *testing.T) {
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{testinghelpers.NewFakeAddOn("test", "test")},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+
+	// the syncs are held while the controller is paused
+	ctrl.Pause()
+	for _, queueKey := range []string{"test/test", factory.DefaultQueueKey, "test/test"} {
+		if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, queueKey)); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+	if ctrl.syncCtx.Queue().Len() != 0 {
+		t.Errorf("expected no queue key is requeued during the pause, but got %d", ctrl.syncCtx.Queue().Len())
+	}
+
+	// the held queue keys are requeued once the controller is resumed
+	ctrl.Resume()
+	if ctrl.syncCtx.Queue().Len() != 2 {
+		t.Errorf("expected 2 queue keys are requeued, but got %d", ctrl.syncCtx.Queue().Len())
+	}
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+
+	// resuming a running controller is a no-op
+	ctrl.Resume()
+	if ctrl.syncCtx.Queue().Len() != 2 {
+		t.Errorf("expected 2 queue keys, but got %d", ctrl.syncCtx.Queue().Len())
+	}
+}
@@ -0,0 +1,208 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	rbacv1client "k8s.io/client-go/kubernetes/typed/rbac/v1"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addoninformerv1alpha1 "open-cluster-management.io/api/client/addon/informers/externalversions/addon/v1alpha1"
+	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+)
+
+// addOnLeaseRBACController ensures the agent of an addon is allowed to renew its lease, a Role granting the access
+// to the addon lease and a RoleBinding binding the Role to the service accounts in the addon installation namespace
+// are maintained in the addon lease namespace, and they are deleted once the addon is deleted.
+type addOnLeaseRBACController struct {
+	clusterName          string
+	addOnLister          addonlisterv1alpha1.ManagedClusterAddOnLister
+	managementRBACClient rbacv1client.RbacV1Interface
+	spokeRBACClient      rbacv1client.RbacV1Interface
+}
+
+// NewAddOnLeaseRBACController returns an instance of addOnLeaseRBACController
+func NewAddOnLeaseRBACController(clusterName string,
+	addOnInformer addoninformerv1alpha1.ManagedClusterAddOnInformer,
+	managementRBACClient rbacv1client.RbacV1Interface,
+	spokeRBACClient rbacv1client.RbacV1Interface,
+	recorder events.Recorder) factory.Controller {
+	c := &addOnLeaseRBACController{
+		clusterName:          clusterName,
+		addOnLister:          addOnInformer.Lister(),
+		managementRBACClient: managementRBACClient,
+		spokeRBACClient:      spokeRBACClient,
+	}
+
+	return factory.New().
+		WithInformersQueueKeyFunc(c.queueKeyFunc, addOnInformer.Informer()).
+		WithSync(c.sync).
+		ToController("AddOnLeaseRBACController", recorder)
+}
+
+// queueKeyFunc returns the queue key of an addon in the format of <lease location>/<lease namespace>/<addon name>,
+// so that the RBAC of an addon can still be located after the addon is deleted.
+func (c *addOnLeaseRBACController) queueKeyFunc(obj runtime.Object) string {
+	addOn, ok := obj.(*addonv1alpha1.ManagedClusterAddOn)
+	if !ok {
+		return ""
+	}
+
+	leaseConfig, err := getAddOnLeaseConfig(addOn)
+	if err != nil {
+		// the addon lease configuration is invalid, ignore it.
+		return ""
+	}
+
+	location := leaseOnSpokeCluster
+	if leaseConfig.AgentRunningOutsideManagedCluster {
+		location = leaseOnManagementCluster
+	}
+
+	return fmt.Sprintf("%s/%s/%s", location, leaseConfig.leaseNamespace, addOn.Name)
+}
+
+func (c *addOnLeaseRBACController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	parts := strings.Split(syncCtx.QueueKey(), "/")
+	if len(parts) != 3 {
+		// queue key is bad format, ignore it.
+		return nil
+	}
+	location, leaseNamespace, addOnName := parts[0], parts[1], parts[2]
+
+	rbacClient := c.spokeRBACClient
+	if location == leaseOnManagementCluster {
+		rbacClient = c.managementRBACClient
+	}
+
+	addOn, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).Get(addOnName)
+	if errors.IsNotFound(err) {
+		// the addon is deleted, revoke the access to its lease.
+		return c.cleanup(ctx, rbacClient, leaseNamespace, addOnName, syncCtx.Recorder())
+	}
+	if err != nil {
+		return err
+	}
+
+	leaseConfig, err := getAddOnLeaseConfig(addOn)
+	if err != nil {
+		return nil
+	}
+	if addOn.Status.HealthCheck.Mode == addonv1alpha1.HealthCheckModeCustomized ||
+		leaseConfig.leaseNamespace != leaseNamespace {
+		// the lease is not used by the addon or the lease namespace is changed, revoke the access to the lease
+		// in this namespace.
+		return c.cleanup(ctx, rbacClient, leaseNamespace, addOnName, syncCtx.Recorder())
+	}
+
+	role, roleBinding := leaseRBAC(leaseConfig, leaseNamespace)
+	if _, _, err := resourceapply.ApplyRole(ctx, rbacClient, syncCtx.Recorder(), role); err != nil {
+		return err
+	}
+	_, _, err = resourceapply.ApplyRoleBinding(ctx, rbacClient, syncCtx.Recorder(), roleBinding)
+	return err
+}
+
+// cleanup deletes the Role and RoleBinding of the addon lease, the objects which are not maintained by the
+// controller are kept.
+func (c *addOnLeaseRBACController) cleanup(ctx context.Context, rbacClient rbacv1client.RbacV1Interface,
+	leaseNamespace, addOnName string, recorder events.Recorder) error {
+	name := leaseRBACName(addOnName)
+
+	roleBinding, err := rbacClient.RoleBindings(leaseNamespace).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return err
+	case roleBinding.Labels[addonv1alpha1.AddonLabelKey] == addOnName:
+		if err := rbacClient.RoleBindings(leaseNamespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil &&
+			!errors.IsNotFound(err) {
+			return err
+		}
+		recorder.Eventf("AddOnLeaseRoleBindingDeleted", "The RoleBinding %s/%s of addon %q is deleted",
+			leaseNamespace, name, addOnName)
+	}
+
+	role, err := rbacClient.Roles(leaseNamespace).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return err
+	case role.Labels[addonv1alpha1.AddonLabelKey] == addOnName:
+		if err := rbacClient.Roles(leaseNamespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil &&
+			!errors.IsNotFound(err) {
+			return err
+		}
+		recorder.Eventf("AddOnLeaseRoleDeleted", "The Role %s/%s of addon %q is deleted", leaseNamespace, name, addOnName)
+	}
+	return nil
+}
+
+// leaseRBACName returns the name of the Role and RoleBinding of the addon lease
+func leaseRBACName(addOnName string) string {
+	return fmt.Sprintf("open-cluster-management:%s:lease", addOnName)
+}
+
+// leaseRBAC returns the Role granting the access to the addon lease and the RoleBinding binding the Role to the
+// service accounts in the addon installation namespace. The access is restricted to the lease named after the
+// addon unless the addon or component leases are selected by a label selector.
+func leaseRBAC(leaseConfig *leaseConfig, leaseNamespace string) (*rbacv1.Role, *rbacv1.RoleBinding) {
+	name := leaseRBACName(leaseConfig.addOnName)
+	labels := map[string]string{addonv1alpha1.AddonLabelKey: leaseConfig.addOnName}
+
+	var resourceNames []string
+	if leaseConfig.leaseSelector == nil && leaseConfig.componentLeaseSelector == nil {
+		resourceNames = []string{leaseConfig.addOnName}
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: leaseNamespace,
+			Labels:    labels,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{"coordination.k8s.io"},
+				Resources:     []string{"leases"},
+				ResourceNames: resourceNames,
+				Verbs:         []string{"get", "update", "patch"},
+			},
+			{
+				// the create request cannot be restricted by the resource name
+				APIGroups: []string{"coordination.k8s.io"},
+				Resources: []string{"leases"},
+				Verbs:     []string{"create"},
+			},
+		},
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: leaseNamespace,
+			Labels:    labels,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				APIGroup: rbacv1.GroupName,
+				Kind:     rbacv1.GroupKind,
+				Name:     fmt.Sprintf("system:serviceaccounts:%s", leaseConfig.InstallationNamespace),
+			},
+		},
+	}
+	return role, roleBinding
+}
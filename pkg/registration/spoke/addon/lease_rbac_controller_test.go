@@ -0,0 +1,170 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func newLeaseRBAC(t *testing.T, addOn *addonv1alpha1.ManagedClusterAddOn) (*rbacv1.Role, *rbacv1.RoleBinding) {
+	leaseConfig, err := getAddOnLeaseConfig(addOn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaseRBAC(leaseConfig, leaseConfig.leaseNamespace)
+}
+
+func TestLeaseRBACSync(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	role, roleBinding := newLeaseRBAC(t, addOn)
+
+	unlabeledRole, unlabeledRoleBinding := role.DeepCopy(), roleBinding.DeepCopy()
+	unlabeledRole.Labels, unlabeledRoleBinding.Labels = nil, nil
+
+	selectorAddOn := testinghelpers.NewFakeAddOn("test", "test")
+	selectorAddOn.Annotations = map[string]string{leaseSelectorAnnotation: "app=test"}
+
+	cases := []struct {
+		name                      string
+		queueKey                  string
+		addOns                    []runtime.Object
+		spokeObjects              []runtime.Object
+		managementObjects         []runtime.Object
+		validateSpokeActions      func(t *testing.T, actions []clienttesting.Action)
+		validateManagementActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:     "bad queue key",
+			queueKey: "test/test",
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:     "addon exists",
+			queueKey: "spoke/test/test",
+			addOns:   []runtime.Object{addOn},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "create", "get", "create")
+				role := actions[1].(clienttesting.CreateActionImpl).Object.(*rbacv1.Role)
+				if len(role.Rules) != 2 || len(role.Rules[0].ResourceNames) != 1 || role.Rules[0].ResourceNames[0] != "test" {
+					t.Errorf("expected the access is restricted to the addon lease, but got %v", role.Rules)
+				}
+				roleBinding := actions[3].(clienttesting.CreateActionImpl).Object.(*rbacv1.RoleBinding)
+				if len(roleBinding.Subjects) != 1 || roleBinding.Subjects[0].Name != "system:serviceaccounts:test" {
+					t.Errorf("expected the service accounts of the addon are bound, but got %v", roleBinding.Subjects)
+				}
+			},
+		},
+		{
+			name:         "addon exists and its rbac is up to date",
+			queueKey:     "spoke/test/test",
+			addOns:       []runtime.Object{addOn},
+			spokeObjects: []runtime.Object{role, roleBinding},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "get")
+			},
+		},
+		{
+			name:     "addon leases are selected by labels",
+			queueKey: "spoke/test/test",
+			addOns:   []runtime.Object{selectorAddOn},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "create", "get", "create")
+				role := actions[1].(clienttesting.CreateActionImpl).Object.(*rbacv1.Role)
+				if len(role.Rules[0].ResourceNames) != 0 {
+					t.Errorf("expected the access is not restricted by names, but got %v", role.Rules[0].ResourceNames)
+				}
+			},
+		},
+		{
+			name:         "addon is deleted",
+			queueKey:     "spoke/test/test",
+			spokeObjects: []runtime.Object{role, roleBinding},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "delete", "get", "delete")
+				testingcommon.AssertDelete(t, actions[1], "rolebindings", "test", leaseRBACName("test"))
+				testingcommon.AssertDelete(t, actions[3], "roles", "test", leaseRBACName("test"))
+			},
+		},
+		{
+			name:         "addon is deleted and its rbac is not maintained by the controller",
+			queueKey:     "spoke/test/test",
+			spokeObjects: []runtime.Object{unlabeledRole, unlabeledRoleBinding},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "get")
+			},
+		},
+		{
+			name:     "addon health check mode is customized",
+			queueKey: "spoke/test/test",
+			addOns: []runtime.Object{&addonv1alpha1.ManagedClusterAddOn{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testinghelpers.TestManagedClusterName, Name: "test"},
+				Spec:       addonv1alpha1.ManagedClusterAddOnSpec{InstallNamespace: "test"},
+				Status: addonv1alpha1.ManagedClusterAddOnStatus{
+					HealthCheck: addonv1alpha1.HealthCheck{Mode: addonv1alpha1.HealthCheckModeCustomized},
+				},
+			}},
+			spokeObjects: []runtime.Object{role, roleBinding},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "delete", "get", "delete")
+			},
+		},
+		{
+			name:              "addon is deleted (on management cluster)",
+			queueKey:          "management/test/test",
+			managementObjects: []runtime.Object{role, roleBinding},
+			validateSpokeActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertNoActions(t, actions)
+			},
+			validateManagementActions: func(t *testing.T, actions []clienttesting.Action) {
+				testingcommon.AssertActions(t, actions, "get", "delete", "get", "delete")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOnClient := addonfake.NewSimpleClientset(c.addOns...)
+			addOnInformerFactory := addoninformers.NewSharedInformerFactory(addOnClient, time.Minute*10)
+			addOnStore := addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore()
+			for _, addOn := range c.addOns {
+				if err := addOnStore.Add(addOn); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			spokeKubeClient := kubefake.NewSimpleClientset(c.spokeObjects...)
+			managementKubeClient := kubefake.NewSimpleClientset(c.managementObjects...)
+
+			ctrl := &addOnLeaseRBACController{
+				clusterName:          testinghelpers.TestManagedClusterName,
+				addOnLister:          addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				managementRBACClient: managementKubeClient.RbacV1(),
+				spokeRBACClient:      spokeKubeClient.RbacV1(),
+			}
+			syncCtx := testingcommon.NewFakeSyncContext(t, c.queueKey)
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			c.validateSpokeActions(t, spokeKubeClient.Actions())
+			if c.validateManagementActions != nil {
+				c.validateManagementActions(t, managementKubeClient.Actions())
+			}
+		})
+	}
+}
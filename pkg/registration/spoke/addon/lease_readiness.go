@@ -0,0 +1,50 @@
+package addon
+
+import (
+	"net/http"
+	"time"
+)
+
+// AddOnReadyPath is the http path of the addon lease controller readiness endpoint
+const AddOnReadyPath = "/addons/ready"
+
+// cacheSyncRetryInterval is the interval to retry the resync of the addons if the informer caches are not synced
+const cacheSyncRetryInterval = time.Second
+
+// hasCachesSynced returns true if all of the informer caches read by the controller are synced.
+func (c *managedClusterAddOnLeaseController) hasCachesSynced() bool {
+	for _, synced := range c.cachesSynced {
+		if !synced() {
+			return false
+		}
+	}
+	return true
+}
+
+// HasSyncedOnce returns true once the controller has completed its first full resync of the addons with the informer
+// caches synced, so that the readiness of the agent can be gated until the addons are evaluated rather than reporting
+// all of them unknown.
+func (c *managedClusterAddOnLeaseController) HasSyncedOnce() bool {
+	return c.syncedOnce.Load()
+}
+
+// IsStalled returns true if the watchdog of the controller is enabled and detects the controller is stalled.
+func (c *managedClusterAddOnLeaseController) IsStalled() bool {
+	return c.watchdog != nil && c.watchdog.isStalled()
+}
+
+// NewReadinessHandler returns the handler of the readiness endpoint of the controller, it responds 200 once the
+// controller has synced once, and 503 before that or while the controller is stalled.
+func NewReadinessHandler(controller AddOnLeaseController) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !controller.HasSyncedOnce() {
+			http.Error(w, "the addons have not been evaluated", http.StatusServiceUnavailable)
+			return
+		}
+		if controller.IsStalled() {
+			http.Error(w, "the addon lease controller is stalled", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+}
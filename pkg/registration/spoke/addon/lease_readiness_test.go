@@ -0,0 +1,106 @@
+package addon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{testinghelpers.NewFakeAddOn("test", "test")},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+	handler := NewReadinessHandler(ctrl)
+
+	assertReadiness := func(expectedCode int) {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, AddOnReadyPath, nil))
+		if recorder.Code != expectedCode {
+			t.Errorf("expected status code %d, but got %d", expectedCode, recorder.Code)
+		}
+	}
+
+	// the controller is not ready before the first resync
+	assertReadiness(http.StatusServiceUnavailable)
+
+	// a single addon sync does not complete the resync
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if ctrl.HasSyncedOnce() {
+		t.Errorf("expected the controller has not synced once")
+	}
+	assertReadiness(http.StatusServiceUnavailable)
+
+	// the resync with the unsynced addon cache is retried
+	synced := false
+	ctrl.cachesSynced = []cache.InformerSynced{func() bool { return synced }}
+	syncCtx := testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if ctrl.HasSyncedOnce() {
+		t.Errorf("expected the controller has not synced once with the unsynced addon cache")
+	}
+	assertReadiness(http.StatusServiceUnavailable)
+	if syncCtx.Queue().Len() != 0 {
+		t.Errorf("expected the resync is retried later, but the queue has %d keys", syncCtx.Queue().Len())
+	}
+
+	synced = true
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if !ctrl.HasSyncedOnce() {
+		t.Errorf("expected the controller has synced once")
+	}
+	assertReadiness(http.StatusOK)
+}
+
+// assertCachesSyncedWithInformer asserts the caches of the controller built with the options are synced only once
+// the informers of the informer factory are synced.
+func assertCachesSyncedWithInformer(t *testing.T, informerFactory interface{ Start(<-chan struct{}) },
+	options AddOnLeaseControllerOptions) {
+	t.Helper()
+	addOnClient := addonfake.NewSimpleClientset()
+	addOnInformerFactory := addoninformers.NewSharedInformerFactory(addOnClient, time.Minute*10)
+	ctrl := NewManagedClusterAddOnLeaseController(testinghelpers.TestManagedClusterName,
+		addOnClient,
+		addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns(),
+		kubefake.NewSimpleClientset().CoordinationV1(),
+		kubefake.NewSimpleClientset().CoordinationV1(),
+		kubefake.NewSimpleClientset().CoordinationV1(),
+		time.Minute,
+		options,
+		events.NewInMemoryRecorder("test"),
+	).(*managedClusterAddOnLeaseController)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	addOnInformerFactory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().HasSynced) {
+		t.Fatal("failed to sync the addon cache")
+	}
+	if ctrl.hasCachesSynced() {
+		t.Errorf("expected the caches are not synced before the informers are synced")
+	}
+
+	informerFactory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), ctrl.hasCachesSynced) {
+		t.Errorf("expected the caches are synced")
+	}
+}
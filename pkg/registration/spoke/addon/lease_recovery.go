@@ -0,0 +1,43 @@
+package addon
+
+import (
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// recoveryTracker tracks the transition sequences of the addon available conditions to count the recoveries of the
+// addons, an addon recovers once it turns available after it has been unavailable, even if its status was unknown
+// in between.
+type recoveryTracker struct {
+	lock        sync.Mutex
+	unavailable sets.Set[string]
+}
+
+// record records the transition of the available condition of the addon, and increases the recovery count of the
+// addon if it recovers.
+func (r *recoveryTracker) record(clusterName, addOnName string, oldStatus, newStatus metav1.ConditionStatus) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.unavailable == nil {
+		r.unavailable = sets.New[string]()
+	}
+	switch newStatus {
+	case metav1.ConditionFalse:
+		r.unavailable.Insert(addOnName)
+	case metav1.ConditionTrue:
+		if oldStatus == metav1.ConditionFalse || r.unavailable.Has(addOnName) {
+			addOnRecoveries.WithLabelValues(clusterName, addOnName).Inc()
+		}
+		r.unavailable.Delete(addOnName)
+	}
+}
+
+// forget removes the transition sequence of the addon
+func (r *recoveryTracker) forget(addOnName string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.unavailable.Delete(addOnName)
+}
@@ -0,0 +1,73 @@
+package addon
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// softReasonPending is a soft reason observed on an addon which is not applied yet
+type softReasonPending struct {
+	reason string
+	since  time.Time
+}
+
+// softReasonDebouncer defers the available conditions with the soft reasons, e.g. the lease is not found briefly
+// right after the controller starts, a condition with a soft reason is only applied once it persists for the
+// debounce duration, so that the momentary flaps of the addons will not trigger the alerts.
+type softReasonDebouncer struct {
+	clock    clock.Clock
+	reasons  sets.Set[string]
+	debounce time.Duration
+
+	lock    sync.Mutex
+	pending map[string]softReasonPending
+}
+
+func newSoftReasonDebouncer(clock clock.Clock, reasons []string, debounce time.Duration) *softReasonDebouncer {
+	return &softReasonDebouncer{
+		clock:    clock,
+		reasons:  sets.New[string](reasons...),
+		debounce: debounce,
+		pending:  map[string]softReasonPending{},
+	}
+}
+
+// remaining returns the remaining duration before the condition of the addon can be applied, it is not positive if
+// the condition is not deferred. The debounce duration of an addon starts once its soft reason is observed the first
+// time, and it restarts if the reason is changed.
+func (d *softReasonDebouncer) remaining(addOn *addonv1alpha1.ManagedClusterAddOn, condition metav1.Condition) time.Duration {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	existing := meta.FindStatusCondition(addOn.Status.Conditions, condition.Type)
+	if !d.reasons.Has(condition.Reason) || (existing != nil && existing.Reason == condition.Reason) {
+		delete(d.pending, addOn.Name)
+		return 0
+	}
+
+	pending, ok := d.pending[addOn.Name]
+	if !ok || pending.reason != condition.Reason {
+		d.pending[addOn.Name] = softReasonPending{reason: condition.Reason, since: d.clock.Now()}
+		return d.debounce
+	}
+
+	remaining := d.debounce - d.clock.Since(pending.since)
+	if remaining <= 0 {
+		delete(d.pending, addOn.Name)
+	}
+	return remaining
+}
+
+// forget removes the pending soft reason of the addon
+func (d *softReasonDebouncer) forget(addOnName string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.pending, addOnName)
+}
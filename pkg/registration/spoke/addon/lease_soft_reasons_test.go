@@ -0,0 +1,83 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithSoftReasons(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	addOn.Status.Conditions = []metav1.Condition{
+		{
+			Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+			Status: metav1.ConditionTrue,
+			Reason: "ManagedClusterAddOnLeaseUpdated",
+		},
+	}
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+	fakeClock := ctrl.clock.(*clocktesting.FakeClock)
+	ctrl.softReasonDebouncer = newSoftReasonDebouncer(ctrl.clock, []string{"ManagedClusterAddOnLeaseNotFound"}, time.Minute)
+	syncCtx := testingcommon.NewFakeSyncContext(t, "test/test")
+
+	// the lease is not found for the first time, the condition is deferred
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+
+	fakeClock.Step(30 * time.Second)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+
+	// the lease is still not found after the debounce, the condition is applied
+	fakeClock.Step(30 * time.Second)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	assertAvailableCondition(t, actions[0], metav1.ConditionUnknown, "ManagedClusterAddOnLeaseNotFound")
+}
+
+func TestSoftReasonDebouncer(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	debouncer := newSoftReasonDebouncer(fakeClock, []string{"SoftReason"}, time.Minute)
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	soft := metav1.Condition{Type: addonv1alpha1.ManagedClusterAddOnConditionAvailable, Reason: "SoftReason"}
+	hard := metav1.Condition{Type: addonv1alpha1.ManagedClusterAddOnConditionAvailable, Reason: "HardReason"}
+
+	if remaining := debouncer.remaining(addOn, hard); remaining > 0 {
+		t.Errorf("expected the hard reason is not deferred, but got %v", remaining)
+	}
+	if remaining := debouncer.remaining(addOn, soft); remaining != time.Minute {
+		t.Errorf("expected the soft reason is deferred for 1m, but got %v", remaining)
+	}
+
+	// the soft reason flaps, the debounce restarts
+	fakeClock.Step(45 * time.Second)
+	if remaining := debouncer.remaining(addOn, hard); remaining > 0 {
+		t.Errorf("expected the hard reason is not deferred, but got %v", remaining)
+	}
+	fakeClock.Step(30 * time.Second)
+	if remaining := debouncer.remaining(addOn, soft); remaining != time.Minute {
+		t.Errorf("expected the soft reason is deferred for 1m, but got %v", remaining)
+	}
+
+	// the soft reason has been applied to the addon
+	addOn.Status.Conditions = []metav1.Condition{soft}
+	if remaining := debouncer.remaining(addOn, soft); remaining > 0 {
+		t.Errorf("expected the applied soft reason is not deferred, but got %v", remaining)
+	}
+}
@@ -0,0 +1,108 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// informerActivity tracks the time of the last event received by the addon informer. The resync events of the
+// informer are replayed from its local cache, so they are not counted.
+type informerActivity struct {
+	lock          sync.RWMutex
+	lastEventTime time.Time
+}
+
+func newInformerActivity(clock clock.Clock) *informerActivity {
+	return &informerActivity{lastEventTime: clock.Now()}
+}
+
+func (a *informerActivity) record(clock clock.Clock) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.lastEventTime = clock.Now()
+}
+
+func (a *informerActivity) since(clock clock.Clock) time.Duration {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return clock.Since(a.lastEventTime)
+}
+
+// eventHandler returns the handler recording the events of the addon informer
+func (a *informerActivity) eventHandler(clock clock.Clock) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			a.record(clock)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldAccessor, oldErr := meta.Accessor(oldObj)
+			newAccessor, newErr := meta.Accessor(newObj)
+			if oldErr == nil && newErr == nil && oldAccessor.GetResourceVersion() == newAccessor.GetResourceVersion() {
+				// ignore the resync event
+				return
+			}
+			a.record(clock)
+		},
+		DeleteFunc: func(obj interface{}) {
+			a.record(clock)
+		},
+	}
+}
+
+// isInformerStale returns true if the addon informer has not received any event within the staleness threshold.
+// The staleness is never reported if the threshold is not set.
+func (c *managedClusterAddOnLeaseController) isInformerStale() bool {
+	if c.stalenessThreshold <= 0 || c.informerActivity == nil {
+		return false
+	}
+	return c.informerActivity.since(c.clock) > c.stalenessThreshold
+}
+
+// markAddOnsStale updates the available condition of the addons to unknown, since the addons read from a stale
+// informer cache may result in a misleading availability.
+func (c *managedClusterAddOnLeaseController) markAddOnsStale(ctx context.Context,
+	addOns []*addonv1alpha1.ManagedClusterAddOn, recorder events.Recorder) error {
+	klog.Warningf("The addon informer of cluster %q has not received any event for more than %v, "+
+		"mark the status of %d addons unknown", c.clusterName, c.stalenessThreshold, len(addOns))
+
+	var errs []error
+	for _, addOn := range addOns {
+		if addOn.Status.HealthCheck.Mode == addonv1alpha1.HealthCheckModeCustomized {
+			continue
+		}
+
+		leaseConfig, err := c.getAddOnLeaseConfig(addOn)
+		if isAddOnConfigUnresolvable(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		condition := metav1.Condition{
+			Type:   c.conditionType,
+			Status: metav1.ConditionUnknown,
+			Reason: "ManagedClusterAddOnLeaseControllerStale",
+			Message: fmt.Sprintf("The status of %s add-on is unknown, the lease controller has not observed "+
+				"any addon change for more than %v.", addOn.Name, c.stalenessThreshold),
+		}
+		if err := c.updateAvailableCondition(ctx, addOn, leaseConfig.leaseNamespace, condition, recorder); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
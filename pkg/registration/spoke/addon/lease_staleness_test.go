@@ -0,0 +1,73 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestInformerActivity(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	activity := newInformerActivity(fakeClock)
+	handler := activity.eventHandler(fakeClock)
+
+	fakeClock.Step(time.Minute)
+	oldAddOn := &addonv1alpha1.ManagedClusterAddOn{ObjectMeta: metav1.ObjectMeta{Name: "test", ResourceVersion: "1"}}
+	// the resync event is ignored
+	handler.OnUpdate(oldAddOn, oldAddOn.DeepCopy())
+	if activity.since(fakeClock) != time.Minute {
+		t.Errorf("expected the resync event is ignored, but got %v since last event", activity.since(fakeClock))
+	}
+
+	newAddOn := oldAddOn.DeepCopy()
+	newAddOn.ResourceVersion = "2"
+	handler.OnUpdate(oldAddOn, newAddOn)
+	if activity.since(fakeClock) != 0 {
+		t.Errorf("expected the update event is recorded, but got %v since last event", activity.since(fakeClock))
+	}
+}
+
+func TestSyncWithStaleInformer(t *testing.T) {
+	addOns := []runtime.Object{
+		testinghelpers.NewFakeAddOn("test", "test"),
+	}
+	ctrl, addOnClient := newTestLeaseController(t, addOns,
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	ctrl.clock = fakeClock
+	ctrl.stalenessThreshold = 10 * time.Minute
+	ctrl.informerActivity = newInformerActivity(fakeClock)
+
+	// the addon is enqueued if the informer is not stale
+	syncCtx := testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if syncCtx.Queue().Len() != 1 {
+		t.Errorf("expected one addon in queue, but got %d", syncCtx.Queue().Len())
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+
+	// the addon status is unknown once the informer is stale
+	fakeClock.Step(11 * time.Minute)
+	syncCtx = testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if syncCtx.Queue().Len() != 0 {
+		t.Errorf("expected no addons in queue, but got %d", syncCtx.Queue().Len())
+	}
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	assertAvailableCondition(t, actions[0], metav1.ConditionUnknown, "ManagedClusterAddOnLeaseControllerStale")
+}
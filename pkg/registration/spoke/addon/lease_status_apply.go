@@ -0,0 +1,62 @@
+package addon
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// StatusUpdateStrategy is the strategy of the controller to update the available condition of an addon
+type StatusUpdateStrategy string
+
+const (
+	// StatusUpdateStrategyPatch patches the status of an addon with a merge patch, the patch is retried with the
+	// latest addon on conflict. It is the default strategy.
+	StatusUpdateStrategyPatch StatusUpdateStrategy = "Patch"
+
+	// StatusUpdateStrategyServerSideApply applies the status conditions of an addon with the server-side apply, the
+	// conditions are owned by the field manager of the controller, so that the update does not conflict with the
+	// updates of the other status fields by the other controllers.
+	StatusUpdateStrategyServerSideApply StatusUpdateStrategy = "ServerSideApply"
+)
+
+// statusFieldManager is the field manager of the addon status conditions applied by the controller
+const statusFieldManager = "addon-lease-controller"
+
+// applyStatus applies the available condition of an addon with the server-side apply, it returns false if the
+// condition is unchanged. The conditions of the addon status are an atomic list, so all of the conditions are applied
+// together with the updated one.
+func (c *managedClusterAddOnLeaseController) applyStatus(ctx context.Context,
+	addOn *addonv1alpha1.ManagedClusterAddOn, condition metav1.Condition) (bool, error) {
+	newAddOn := addOn.DeepCopy()
+	meta.SetStatusCondition(&newAddOn.Status.Conditions, condition)
+	if equality.Semantic.DeepEqual(newAddOn.Status, addOn.Status) {
+		return false, nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"apiVersion": addonv1alpha1.GroupVersion.String(),
+		"kind":       "ManagedClusterAddOn",
+		"metadata": map[string]interface{}{
+			"name":      addOn.Name,
+			"namespace": addOn.Namespace,
+		},
+		"status": map[string]interface{}{
+			"conditions": newAddOn.Status.Conditions,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = c.addOnClient.Patch(ctx, addOn.Name, types.ApplyPatchType, patch,
+		metav1.PatchOptions{FieldManager: statusFieldManager, Force: pointer.Bool(true)}, "status")
+	return err == nil, err
+}
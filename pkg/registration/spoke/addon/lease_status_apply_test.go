@@ -0,0 +1,67 @@
+package addon
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithServerSideApply(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	addOn.Status.Conditions = []metav1.Condition{{
+		Type:   "Configured",
+		Status: metav1.ConditionTrue,
+		Reason: "ConfigurationApplied",
+	}}
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+	ctrl.statusUpdateStrategy = StatusUpdateStrategyServerSideApply
+
+	// the fake client does not support the apply patch, see https://github.com/kubernetes/kubernetes/issues/103816
+	addOnClient.PrependReactor("patch", "managedclusteraddons",
+		func(action clienttesting.Action) (bool, runtime.Object, error) {
+			return true, addOn, nil
+		})
+
+	syncCtx := testingcommon.NewFakeSyncContext(t, "test/test")
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch")
+	patchAction := actions[0].(clienttesting.PatchActionImpl)
+	if patchAction.GetPatchType() != types.ApplyPatchType {
+		t.Errorf("expected apply patch, but got %q", patchAction.GetPatchType())
+	}
+	if patchAction.GetSubresource() != "status" {
+		t.Errorf("expected the status is applied, but got %q", patchAction.GetSubresource())
+	}
+
+	applied := &addonv1alpha1.ManagedClusterAddOn{}
+	if err := json.Unmarshal(patchAction.GetPatch(), applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied.Name != "test" || applied.Kind != "ManagedClusterAddOn" {
+		t.Errorf("unexpected applied addon %s %q", applied.Kind, applied.Name)
+	}
+	// the existing conditions are applied together with the available condition
+	if !meta.IsStatusConditionTrue(applied.Status.Conditions, "Configured") {
+		t.Errorf("expected the existing condition is kept, but got %v", applied.Status.Conditions)
+	}
+	if !meta.IsStatusConditionTrue(applied.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable) {
+		t.Errorf("expected the addon is available, but got %v", applied.Status.Conditions)
+	}
+}
@@ -0,0 +1,77 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// statusSummary caches the latest status of each addon observed by syncSingle, and reports a compact summary of
+// the addons periodically as a low-effort heartbeat of the overall addon health in the agent logs.
+type statusSummary struct {
+	clusterName string
+	interval    time.Duration
+
+	lock     sync.Mutex
+	statuses map[string]metav1.ConditionStatus
+}
+
+func newStatusSummary(clusterName string, interval time.Duration) *statusSummary {
+	return &statusSummary{
+		clusterName: clusterName,
+		interval:    interval,
+		statuses:    map[string]metav1.ConditionStatus{},
+	}
+}
+
+// record records the latest observed status of the addon
+func (s *statusSummary) record(addOnName string, status metav1.ConditionStatus) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.statuses[addOnName] = status
+}
+
+// forget removes the addon from the summary
+func (s *statusSummary) forget(addOnName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.statuses, addOnName)
+}
+
+// summary returns the counts of the available, unavailable and unknown addons, and the names of the addons which
+// are not available.
+func (s *statusSummary) summary() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	counts := map[metav1.ConditionStatus]int{}
+	notAvailable := []string{}
+	for addOnName, status := range s.statuses {
+		counts[status]++
+		if status != metav1.ConditionTrue {
+			notAvailable = append(notAvailable, fmt.Sprintf("%s(%s)", addOnName, status))
+		}
+	}
+	sort.Strings(notAvailable)
+
+	summary := fmt.Sprintf("Addons of cluster %q: %d available, %d unavailable, %d unknown",
+		s.clusterName, counts[metav1.ConditionTrue], counts[metav1.ConditionFalse], counts[metav1.ConditionUnknown])
+	if len(notAvailable) != 0 {
+		summary = fmt.Sprintf("%s, not available: %s", summary, strings.Join(notAvailable, ", "))
+	}
+	return summary
+}
+
+// run logs the summary every interval until the context is done
+func (s *statusSummary) run(ctx context.Context) {
+	wait.UntilWithContext(ctx, func(_ context.Context) {
+		klog.Info(s.summary())
+	}, s.interval)
+}
@@ -0,0 +1,75 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestStatusSummary(t *testing.T) {
+	addOns := []runtime.Object{
+		testinghelpers.NewFakeAddOn("available", "available"),
+		testinghelpers.NewFakeAddOn("unavailable", "unavailable"),
+		testinghelpers.NewFakeAddOn("unknown", "unknown"),
+	}
+	leases := []runtime.Object{
+		testinghelpers.NewAddOnLease("available", "available", time.Now()),
+		testinghelpers.NewAddOnLease("unavailable", "unavailable", time.Now().Add(-time.Hour)),
+	}
+	ctrl, _ := newTestLeaseController(t, addOns, leases)
+	ctrl.statusSummary = newStatusSummary(ctrl.clusterName, time.Minute)
+
+	expected := `Addons of cluster "testmanagedcluster": 0 available, 0 unavailable, 0 unknown`
+	if actual := ctrl.statusSummary.summary(); actual != expected {
+		t.Errorf("expected summary %q, but got %q", expected, actual)
+	}
+
+	for _, queueKey := range []string{"available/available", "unavailable/unavailable", "unknown/unknown"} {
+		if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, queueKey)); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+	expected = `Addons of cluster "testmanagedcluster": 1 available, 1 unavailable, 1 unknown, ` +
+		`not available: unavailable(False), unknown(Unknown)`
+	if actual := ctrl.statusSummary.summary(); actual != expected {
+		t.Errorf("expected summary %q, but got %q", expected, actual)
+	}
+
+	// the forgotten addon is removed from the summary
+	ctrl.forgetAddOn("unknown")
+	expected = `Addons of cluster "testmanagedcluster": 1 available, 1 unavailable, 0 unknown, ` +
+		`not available: unavailable(False)`
+	if actual := ctrl.statusSummary.summary(); actual != expected {
+		t.Errorf("expected summary %q, but got %q", expected, actual)
+	}
+}
+
+func TestStatusSummaryWithExclusion(t *testing.T) {
+	addOns := []runtime.Object{
+		testinghelpers.NewFakeAddOn("user", "user"),
+		testinghelpers.NewFakeAddOn("platform", "platform"),
+	}
+	ctrl, _ := newTestLeaseController(t, addOns, []runtime.Object{})
+	ctrl.statusSummary = newStatusSummary(ctrl.clusterName, time.Minute)
+	ctrl.aggregateExclusion = newAggregateExclusion([]string{"platform"}, nil)
+
+	for _, queueKey := range []string{"user/user", "platform/platform"} {
+		if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, queueKey)); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+
+	// the excluded addon is still tracked individually
+	if _, ok := ctrl.observedLeases.get("platform"); !ok {
+		t.Errorf("expected the excluded addon is tracked")
+	}
+	expected := `Addons of cluster "testmanagedcluster": 0 available, 0 unavailable, 1 unknown, not available: user(Unknown)`
+	if actual := ctrl.statusSummary.summary(); actual != expected {
+		t.Errorf("expected summary %q, but got %q", expected, actual)
+	}
+}
@@ -0,0 +1,353 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// flushStatusQueueKey is the queue key to trigger the flush of the pending addon status updates. It is
+// an invalid addon queue key, so it will never conflict with the key of an addon.
+const flushStatusQueueKey = "flush/pending/status"
+
+// pendingStatusUpdate is an addon available condition which is waiting to be updated on the hub cluster
+type pendingStatusUpdate struct {
+	leaseNamespace string
+	condition      metav1.Condition
+}
+
+// pendingStatusUpdates coalesces the addon available conditions computed within a batch interval, only
+// the latest condition of each addon is kept.
+type pendingStatusUpdates struct {
+	lock    sync.Mutex
+	updates map[string]pendingStatusUpdate
+}
+
+func newPendingStatusUpdates() *pendingStatusUpdates {
+	return &pendingStatusUpdates{
+		updates: map[string]pendingStatusUpdate{},
+	}
+}
+
+// add adds or replaces the pending status update of an addon
+func (p *pendingStatusUpdates) add(addOnName string, update pendingStatusUpdate) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.updates[addOnName] = update
+}
+
+// restore adds back a pending status update which is failed to update, it will not replace the
+// update computed afterwards.
+func (p *pendingStatusUpdates) restore(addOnName string, update pendingStatusUpdate) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.updates[addOnName]; !ok {
+		p.updates[addOnName] = update
+	}
+}
+
+// drain returns all of the pending status updates and clears them
+func (p *pendingStatusUpdates) drain() map[string]pendingStatusUpdate {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	updates := p.updates
+	p.updates = map[string]pendingStatusUpdate{}
+	return updates
+}
+
+// statusUpdateUnauthorizedBackoff is the duration that the addon status updates are paused once the addon client is
+// unauthorized, e.g. its client certificate is rotated out, so that the controller will not retry in a tight loop.
+const statusUpdateUnauthorizedBackoff = 2 * time.Minute
+
+// unauthorizedBackoff tracks the pause of the addon status updates since the addon client is unauthorized
+type unauthorizedBackoff struct {
+	lock  sync.Mutex
+	until time.Time
+}
+
+// remaining returns the remaining duration of the pause, it is not positive if the updates are not paused.
+func (b *unauthorizedBackoff) remaining(now time.Time) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.until.Sub(now)
+}
+
+// start pauses the updates for the backoff duration, it returns true if the updates are paused for the first time
+// since the last successful update.
+func (b *unauthorizedBackoff) start(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	first := b.until.IsZero()
+	b.until = now.Add(statusUpdateUnauthorizedBackoff)
+	return first
+}
+
+// reset resumes the updates once an update succeeds
+func (b *unauthorizedBackoff) reset() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.until = time.Time{}
+}
+
+// updateAvailableCondition updates the available condition of an addon on the hub cluster. The update is retried
+// with the latest addon on conflict, if the conflict still exists after the retries, a warning event is emitted and
+// the update is left to the next resync, so that the addon will not be requeued in a tight loop.
+// Once the addon client is unauthorized, the updates of all of the addons are paused for a backoff duration.
+// The update is skipped if the addon is suspended by the annotation addon.open-cluster-management.io/lease-suspend,
+// or the status updates of all of the addons are disabled by the ConfigMap addon-lease-defaults.
+// The condition is updated on the backup hubs as well, and the update succeeds if any of the hubs is updated.
+func (c *managedClusterAddOnLeaseController) updateAvailableCondition(ctx context.Context,
+	addOn *addonv1alpha1.ManagedClusterAddOn,
+	leaseNamespace string,
+	condition metav1.Condition,
+	recorder events.Recorder) error {
+	// the conditions are reported with the condition type of the controller
+	condition.Type = c.conditionType
+	if c.collapseUnknownStatus {
+		condition = collapseUnknownCondition(condition)
+	}
+	if c.isLeaseSuspended(addOn, recorder) {
+		klog.V(4).InfoS("Skip updating the addon available condition", "cluster", c.clusterName, "addon", addOn.Name,
+			"reason", "addon is suspended", "status", condition.Status)
+		return nil
+	}
+
+	if c.isStatusUpdateDisabled(recorder) {
+		klog.V(2).InfoS("Skip updating the addon available condition", "cluster", c.clusterName, "addon", addOn.Name,
+			"reason", "status updates are disabled globally", "status", condition.Status,
+			"conditionReason", condition.Reason, "message", condition.Message)
+		return nil
+	}
+
+	if c.observeOnly {
+		// the addon status is never updated in observe only mode, so the condition is compared with the one observed
+		// last time, or the one in the addon status if the addon is not observed yet.
+		lastCondition, observed := c.observedConditions.get(addOn.Name)
+		if !observed {
+			if existing := meta.FindStatusCondition(addOn.Status.Conditions, c.conditionType); existing != nil {
+				lastCondition, observed = *existing, true
+			}
+		}
+		if observed && isConditionUnchanged(lastCondition, condition) {
+			return nil
+		}
+		c.observedConditions.set(addOn.Name, condition)
+		klog.V(2).InfoS("Skip updating the addon available condition in observe only mode",
+			"cluster", c.clusterName, "addon", addOn.Name, "status", condition.Status,
+			"reason", condition.Reason, "message", condition.Message)
+		c.recordStatusObserved(addOn.Name, leaseNamespace, lastCondition.Status, condition, recorder)
+		return nil
+	}
+
+	queueKey := fmt.Sprintf("%s/%s", leaseNamespace, addOn.Name)
+	if remaining := c.warmupRemaining(); remaining > 0 {
+		klog.V(4).InfoS("Skip updating the addon available condition", "cluster", c.clusterName, "addon", addOn.Name,
+			"reason", "controller is warming up", "status", condition.Status, "retryAfter", remaining)
+		c.syncCtx.Queue().AddAfter(queueKey, remaining)
+		return nil
+	}
+	if remaining := c.statusUpdateBackoff.remaining(c.clock.Now()); remaining > 0 {
+		klog.V(4).InfoS("Skip updating the addon available condition", "cluster", c.clusterName, "addon", addOn.Name,
+			"reason", "addon client is unauthorized", "retryAfter", remaining)
+		c.syncCtx.Queue().AddAfter(queueKey, remaining)
+		return nil
+	}
+
+	attempts := 0
+	updated := false
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		attempts++
+		if attempts > 1 {
+			// the addon in the cache is out of date, get the latest one from the hub cluster
+			latestAddOn, err := c.addOnClient.Get(ctx, addOn.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			addOn = latestAddOn
+		}
+
+		var err error
+		if c.statusUpdateStrategy == StatusUpdateStrategyServerSideApply {
+			updated, err = c.applyStatus(ctx, addOn, condition)
+			return err
+		}
+		newAddon := addOn.DeepCopy()
+		meta.SetStatusCondition(&newAddon.Status.Conditions, condition)
+		updated, err = c.patcher.PatchStatus(ctx, newAddon, newAddon.Status, addOn.Status)
+		return err
+	})
+	// the hub cluster is not requested if the status is unchanged
+	if c.resyncBackoff != nil && (err != nil || updated) {
+		c.resyncBackoff.record(c.clock.Now(), err)
+	}
+	backupHubsUpdated := c.updateBackupHubs(ctx, addOn.Name, condition)
+	if errors.IsConflict(err) {
+		recorder.Warningf("ManagedClusterAddOnStatusUpdateConflict",
+			"failed to update managed cluster addon %q available condition after %d attempts: %v", addOn.Name, attempts, err)
+		return nil
+	}
+	if errors.IsUnauthorized(err) || errors.IsForbidden(err) {
+		// the addon client loses its authorization, pause the updates rather than requeueing the addon with the
+		// rate limiter, and only emit the event once until an update succeeds.
+		if c.statusUpdateBackoff.start(c.clock.Now()) {
+			recorder.Warningf("AddOnStatusUpdateUnauthorized",
+				"failed to update managed cluster addon %q available condition, the status updates are paused for %s: %v",
+				addOn.Name, statusUpdateUnauthorizedBackoff, err)
+		}
+		c.syncCtx.Queue().AddAfter(queueKey, statusUpdateUnauthorizedBackoff)
+		return nil
+	}
+	if err != nil && backupHubsUpdated > 0 {
+		// the update succeeds as long as the condition is updated on one of the hubs
+		klog.Warningf("Failed to update managed cluster addon %q available condition, it is updated on %d backup hubs: %v",
+			addOn.Name, backupHubsUpdated, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.statusUpdateBackoff.reset()
+	if updated {
+		c.recordStatusUpdated(addOn, leaseNamespace, condition, recorder)
+		// the status has been updated, the failure of the annotation is not retried
+		if len(c.statusWriterID) != 0 {
+			if err := c.annotateStatusWriter(ctx, addOn.Name); err != nil {
+				klog.Warningf("Failed to annotate addon %q with the status writer %q: %v", addOn.Name, c.statusWriterID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordStatusUpdated records the metrics, event and cloudevent of an addon available condition update, the addon
+// is the one before the update.
+func (c *managedClusterAddOnLeaseController) recordStatusUpdated(addOn *addonv1alpha1.ManagedClusterAddOn,
+	leaseNamespace string, condition metav1.Condition, recorder events.Recorder) {
+	addOnLeaseStatusTransitions.WithLabelValues(c.clusterName, addOn.Name, string(condition.Status)).Inc()
+	recorder.Eventf("ManagedClusterAddOnStatusUpdated",
+		"update managed cluster addon %q available condition to %q with its lease %q/%q status",
+		addOn.Name, condition.Status, leaseNamespace, addOn.Name)
+
+	var oldStatus metav1.ConditionStatus
+	if oldCondition := meta.FindStatusCondition(addOn.Status.Conditions, c.conditionType); oldCondition != nil {
+		oldStatus = oldCondition.Status
+	}
+	c.recoveryTracker.record(c.clusterName, addOn.Name, oldStatus, condition.Status)
+	c.publishAvailabilityChange(addOn.Name, oldStatus, condition.Status)
+	c.auditAvailabilityChange(addOn.Name, oldStatus, condition.Status)
+	c.writtenConditions.set(addOn.Name, condition)
+	if oldStatus != condition.Status {
+		c.enqueueDependents(addOn.Name)
+		c.enqueueAggregate(c.syncCtx)
+	}
+}
+
+// recordStatusObserved records the metrics and event of an addon available condition change observed in observe only
+// mode. The cloudevent and audit log are not recorded, since the condition is not updated on the hub cluster.
+func (c *managedClusterAddOnLeaseController) recordStatusObserved(addOnName, leaseNamespace string,
+	oldStatus metav1.ConditionStatus, condition metav1.Condition, recorder events.Recorder) {
+	addOnLeaseStatusTransitions.WithLabelValues(c.clusterName, addOnName, string(condition.Status)).Inc()
+	recorder.Eventf("ManagedClusterAddOnStatusObserved",
+		"observe managed cluster addon %q available condition %q with its lease %q/%q status, "+
+			"the condition is not updated in observe only mode", addOnName, condition.Status, leaseNamespace, addOnName)
+	c.recoveryTracker.record(c.clusterName, addOnName, oldStatus, condition.Status)
+}
+
+// isConditionUnchanged returns true if setting the condition does not change the last condition.
+func isConditionUnchanged(lastCondition, condition metav1.Condition) bool {
+	return lastCondition.Status == condition.Status && lastCondition.Reason == condition.Reason &&
+		lastCondition.Message == condition.Message && lastCondition.ObservedGeneration == condition.ObservedGeneration
+}
+
+// flushPendingStatusUpdates updates the pending addon available conditions on the hub cluster. The
+// failed updates are kept and will be retried in the next flush.
+func (c *managedClusterAddOnLeaseController) flushPendingStatusUpdates(ctx context.Context, recorder events.Recorder) error {
+	var errs []error
+	for addOnName, update := range c.pendingStatusUpdates.drain() {
+		addOn, err := c.addOnLister.ManagedClusterAddOns(c.clusterName).Get(addOnName)
+		if errors.IsNotFound(err) {
+			// addon is not found, could be deleted, ignore it.
+			continue
+		}
+		if err == nil {
+			err = c.updateAvailableCondition(ctx, addOn, update.leaseNamespace, update.condition, recorder)
+		}
+		if err != nil {
+			c.pendingStatusUpdates.restore(addOnName, update)
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// drainSyncContext is the sync context to process a queue key remaining in the queue after the controller stops
+type drainSyncContext struct {
+	factory.SyncContext
+	queueKey string
+}
+
+func (d drainSyncContext) QueueKey() string { return d.queueKey }
+
+// Run runs the controller until the context is done, then drains the controller queue, so that the addons
+// remaining in the queue are checked and the pending status updates are applied before the controller returns.
+// The addons are checked in one pass before the controller starts if the InitialSyncTimeout of the options is set.
+// The controller runs with the Workers of the options if it is greater than the given workers, and a summary of the
+// addons is logged periodically if the StatusSummaryInterval of the options is set. The status updates are suppressed
+// within the StartupWarmupWindow of the options once the controller starts.
+func (c *managedClusterAddOnLeaseController) Run(ctx context.Context, workers int) {
+	if workers < c.workers {
+		workers = c.workers
+	}
+	c.initialSync(ctx, c.initialSyncTimeout)
+	c.startWarmup()
+	if c.watchdog != nil {
+		go c.watchdog.run(ctx)
+	}
+	if c.statusSummary != nil {
+		go c.statusSummary.run(ctx)
+	}
+	c.Controller.Run(ctx, workers)
+	c.drain(c.shutdownTimeout)
+}
+
+// drain processes the addons remaining in the controller queue and flushes the pending status updates until
+// the timeout is exceeded.
+func (c *managedClusterAddOnLeaseController) drain(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	queue := c.syncCtx.Queue()
+	for queue.Len() > 0 && ctx.Err() == nil {
+		key, _ := queue.Get()
+		queueKey, ok := key.(string)
+		// the full resync is skipped since it enqueues all of the addons again
+		if ok && queueKey != factory.DefaultQueueKey && queueKey != flushStatusQueueKey {
+			if err := c.sync(ctx, drainSyncContext{SyncContext: c.syncCtx, queueKey: queueKey}); err != nil {
+				klog.Warningf("Failed to sync addon %q on shutdown: %v", queueKey, err)
+			}
+		}
+		queue.Done(key)
+	}
+
+	if err := c.flushPendingStatusUpdates(ctx, c.syncCtx.Recorder()); err != nil {
+		klog.Warningf("Failed to flush the pending addon status updates on shutdown: %v", err)
+	}
+}
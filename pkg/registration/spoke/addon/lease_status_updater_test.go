@@ -0,0 +1,299 @@
+package addon
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/component-base/metrics/testutil"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestBatchStatusUpdates(t *testing.T) {
+	addOnCount := 3
+	addOns := []runtime.Object{}
+	leases := []runtime.Object{}
+	for i := 0; i < addOnCount; i++ {
+		name := fmt.Sprintf("test%d", i)
+		addOns = append(addOns, testinghelpers.NewFakeAddOn(name, "test"))
+		leases = append(leases, testinghelpers.NewAddOnLease("test", name, time.Now()))
+	}
+
+	ctrl, addOnClient := newTestLeaseController(t, addOns, leases)
+	ctrl.statusUpdateBatchInterval = time.Minute
+	ctrl.pendingStatusUpdates = newPendingStatusUpdates()
+
+	// sync each addon twice within the batch interval, no status is updated
+	for round := 0; round < 2; round++ {
+		for i := 0; i < addOnCount; i++ {
+			syncCtx := testingcommon.NewFakeSyncContext(t, fmt.Sprintf("test/test%d", i))
+			if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+		}
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+
+	// flush the pending updates, each addon is updated once
+	syncCtx := testingcommon.NewFakeSyncContext(t, flushStatusQueueKey)
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	actions := addOnClient.Actions()
+	testingcommon.AssertActions(t, actions, "patch", "patch", "patch")
+	for _, action := range actions {
+		assertAvailableCondition(t, action, metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+	}
+
+	// nothing to flush
+	addOnClient.ClearActions()
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+}
+
+func TestPendingStatusUpdates(t *testing.T) {
+	updates := newPendingStatusUpdates()
+	updates.add("test", pendingStatusUpdate{condition: metav1.Condition{Status: metav1.ConditionFalse}})
+	updates.add("test", pendingStatusUpdate{condition: metav1.Condition{Status: metav1.ConditionTrue}})
+	updates.restore("test", pendingStatusUpdate{condition: metav1.Condition{Status: metav1.ConditionUnknown}})
+
+	drained := updates.drain()
+	if len(drained) != 1 {
+		t.Errorf("expected one pending update, but got %d", len(drained))
+	}
+	if drained["test"].condition.Status != metav1.ConditionTrue {
+		t.Errorf("expected the latest update is kept, but got %v", drained["test"])
+	}
+	if len(updates.drain()) != 0 {
+		t.Errorf("expected no pending update after drain")
+	}
+}
+
+func TestUpdateAvailableConditionOnConflict(t *testing.T) {
+	cases := []struct {
+		name            string
+		conflicts       int
+		expectedActions []string
+		expectedEvent   bool
+	}{
+		{
+			name:            "no conflict",
+			expectedActions: []string{"patch"},
+		},
+		{
+			name:            "succeed after retry",
+			conflicts:       1,
+			expectedActions: []string{"patch", "get", "patch"},
+		},
+		{
+			name:            "conflict persists",
+			conflicts:       10,
+			expectedActions: []string{"patch", "get", "patch", "get", "patch", "get", "patch"},
+			expectedEvent:   true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+			conflicts := 0
+			addOnClient.PrependReactor("patch", "managedclusteraddons",
+				func(action clienttesting.Action) (bool, runtime.Object, error) {
+					if conflicts >= c.conflicts {
+						return false, nil, nil
+					}
+					conflicts++
+					return true, nil, errors.NewConflict(
+						addonv1alpha1.Resource("managedclusteraddons"), "test", fmt.Errorf("conflict"))
+				})
+
+			recorder := events.NewInMemoryRecorder("test")
+			condition := metav1.Condition{
+				Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+				Status: metav1.ConditionTrue,
+				Reason: "ManagedClusterAddOnLeaseUpdated",
+			}
+			if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			testingcommon.AssertActions(t, addOnClient.Actions(), c.expectedActions...)
+
+			hasConflictEvent := false
+			for _, event := range recorder.Events() {
+				if event.Reason == "ManagedClusterAddOnStatusUpdateConflict" {
+					hasConflictEvent = true
+				}
+			}
+			if hasConflictEvent != c.expectedEvent {
+				t.Errorf("expected conflict event %v, but got %v", c.expectedEvent, hasConflictEvent)
+			}
+		})
+	}
+}
+
+func TestDrainOnShutdown(t *testing.T) {
+	addOns := []runtime.Object{
+		testinghelpers.NewFakeAddOn("test1", "test"),
+		testinghelpers.NewFakeAddOn("test2", "test"),
+	}
+	leases := []runtime.Object{
+		testinghelpers.NewAddOnLease("test", "test1", time.Now()),
+		testinghelpers.NewAddOnLease("test", "test2", time.Now()),
+	}
+	ctrl, addOnClient := newTestLeaseController(t, addOns, leases)
+	ctrl.statusUpdateBatchInterval = time.Minute
+	ctrl.pendingStatusUpdates = newPendingStatusUpdates()
+
+	// the addons remaining in the queue after the queue is shut down are checked
+	ctrl.syncCtx.Queue().Add("test/test1")
+	ctrl.syncCtx.Queue().Add("test/test2")
+	ctrl.syncCtx.Queue().Add(factory.DefaultQueueKey)
+	ctrl.syncCtx.Queue().ShutDown()
+
+	ctrl.drain(time.Second)
+	if ctrl.syncCtx.Queue().Len() != 0 {
+		t.Errorf("expected the queue is drained, but got %d", ctrl.syncCtx.Queue().Len())
+	}
+	testingcommon.AssertActions(t, addOnClient.Actions(), "patch", "patch")
+}
+
+func TestUpdateAvailableConditionInObserveOnlyMode(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+	ctrl.observeOnly = true
+
+	recorder := events.NewInMemoryRecorder("test")
+	condition := metav1.Condition{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionTrue,
+		Reason: "ManagedClusterAddOnLeaseUpdated",
+	}
+	counter := addOnLeaseStatusTransitions.WithLabelValues(testinghelpers.TestManagedClusterName, "test",
+		string(metav1.ConditionTrue))
+	before, err := testutil.GetCounterMetricValue(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the unchanged condition is only reported once since the addon status is never updated
+	for i := 0; i < 3; i++ {
+		if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+	if len(recorder.Events()) != 1 || recorder.Events()[0].Reason != "ManagedClusterAddOnStatusObserved" {
+		t.Errorf("expected one status observed event, but got %v", recorder.Events())
+	}
+	after, err := testutil.GetCounterMetricValue(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after-before != 1 {
+		t.Errorf("expected the transition is counted once, but got %v", after-before)
+	}
+
+	// the change of the condition is reported again
+	condition.Status = metav1.ConditionFalse
+	condition.Reason = "ManagedClusterAddOnLeaseUpdateStopped"
+	if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if len(recorder.Events()) != 2 || recorder.Events()[1].Reason != "ManagedClusterAddOnStatusObserved" {
+		t.Errorf("expected two status observed events, but got %v", recorder.Events())
+	}
+
+	// the condition in the addon status is not reported
+	observedAddOn := testinghelpers.NewFakeAddOn("observed", "test")
+	observedAddOn.Status.Conditions = []metav1.Condition{condition}
+	if err := ctrl.updateAvailableCondition(context.TODO(), observedAddOn, "test", condition, recorder); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if len(recorder.Events()) != 2 {
+		t.Errorf("expected no event of the unchanged addon status, but got %v", recorder.Events())
+	}
+}
+
+func TestUpdateAvailableConditionUnauthorized(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+	forbidden := true
+	addOnClient.PrependReactor("patch", "managedclusteraddons",
+		func(action clienttesting.Action) (bool, runtime.Object, error) {
+			if !forbidden {
+				return false, nil, nil
+			}
+			return true, nil, errors.NewForbidden(
+				addonv1alpha1.Resource("managedclusteraddons"), "test", fmt.Errorf("forbidden"))
+		})
+
+	recorder := events.NewInMemoryRecorder("test")
+	condition := metav1.Condition{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionTrue,
+		Reason: "ManagedClusterAddOnLeaseUpdated",
+	}
+	countUnauthorizedEvents := func() int {
+		count := 0
+		for _, event := range recorder.Events() {
+			if event.Reason == "AddOnStatusUpdateUnauthorized" {
+				count++
+			}
+		}
+		return count
+	}
+
+	// the forbidden error is not returned to requeue the addon with the rate limiter
+	if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertActions(t, addOnClient.Actions(), "patch")
+	if count := countUnauthorizedEvents(); count != 1 {
+		t.Errorf("expected 1 unauthorized event, but got %d", count)
+	}
+
+	// the update is skipped within the backoff
+	addOnClient.ClearActions()
+	if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+
+	// the update is retried after the backoff, no more event is emitted
+	fakeClock := ctrl.clock.(*clocktesting.FakeClock)
+	fakeClock.Step(statusUpdateUnauthorizedBackoff + time.Second)
+	if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertActions(t, addOnClient.Actions(), "patch")
+	if count := countUnauthorizedEvents(); count != 1 {
+		t.Errorf("expected 1 unauthorized event, but got %d", count)
+	}
+
+	// the updates are resumed once the authorization is recovered
+	addOnClient.ClearActions()
+	forbidden = false
+	fakeClock.Step(statusUpdateUnauthorizedBackoff + time.Second)
+	if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertActions(t, addOnClient.Actions(), "patch")
+	if remaining := ctrl.statusUpdateBackoff.remaining(fakeClock.Now()); remaining > 0 {
+		t.Errorf("expected the status updates are resumed, but remaining %v", remaining)
+	}
+}
@@ -0,0 +1,35 @@
+package addon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// statusWriterAnnotation is the annotation of an addon recording the controller which updated the status of the
+// addon last time and when, the value is in the format of <controller id>@<RFC3339 timestamp>.
+const statusWriterAnnotation = "addon.open-cluster-management.io/status-writer"
+
+// annotateStatusWriter records the identity of the controller on the addon once its status is updated, so that
+// multiple controllers fighting over the same addon can be diagnosed. The annotation is patched without the
+// resource version since it is always overwritten by the last writer.
+func (c *managedClusterAddOnLeaseController) annotateStatusWriter(ctx context.Context, addOnName string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				statusWriterAnnotation: fmt.Sprintf("%s@%s", c.statusWriterID, c.clock.Now().UTC().Format(time.RFC3339)),
+			},
+		},
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.addOnClient.Patch(ctx, addOnName, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	return err
+}
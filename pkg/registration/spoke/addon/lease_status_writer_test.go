@@ -0,0 +1,68 @@
+package addon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithStatusWriterID(t *testing.T) {
+	cases := []struct {
+		name            string
+		statusWriterID  string
+		expectedActions []string
+	}{
+		{
+			name:            "status writer is not annotated",
+			expectedActions: []string{"patch"},
+		},
+		{
+			name:            "status writer is annotated",
+			statusWriterID:  "agent-1",
+			expectedActions: []string{"patch", "patch"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl, addOnClient := newTestLeaseController(t,
+				[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+			ctrl.statusWriterID = c.statusWriterID
+
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			actions := addOnClient.Actions()
+			testingcommon.AssertActions(t, actions, c.expectedActions...)
+			assertAvailableCondition(t, actions[0], metav1.ConditionTrue, "ManagedClusterAddOnLeaseUpdated")
+			if len(c.statusWriterID) == 0 {
+				return
+			}
+
+			patch := actions[1].(clienttesting.PatchAction).GetPatch()
+			addOn := &addonv1alpha1.ManagedClusterAddOn{}
+			if err := json.Unmarshal(patch, addOn); err != nil {
+				t.Fatal(err)
+			}
+			expected := fmt.Sprintf("%s@%s", c.statusWriterID, ctrl.clock.Now().UTC().Format(time.RFC3339))
+			if addOn.Annotations[statusWriterAnnotation] != expected {
+				t.Errorf("expected status writer %q, but got %q", expected, addOn.Annotations[statusWriterAnnotation])
+			}
+			if len(addOn.ResourceVersion) != 0 {
+				t.Errorf("expected the status writer is patched without resource version, but got %q", addOn.ResourceVersion)
+			}
+		})
+	}
+}
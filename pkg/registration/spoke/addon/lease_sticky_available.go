@@ -0,0 +1,60 @@
+package addon
+
+import (
+	"sync"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// leaseStickyAvailableAnnotation is the annotation of an addon to keep the addon available once it is available, for
+// the addons whose transient gaps of the lease renewal are expected. The stale lease of such an addon is reported by
+// a warning event and metric rather than turning the addon unavailable, until the annotation is removed.
+const leaseStickyAvailableAnnotation = "addon.open-cluster-management.io/lease-sticky-available"
+
+// stickyAvailableAddOns records the addons whose stale lease has been reported
+type stickyAvailableAddOns struct {
+	lock   sync.Mutex
+	addOns sets.Set[string]
+}
+
+// holdStickyAvailable returns true if the addon is available and the observed condition which turns it unavailable
+// is held since the addon is sticky available. A warning event is emitted and the metric is incremented the first
+// time the addon is held, and again after the addon is checked available. The condition forced by the annotation
+// addon.open-cluster-management.io/force-status is not held.
+func (c *managedClusterAddOnLeaseController) holdStickyAvailable(addOn *addonv1alpha1.ManagedClusterAddOn,
+	condition metav1.Condition, recorder events.Recorder) bool {
+	c.stickyAvailableAddOns.lock.Lock()
+	defer c.stickyAvailableAddOns.lock.Unlock()
+
+	_, forced := getForcedAvailableCondition(c.conditionType, addOn)
+	existing := meta.FindStatusCondition(addOn.Status.Conditions, c.conditionType)
+	if addOn.Annotations[leaseStickyAvailableAnnotation] != "true" || forced ||
+		condition.Status == metav1.ConditionTrue || existing == nil || existing.Status != metav1.ConditionTrue {
+		c.stickyAvailableAddOns.addOns.Delete(addOn.Name)
+		return false
+	}
+
+	if c.stickyAvailableAddOns.addOns == nil {
+		c.stickyAvailableAddOns.addOns = sets.New[string]()
+	}
+	if !c.stickyAvailableAddOns.addOns.Has(addOn.Name) {
+		c.stickyAvailableAddOns.addOns.Insert(addOn.Name)
+		addOnStickyAvailableHolds.WithLabelValues(c.clusterName, addOn.Name).Inc()
+		recorder.Warningf("ManagedClusterAddOnLeaseStaleSticky",
+			"Addon %s on managed cluster %s is kept available by the annotation %s, though its condition is %s: %s",
+			addOn.Name, c.clusterName, leaseStickyAvailableAnnotation, condition.Reason, condition.Message)
+	}
+	return true
+}
+
+// forgetStickyAvailable removes the addon from the held addons
+func (c *managedClusterAddOnLeaseController) forgetStickyAvailable(addOnName string) {
+	c.stickyAvailableAddOns.lock.Lock()
+	defer c.stickyAvailableAddOns.lock.Unlock()
+	c.stickyAvailableAddOns.addOns.Delete(addOnName)
+}
@@ -0,0 +1,138 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/component-base/metrics/testutil"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWithStickyAvailable(t *testing.T) {
+	cases := []struct {
+		name            string
+		annotations     map[string]string
+		existingStatus  metav1.ConditionStatus
+		expectedActions []string
+		expectedHolds   float64
+	}{
+		{
+			name:            "available addon is turned unavailable",
+			existingStatus:  metav1.ConditionTrue,
+			expectedActions: []string{"patch"},
+		},
+		{
+			name:           "sticky available addon is kept available",
+			annotations:    map[string]string{leaseStickyAvailableAnnotation: "true"},
+			existingStatus: metav1.ConditionTrue,
+			expectedHolds:  1,
+		},
+		{
+			name:            "sticky available addon is turned unavailable once the annotation is cleared",
+			annotations:     map[string]string{leaseStickyAvailableAnnotation: "false"},
+			existingStatus:  metav1.ConditionTrue,
+			expectedActions: []string{"patch"},
+		},
+		{
+			name: "sticky available addon is turned unavailable by the forced status",
+			annotations: map[string]string{
+				leaseStickyAvailableAnnotation: "true",
+				forceStatusAnnotation:          forceStatusUnavailable,
+			},
+			existingStatus:  metav1.ConditionTrue,
+			expectedActions: []string{"patch"},
+		},
+		{
+			name:            "unknown sticky available addon is not kept available",
+			annotations:     map[string]string{leaseStickyAvailableAnnotation: "true"},
+			existingStatus:  metav1.ConditionUnknown,
+			expectedActions: []string{"patch"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			addOn.Annotations = c.annotations
+			addOn.Status.Conditions = []metav1.Condition{{
+				Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+				Status: c.existingStatus,
+				Reason: "ManagedClusterAddOnLeaseUpdated",
+			}}
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now().Add(-10*time.Minute))})
+			addOnStickyAvailableHolds.DeleteLabelValues(testinghelpers.TestManagedClusterName, "test")
+
+			// the stale lease is reported only once
+			for i := 0; i < 2; i++ {
+				if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+					t.Errorf("unexpected err: %v", err)
+				}
+			}
+			actions := addOnClient.Actions()
+			if len(c.expectedActions) == 0 {
+				testingcommon.AssertNoActions(t, actions)
+			} else {
+				// the addon in the cache is not updated, so it is patched in each sync
+				testingcommon.AssertActions(t, actions, "patch", "patch")
+			}
+
+			holds, err := testutil.GetCounterMetricValue(
+				addOnStickyAvailableHolds.WithLabelValues(testinghelpers.TestManagedClusterName, "test"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if holds != c.expectedHolds {
+				t.Errorf("expected %v holds, but got %v", c.expectedHolds, holds)
+			}
+		})
+	}
+}
+
+func TestHoldStickyAvailable(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	addOn.Annotations = map[string]string{leaseStickyAvailableAnnotation: "true"}
+	addOn.Status.Conditions = []metav1.Condition{{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionTrue,
+		Reason: "ManagedClusterAddOnLeaseUpdated",
+	}}
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+
+	recorder := events.NewInMemoryRecorder("test")
+	stale := metav1.Condition{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionFalse,
+		Reason: "ManagedClusterAddOnLeaseUpdateStopped",
+	}
+	fresh := metav1.Condition{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionTrue,
+		Reason: "ManagedClusterAddOnLeaseUpdated",
+	}
+
+	// the event is emitted once for the stale lease, and again once the lease is stale after it is renewed
+	for i, condition := range []metav1.Condition{stale, stale, fresh, stale} {
+		held := ctrl.holdStickyAvailable(addOn, condition, recorder)
+		if expected := condition.Status != metav1.ConditionTrue; held != expected {
+			t.Errorf("expected held %v of condition %d, but got %v", expected, i, held)
+		}
+	}
+	staleEvents := 0
+	for _, event := range recorder.Events() {
+		if event.Reason == "ManagedClusterAddOnLeaseStaleSticky" {
+			staleEvents++
+		}
+	}
+	if staleEvents != 2 {
+		t.Errorf("expected 2 stale events, but got %d", staleEvents)
+	}
+}
@@ -0,0 +1,41 @@
+package addon
+
+import (
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// leaseSuspendAnnotation is the annotation of an addon to suspend the update of its available condition, e.g.
+// during the maintenance of the addon agent, the last known condition of the addon is kept until the annotation
+// is removed.
+const leaseSuspendAnnotation = "addon.open-cluster-management.io/lease-suspend"
+
+// isLeaseSuspended returns true if the update of the available condition of the addon is suspended, an event is
+// emitted the first time the addon is found suspended. The addon is forgotten once it is resumed, so that the event
+// is emitted again if the addon is suspended afterwards.
+func (c *managedClusterAddOnLeaseController) isLeaseSuspended(addOn *addonv1alpha1.ManagedClusterAddOn,
+	recorder events.Recorder) bool {
+	c.suspendedAddOnsLock.Lock()
+	defer c.suspendedAddOnsLock.Unlock()
+
+	if addOn.Annotations[leaseSuspendAnnotation] != "true" {
+		c.suspendedAddOns.Delete(addOn.Name)
+		return false
+	}
+
+	if !c.suspendedAddOns.Has(addOn.Name) {
+		c.suspendedAddOns.Insert(addOn.Name)
+		recorder.Eventf("ManagedClusterAddOnLeaseSuspended",
+			"The available condition of addon %s on managed cluster %s is suspended by the annotation %s",
+			addOn.Name, c.clusterName, leaseSuspendAnnotation)
+	}
+	return true
+}
+
+// forgetLeaseSuspended removes the addon from the suspended addons
+func (c *managedClusterAddOnLeaseController) forgetLeaseSuspended(addOnName string) {
+	c.suspendedAddOnsLock.Lock()
+	defer c.suspendedAddOnsLock.Unlock()
+	c.suspendedAddOns.Delete(addOnName)
+}
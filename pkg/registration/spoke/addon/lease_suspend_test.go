@@ -0,0 +1,64 @@
+package addon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestUpdateAvailableConditionSuspended(t *testing.T) {
+	addOn := testinghelpers.NewFakeAddOn("test", "test")
+	addOn.Annotations = map[string]string{leaseSuspendAnnotation: "true"}
+	ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+
+	recorder := events.NewInMemoryRecorder("test")
+	condition := metav1.Condition{
+		Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+		Status: metav1.ConditionFalse,
+		Reason: "ManagedClusterAddOnLeaseUpdateStopped",
+	}
+
+	// the suspension event is emitted only once
+	for i := 0; i < 2; i++ {
+		if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+	testingcommon.AssertNoActions(t, addOnClient.Actions())
+	if len(recorder.Events()) != 1 || recorder.Events()[0].Reason != "ManagedClusterAddOnLeaseSuspended" {
+		t.Errorf("expected the suspension event, but got %v", recorder.Events())
+	}
+
+	// the update is resumed once the annotation is removed
+	addOn.Annotations = map[string]string{leaseSuspendAnnotation: "false"}
+	if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertActions(t, addOnClient.Actions(), "patch")
+	if ctrl.suspendedAddOns.Has(addOn.Name) {
+		t.Errorf("expected addon %q is resumed", addOn.Name)
+	}
+
+	// the suspension event is emitted again once the addon is suspended again
+	addOn.Annotations = map[string]string{leaseSuspendAnnotation: "true"}
+	if err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition, recorder); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	suspendedEvents := 0
+	for _, event := range recorder.Events() {
+		if event.Reason == "ManagedClusterAddOnLeaseSuspended" {
+			suspendedEvents++
+		}
+	}
+	if suspendedEvents != 2 {
+		t.Errorf("expected 2 suspension events, but got %d", suspendedEvents)
+	}
+}
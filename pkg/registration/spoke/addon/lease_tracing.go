@@ -0,0 +1,51 @@
+package addon
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// noopTracer is the tracer of the controller if no tracer is configured
+var noopTracer = trace.NewNoopTracerProvider().Tracer(leaseControllerName)
+
+// the decisions made by syncSingle on the available condition of an addon, the condition is deferred by the soft
+// reason debouncer, held by the sticky available annotation or within the maintenance window, batched with the
+// pending status updates, or handed to updateAvailableCondition to update.
+const (
+	syncDecisionDeferred = "Deferred"
+	syncDecisionHeld     = "Held"
+	syncDecisionBatched  = "Batched"
+	syncDecisionUpdate   = "Update"
+)
+
+// startSpan starts a span of the controller tagged with the cluster name and the given attributes
+func (c *managedClusterAddOnLeaseController) startSpan(ctx context.Context, name string,
+	attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := c.tracer
+	if tracer == nil {
+		tracer = noopTracer
+	}
+	return tracer.Start(ctx, name,
+		trace.WithAttributes(append([]attribute.KeyValue{attribute.String("cluster", c.clusterName)}, attributes...)...))
+}
+
+// setConditionAttributes tags the span with the computed available condition of an addon
+func setConditionAttributes(span trace.Span, condition metav1.Condition) {
+	span.SetAttributes(
+		attribute.String("condition.status", string(condition.Status)),
+		attribute.String("condition.reason", condition.Reason),
+	)
+}
+
+// endSpan ends the span with the error of the traced operation
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
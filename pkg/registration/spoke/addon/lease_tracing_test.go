@@ -0,0 +1,86 @@
+package addon
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+// spanRecorder keeps the ended spans in memory
+type spanRecorder struct {
+	lock  sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (r *spanRecorder) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(_ context.Context) error { return nil }
+
+func (r *spanRecorder) attributes(name string) map[attribute.Key]string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, span := range r.spans {
+		if span.Name() != name {
+			continue
+		}
+		attributes := map[attribute.Key]string{}
+		for _, kv := range span.Attributes() {
+			attributes[kv.Key] = kv.Value.Emit()
+		}
+		return attributes
+	}
+	return nil
+}
+
+func TestSyncWithTracer(t *testing.T) {
+	ctrl, addOnClient := newTestLeaseController(t,
+		[]runtime.Object{testinghelpers.NewFakeAddOn("test", "test")},
+		[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now().Add(-10*time.Minute))})
+
+	recorder := &spanRecorder{}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(recorder))
+	defer func() {
+		_ = provider.Shutdown(context.TODO())
+	}()
+	ctrl.tracer = provider.Tracer("test")
+
+	syncCtx := testingcommon.NewFakeSyncContext(t, "test/test")
+	if err := ctrl.sync(context.TODO(), syncCtx); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	testingcommon.AssertActions(t, addOnClient.Actions(), "patch")
+
+	syncAttributes := recorder.attributes("sync")
+	if syncAttributes["cluster"] != testinghelpers.TestManagedClusterName || syncAttributes["queueKey"] != "test/test" {
+		t.Errorf("unexpected attributes of the sync span: %v", syncAttributes)
+	}
+
+	expected := map[attribute.Key]string{
+		"cluster":          testinghelpers.TestManagedClusterName,
+		"addon":            "test",
+		"leaseNamespace":   "test",
+		"condition.status": "False",
+		"condition.reason": "ManagedClusterAddOnLeaseUpdateStopped",
+		"decision":         syncDecisionUpdate,
+	}
+	syncSingleAttributes := recorder.attributes("syncSingle")
+	for key, value := range expected {
+		if syncSingleAttributes[key] != value {
+			t.Errorf("expected attribute %q of the syncSingle span to be %q, but got %q", key, value,
+				syncSingleAttributes[key])
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package addon
+
+import (
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// addOnDeletionHandler returns the handler of the addon deletion events, once an addon managed by the controller is
+// deleted, an event is emitted for auditing and the state and metrics of the addon are cleaned up.
+func (c *managedClusterAddOnLeaseController) addOnDeletionHandler(recorder events.Recorder) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			var addOn *addonv1alpha1.ManagedClusterAddOn
+			switch t := obj.(type) {
+			case *addonv1alpha1.ManagedClusterAddOn:
+				addOn = t
+			case cache.DeletedFinalStateUnknown:
+				var ok bool
+				addOn, ok = t.Obj.(*addonv1alpha1.ManagedClusterAddOn)
+				if !ok {
+					utilruntime.HandleError(fmt.Errorf("error to get object: %v", obj))
+					return
+				}
+			default:
+				utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+				return
+			}
+
+			if !c.addOnSelector.Matches(labels.Set(addOn.Labels)) {
+				return
+			}
+
+			c.forgetAddOn(addOn.Name)
+			addOnLeaseUnmanaged.WithLabelValues(c.clusterName).Inc()
+			recorder.Eventf("ManagedClusterAddOnUnmanaged",
+				"managed cluster addon %q is deleted and no longer managed by the addon lease controller", addOn.Name)
+		},
+	}
+}
+
+// forgetAddOn cleans up the state and metrics of an addon which is no longer managed by the controller.
+func (c *managedClusterAddOnLeaseController) forgetAddOn(addOnName string) {
+	c.forgetLeaseEstablished(addOnName)
+	c.forgetLeaseSuspended(addOnName)
+	c.forgetStickyAvailable(addOnName)
+	c.forgetStaleAgentVersion(addOnName)
+	c.writtenConditions.forget(addOnName)
+	c.observedConditions.forget(addOnName)
+	if c.softReasonDebouncer != nil {
+		c.softReasonDebouncer.forget(addOnName)
+	}
+	if c.durationValidator != nil {
+		c.durationValidator.forget(addOnName)
+	}
+	if c.holderChurnDetector != nil {
+		c.holderChurnDetector.forget(addOnName)
+	}
+	if c.statusSummary != nil {
+		c.statusSummary.forget(addOnName)
+	}
+	addOnLeaseDurationMismatch.DeleteLabelValues(c.clusterName, addOnName)
+	c.observedLeases.remove(addOnName)
+	c.recoveryTracker.forget(addOnName)
+	addOnRecoveries.DeleteLabelValues(c.clusterName, addOnName)
+	addOnStickyAvailableHolds.DeleteLabelValues(c.clusterName, addOnName)
+	addOnLeaseAge.DeleteLabelValues(c.clusterName, addOnName)
+	addOnLeaseRenewalInterval.DeleteLabelValues(c.clusterName, addOnName)
+	for _, status := range []metav1.ConditionStatus{metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionUnknown} {
+		addOnLeaseStatusTransitions.DeleteLabelValues(c.clusterName, addOnName, string(status))
+		addOnCurrentStateDuration.DeleteLabelValues(c.clusterName, addOnName, string(status))
+	}
+}
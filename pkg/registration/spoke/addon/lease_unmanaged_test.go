@@ -0,0 +1,105 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/testutil"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestAddOnDeletionHandler(t *testing.T) {
+	cases := []struct {
+		name          string
+		obj           func(addOn *addonv1alpha1.ManagedClusterAddOn) interface{}
+		addOnSelector labels.Selector
+		expectedEvent bool
+	}{
+		{
+			name: "addon is deleted",
+			obj: func(addOn *addonv1alpha1.ManagedClusterAddOn) interface{} {
+				return addOn
+			},
+			expectedEvent: true,
+		},
+		{
+			name: "addon is deleted with a tombstone",
+			obj: func(addOn *addonv1alpha1.ManagedClusterAddOn) interface{} {
+				return cache.DeletedFinalStateUnknown{Key: "cluster1/test", Obj: addOn}
+			},
+			expectedEvent: true,
+		},
+		{
+			name: "tombstone with an unexpected object",
+			obj: func(_ *addonv1alpha1.ManagedClusterAddOn) interface{} {
+				return cache.DeletedFinalStateUnknown{Key: "cluster1/test", Obj: "test"}
+			},
+		},
+		{
+			name: "addon is not selected",
+			obj: func(addOn *addonv1alpha1.ManagedClusterAddOn) interface{} {
+				return addOn
+			},
+			addOnSelector: labels.SelectorFromSet(labels.Set{"shard": "a"}),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			ctrl, _ := newTestLeaseController(t, []runtime.Object{addOn},
+				[]runtime.Object{testinghelpers.NewAddOnLease("test", "test", time.Now())})
+			if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "test/test")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if c.addOnSelector != nil {
+				ctrl.addOnSelector = c.addOnSelector
+			}
+
+			counter := addOnLeaseUnmanaged.WithLabelValues(testinghelpers.TestManagedClusterName)
+			before, err := testutil.GetCounterMetricValue(counter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := events.NewInMemoryRecorder("test")
+			ctrl.addOnDeletionHandler(recorder).OnDelete(c.obj(addOn))
+
+			hasEvent := false
+			for _, event := range recorder.Events() {
+				if event.Reason == "ManagedClusterAddOnUnmanaged" {
+					hasEvent = true
+				}
+			}
+			if hasEvent != c.expectedEvent {
+				t.Errorf("expected unmanaged event %t, but got %t", c.expectedEvent, hasEvent)
+			}
+
+			after, err := testutil.GetCounterMetricValue(counter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedCount := 0.0
+			if c.expectedEvent {
+				expectedCount = 1
+			}
+			if after-before != expectedCount {
+				t.Errorf("expected the unmanaged counter is increased by %v, but got %v", expectedCount, after-before)
+			}
+
+			// the state of the unmanaged addon is cleaned up
+			if _, observed := ctrl.observedLeases.get("test"); observed == c.expectedEvent {
+				t.Errorf("expected the observed lease is removed %t, but got observed %t", c.expectedEvent, observed)
+			}
+		})
+	}
+}
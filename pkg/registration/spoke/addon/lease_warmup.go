@@ -0,0 +1,27 @@
+package addon
+
+import (
+	"time"
+)
+
+// startWarmup starts the warmup window of the controller, within which the status updates of the addons are
+// suppressed while the caches warm up.
+func (c *managedClusterAddOnLeaseController) startWarmup() {
+	if c.warmupWindow <= 0 {
+		return
+	}
+	c.warmupDeadline = c.clock.Now().Add(c.warmupWindow)
+}
+
+// warmupRemaining returns the remaining duration of the warmup window, 0 is returned if the controller is not
+// warming up.
+func (c *managedClusterAddOnLeaseController) warmupRemaining() time.Duration {
+	if c.warmupDeadline.IsZero() {
+		return 0
+	}
+	remaining := c.warmupDeadline.Sub(c.clock.Now())
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
@@ -0,0 +1,68 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestUpdateAvailableConditionWithWarmup(t *testing.T) {
+	cases := []struct {
+		name         string
+		warmupWindow time.Duration
+		elapsed      time.Duration
+		expectedVerb []string
+	}{
+		{
+			name:         "warmup is disabled",
+			expectedVerb: []string{"patch"},
+		},
+		{
+			name:         "update is suppressed within the warmup window",
+			warmupWindow: time.Minute,
+			elapsed:      30 * time.Second,
+		},
+		{
+			name:         "update is written after the warmup window",
+			warmupWindow: time.Minute,
+			elapsed:      time.Minute + time.Second,
+			expectedVerb: []string{"patch"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addOn := testinghelpers.NewFakeAddOn("test", "test")
+			ctrl, addOnClient := newTestLeaseController(t, []runtime.Object{addOn}, []runtime.Object{})
+			ctrl.warmupWindow = c.warmupWindow
+			ctrl.startWarmup()
+			ctrl.clock.(*clocktesting.FakeClock).Step(c.elapsed)
+
+			condition := metav1.Condition{
+				Type:   addonv1alpha1.ManagedClusterAddOnConditionAvailable,
+				Status: metav1.ConditionTrue,
+				Reason: "ManagedClusterAddOnLeaseUpdated",
+			}
+			err := ctrl.updateAvailableCondition(context.TODO(), addOn, "test", condition,
+				events.NewInMemoryRecorder("test"))
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if len(c.expectedVerb) == 0 {
+				testingcommon.AssertNoActions(t, addOnClient.Actions())
+			} else {
+				testingcommon.AssertActions(t, addOnClient.Actions(), c.expectedVerb...)
+			}
+		})
+	}
+}
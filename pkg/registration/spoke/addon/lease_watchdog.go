@@ -0,0 +1,75 @@
+package addon
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// syncWatchdog detects the stall of the controller, e.g. a sync blocked by a hung client call, since the periodic
+// resync completes a sync at least once every resync interval.
+type syncWatchdog struct {
+	clock     clock.Clock
+	threshold time.Duration
+	// onStall is called once the controller becomes stalled, it is not called again until a sync is completed
+	onStall func(sinceLastSync time.Duration)
+
+	lock          sync.Mutex
+	lastCompleted time.Time
+	stalled       bool
+}
+
+func newSyncWatchdog(clock clock.Clock, threshold time.Duration, recorder events.Recorder) *syncWatchdog {
+	return &syncWatchdog{
+		clock:     clock,
+		threshold: threshold,
+		onStall: func(sinceLastSync time.Duration) {
+			klog.Warningf("The addon lease controller is stalled, no sync is completed for %s", sinceLastSync)
+			recorder.Warningf("ManagedClusterAddOnLeaseControllerStalled",
+				"no sync of the addon lease controller is completed for %s", sinceLastSync)
+		},
+		lastCompleted: clock.Now(),
+	}
+}
+
+// complete records the completion of a sync, the controller is not stalled any longer
+func (w *syncWatchdog) complete() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.lastCompleted = w.clock.Now()
+	w.stalled = false
+}
+
+// check marks the controller stalled and calls onStall if no sync is completed within the threshold
+func (w *syncWatchdog) check(_ context.Context) {
+	w.lock.Lock()
+	sinceLastSync := w.clock.Since(w.lastCompleted)
+	becomeStalled := sinceLastSync > w.threshold && !w.stalled
+	if becomeStalled {
+		w.stalled = true
+	}
+	w.lock.Unlock()
+
+	if becomeStalled {
+		w.onStall(sinceLastSync)
+	}
+}
+
+// isStalled returns true if no sync is completed within the threshold since the last check
+func (w *syncWatchdog) isStalled() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.stalled
+}
+
+// run checks the controller periodically until the context is done
+func (w *syncWatchdog) run(ctx context.Context) {
+	// the controller starts to sync from now on
+	w.complete()
+	wait.UntilWithContext(ctx, w.check, w.threshold/10)
+}
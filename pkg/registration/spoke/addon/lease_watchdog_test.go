@@ -0,0 +1,93 @@
+package addon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
+)
+
+func TestSyncWatchdog(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	ctrl, _ := newTestLeaseController(t, []runtime.Object{}, []runtime.Object{})
+	ctrl.clock = fakeClock
+	ctrl.watchdog = newSyncWatchdog(fakeClock, 10*time.Minute, eventstesting.NewTestingEventRecorder(t))
+
+	stalls := 0
+	ctrl.watchdog.onStall = func(_ time.Duration) {
+		stalls++
+	}
+
+	// the controller is not stalled within the threshold
+	fakeClock.Step(9 * time.Minute)
+	ctrl.watchdog.check(context.TODO())
+	if stalls != 0 || ctrl.IsStalled() {
+		t.Errorf("expected the controller is not stalled")
+	}
+
+	// a completed sync resets the watchdog
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	fakeClock.Step(9 * time.Minute)
+	ctrl.watchdog.check(context.TODO())
+	if stalls != 0 || ctrl.IsStalled() {
+		t.Errorf("expected the controller is not stalled after a sync")
+	}
+
+	// no sync is completed within the threshold
+	fakeClock.Step(2 * time.Minute)
+	ctrl.watchdog.check(context.TODO())
+	if stalls != 1 || !ctrl.IsStalled() {
+		t.Errorf("expected the controller is stalled")
+	}
+
+	// the stall is reported once until a sync is completed
+	fakeClock.Step(time.Minute)
+	ctrl.watchdog.check(context.TODO())
+	if stalls != 1 {
+		t.Errorf("expected the stall is reported once, but got %d", stalls)
+	}
+
+	// the controller recovers once a sync is completed
+	if err := ctrl.sync(context.TODO(), testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if ctrl.IsStalled() {
+		t.Errorf("expected the controller is not stalled after the recovery")
+	}
+}
+
+func TestSyncWatchdogDisabled(t *testing.T) {
+	addOnClient := addonfake.NewSimpleClientset()
+	addOnInformerFactory := addoninformers.NewSharedInformerFactory(addOnClient, time.Minute*10)
+	ctrl := NewManagedClusterAddOnLeaseController(testinghelpers.TestManagedClusterName,
+		addOnClient,
+		addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns(),
+		kubefake.NewSimpleClientset().CoordinationV1(),
+		kubefake.NewSimpleClientset().CoordinationV1(),
+		kubefake.NewSimpleClientset().CoordinationV1(),
+		time.Minute,
+		AddOnLeaseControllerOptions{},
+		events.NewInMemoryRecorder("test"),
+	).(*managedClusterAddOnLeaseController)
+
+	if ctrl.watchdog != nil {
+		t.Errorf("expected the watchdog is disabled if the threshold is not set")
+	}
+	if ctrl.IsStalled() {
+		t.Errorf("expected the controller without the watchdog is not stalled")
+	}
+}
@@ -47,6 +47,9 @@ func NewClientCertForHubController(
 	spokeSecretInformer corev1informers.SecretInformer,
 	csrControl clientcert.CSRControl,
 	csrExpirationSeconds int32,
+	signerName string,
+	rotationFraction float64,
+	csrAnnotations map[string]string,
 	spokeKubeClient kubernetes.Interface,
 	statusUpdater clientcert.StatusUpdateFunc,
 	recorder events.Recorder,
@@ -66,6 +69,11 @@ func NewClientCertForHubController(
 			clientcert.AgentNameFile:   []byte(agentName),
 			clientcert.KubeconfigFile:  kubeconfigData,
 		},
+		RotationFraction: rotationFraction,
+	}
+
+	if len(signerName) == 0 {
+		signerName = certificates.KubeAPIServerClientSignerName
 	}
 
 	var csrExpirationSecondsInCSROption *int32
@@ -79,6 +87,8 @@ func NewClientCertForHubController(
 				// the label is only an hint for cluster name. Anyone could set/modify it.
 				clusterv1.ClusterNameLabelKey: clusterName,
 			},
+			// the annotations provide the context of the csr, e.g. cluster claims, agent version, to an external approver
+			Annotations: copyAnnotations(csrAnnotations),
 		},
 		Subject: &pkix.Name{
 			Organization: []string{
@@ -87,7 +97,7 @@ func NewClientCertForHubController(
 			},
 			CommonName: fmt.Sprintf("%s%s:%s", user.SubjectPrefix, clusterName, agentName),
 		},
-		SignerName: certificates.KubeAPIServerClientSignerName,
+		SignerName: signerName,
 		EventFilterFunc: func(obj interface{}) bool {
 			accessor, err := meta.Accessor(obj)
 			if err != nil {
@@ -124,6 +134,17 @@ func NewClientCertForHubController(
 	)
 }
 
+func copyAnnotations(annotations map[string]string) map[string]string {
+	if len(annotations) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		copied[k] = v
+	}
+	return copied
+}
+
 func haltCSRCreationFunc(indexer cache.Indexer, clusterName string) func() bool {
 	return func() bool {
 		items, err := indexer.ByIndex(indexByCluster, clusterName)
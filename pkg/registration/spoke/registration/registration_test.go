@@ -1,10 +1,19 @@
 package registration
 
 import (
+	"context"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	certificates "k8s.io/api/certificates/v1"
+	"k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/registration/clientcert"
 	testinghelpers "open-cluster-management.io/ocm/pkg/registration/helpers/testing"
 )
 
@@ -47,3 +56,41 @@ func TestGetClusterAgentNamesFromCertificate(t *testing.T) {
 		})
 	}
 }
+
+func TestClientCertForHubControllerWithCSRAnnotations(t *testing.T) {
+	hubKubeClient := kubefake.NewSimpleClientset()
+	hubInformerFactory := informers.NewSharedInformerFactory(hubKubeClient, 10*time.Minute)
+	managementKubeClient := kubefake.NewSimpleClientset()
+	managementInformerFactory := informers.NewSharedInformerFactory(managementKubeClient, 10*time.Minute)
+
+	csrControl, err := clientcert.NewCSRControl(hubInformerFactory.Certificates(), hubKubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csrAnnotations := map[string]string{"example.com/agent-version": "v1.0.0"}
+	ctrl := NewClientCertForHubController(
+		testinghelpers.TestManagedClusterName, "agent1", "open-cluster-management-agent", "hub-kubeconfig-secret",
+		testinghelpers.NewKubeconfig(nil, nil),
+		managementInformerFactory.Core().V1().Secrets(),
+		csrControl,
+		0,
+		"",
+		0,
+		csrAnnotations,
+		managementKubeClient,
+		GenerateBootstrapStatusUpdater(),
+		eventstesting.NewTestingEventRecorder(t),
+		"test",
+	)
+	if err := ctrl.Sync(context.TODO(), testingcommon.NewFakeSyncContext(t, "key")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	actions := hubKubeClient.Actions()
+	testingcommon.AssertActions(t, actions, "create")
+	csr := actions[0].(clienttesting.CreateActionImpl).Object.(*certificates.CertificateSigningRequest)
+	if !reflect.DeepEqual(csr.Annotations, csrAnnotations) {
+		t.Errorf("expected annotations %v, but got %v", csrAnnotations, csr.Annotations)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path"
 	"time"
@@ -12,11 +13,16 @@ import (
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/spf13/pflag"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -64,16 +70,37 @@ type SpokeAgentOptions struct {
 	ClusterHealthCheckPeriod    time.Duration
 	MaxCustomClusterClaims      int
 	ClientCertExpirationSeconds int32
+	ClientCertSignerName        string
+	ClientCertRotationFraction  float64
+	CSRAnnotations              map[string]string
+	AddOnHealthBindAddress      string
+	AddOnLeaseRBACEnabled       bool
+	AddOnHeartbeatConfigMap     bool
+	AddOnStatusSummaryInterval  time.Duration
+	AddOnNamespaceCheckEnabled  bool
+	AddOnStatusUpdateStrategy   string
+	AddOnCollapseUnknownStatus  bool
+	AddOnStatusWarmupWindow     time.Duration
+	AddOnLeaseWatchdogThreshold time.Duration
+	AddOnAggregateCondition     bool
+	AddOnLeaseDurationDeclared  bool
+	AddOnDeploymentConfig       bool
+	AddOnLeaseCleanupEnabled    bool
+	AddOnLeaseCleanupDryRun     bool
 }
 
 // NewSpokeAgentOptions returns a SpokeAgentOptions
 func NewSpokeAgentOptions() *SpokeAgentOptions {
 	return &SpokeAgentOptions{
-		AgentOptions:             commonoptions.NewAgentOptions(),
-		HubKubeconfigSecret:      "hub-kubeconfig-secret",
-		HubKubeconfigDir:         "/spoke/hub-kubeconfig",
-		ClusterHealthCheckPeriod: 1 * time.Minute,
-		MaxCustomClusterClaims:   20,
+		AgentOptions:               commonoptions.NewAgentOptions(),
+		HubKubeconfigSecret:        "hub-kubeconfig-secret",
+		HubKubeconfigDir:           "/spoke/hub-kubeconfig",
+		ClusterHealthCheckPeriod:   1 * time.Minute,
+		MaxCustomClusterClaims:     20,
+		ClientCertSignerName:       certificatesv1.KubeAPIServerClientSignerName,
+		ClientCertRotationFraction: clientcert.DefaultClientCertRotationFraction,
+		AddOnStatusUpdateStrategy:  string(addon.StatusUpdateStrategyPatch),
+		AddOnLeaseCleanupDryRun:    true,
 	}
 }
 
@@ -216,6 +243,11 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 	// in scenario #2 and #3, which results in an error message in log: 'Observed a panic: timeout waiting for
 	// informer cache'
 	if !ok {
+		// fail fast if the bootstrap client certificate cannot be used to create the csr
+		if err := clientcert.ValidateClientCertificate(bootstrapClientConfig); err != nil {
+			return fmt.Errorf("invalid bootstrap kubeconfig %q: %w", o.BootstrapKubeconfig, err)
+		}
+
 		// create a ClientCertForHubController for spoke agent bootstrap
 		// the bootstrap informers are supposed to be terminated after completing the bootstrap process.
 		bootstrapInformerFactory := informers.NewSharedInformerFactory(bootstrapKubeClient, 10*time.Minute)
@@ -242,6 +274,9 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 			bootstrapNamespacedManagementKubeInformerFactory.Core().V1().Secrets(),
 			csrControl,
 			o.ClientCertExpirationSeconds,
+			o.ClientCertSignerName,
+			o.ClientCertRotationFraction,
+			o.CSRAnnotations,
 			managementKubeClient,
 			registration.GenerateBootstrapStatusUpdater(),
 			recorder,
@@ -331,6 +366,9 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 		namespacedManagementKubeInformerFactory.Core().V1().Secrets(),
 		csrControl,
 		o.ClientCertExpirationSeconds,
+		o.ClientCertSignerName,
+		o.ClientCertRotationFraction,
+		o.CSRAnnotations,
 		managementKubeClient,
 		registration.GenerateStatusUpdater(
 			hubClusterClient,
@@ -364,9 +402,42 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 		recorder,
 	)
 
-	var addOnLeaseController factory.Controller
+	var addOnLeaseController addon.AddOnLeaseController
+	var addOnLeaseCleanupController factory.Controller
 	var addOnRegistrationController factory.Controller
+	var addOnLeaseRBACController factory.Controller
 	if features.DefaultSpokeRegistrationMutableFeatureGate.Enabled(ocmfeature.AddonManagement) {
+		addOnLeaseControllerOptions := addon.AddOnLeaseControllerOptions{
+			LeaseDefaultsConfigMapInformer:  namespacedManagementKubeInformerFactory.Core().V1().ConfigMaps(),
+			LeaseDefaultsConfigMapNamespace: o.ComponentNamespace,
+			StatusSummaryInterval:           o.AddOnStatusSummaryInterval,
+			StatusUpdateStrategy:            addon.StatusUpdateStrategy(o.AddOnStatusUpdateStrategy),
+			CollapseUnknownStatus:           o.AddOnCollapseUnknownStatus,
+			StartupWarmupWindow:             o.AddOnStatusWarmupWindow,
+			WatchdogThreshold:               o.AddOnLeaseWatchdogThreshold,
+			UseLeaseDurationSeconds:         o.AddOnLeaseDurationDeclared,
+			ManagedClusterInformer:          hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
+		}
+		if o.AddOnHeartbeatConfigMap {
+			addOnLeaseControllerOptions.SpokeConfigMapClient = spokeKubeClient.CoreV1()
+			addOnLeaseControllerOptions.ManagementConfigMapClient = managementKubeClient.CoreV1()
+		}
+		if o.AddOnAggregateCondition {
+			addOnLeaseControllerOptions.ManagedClusterClient = hubClusterClient
+		}
+		if o.AddOnNamespaceCheckEnabled {
+			addOnLeaseControllerOptions.SpokeNamespaceInformer = spokeKubeInformerFactory.Core().V1().Namespaces()
+		}
+		if o.AddOnDeploymentConfig {
+			hubDynamicClient, err := dynamic.NewForConfig(hubClientConfig)
+			if err != nil {
+				return err
+			}
+			hubDynamicInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(hubDynamicClient, 10*time.Minute)
+			addOnLeaseControllerOptions.AddOnDeploymentConfigInformer = hubDynamicInformerFactory.ForResource(
+				addon.AddOnDeploymentConfigGVR)
+			go hubDynamicInformerFactory.Start(ctx.Done())
+		}
 		addOnLeaseController = addon.NewManagedClusterAddOnLeaseController(
 			o.AgentOptions.SpokeClusterName,
 			addOnClient,
@@ -375,9 +446,35 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 			managementKubeClient.CoordinationV1(),
 			spokeKubeClient.CoordinationV1(),
 			AddOnLeaseControllerSyncInterval, //TODO: this interval time should be allowed to change from outside
+			addOnLeaseControllerOptions,
 			recorder,
 		)
 
+		if o.AddOnLeaseCleanupEnabled {
+			addOnLeaseCleanupController = addon.NewAddOnLeaseCleanupController(
+				o.AgentOptions.SpokeClusterName,
+				addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns(),
+				managementKubeClient.CoordinationV1(),
+				spokeKubeClient.CoordinationV1(),
+				addon.AddOnLeaseCleanupOptions{
+					DryRun:                        o.AddOnLeaseCleanupDryRun,
+					FixedLeaseNamespace:           addOnLeaseControllerOptions.FixedLeaseNamespace,
+					AddOnDeploymentConfigInformer: addOnLeaseControllerOptions.AddOnDeploymentConfigInformer,
+				},
+				recorder,
+			)
+		}
+
+		if o.AddOnLeaseRBACEnabled {
+			addOnLeaseRBACController = addon.NewAddOnLeaseRBACController(
+				o.AgentOptions.SpokeClusterName,
+				addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns(),
+				managementKubeClient.RbacV1(),
+				spokeKubeClient.RbacV1(),
+				recorder,
+			)
+		}
+
 		addOnRegistrationController = addon.NewAddOnRegistrationController(
 			o.AgentOptions.SpokeClusterName,
 			o.AgentName,
@@ -404,15 +501,57 @@ func (o *SpokeAgentOptions) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 	go clientCertForHubController.Run(ctx, 1)
 	go managedClusterLeaseController.Run(ctx, 1)
 	go managedClusterHealthCheckController.Run(ctx, 1)
+	// the addon lease controller drains its queue on shutdown, wait for it to apply the pending addon status
+	addOnLeaseControllerStopped := make(chan struct{})
 	if features.DefaultSpokeRegistrationMutableFeatureGate.Enabled(ocmfeature.AddonManagement) {
-		go addOnLeaseController.Run(ctx, 1)
+		go func() {
+			defer close(addOnLeaseControllerStopped)
+			addOnLeaseController.Run(ctx, 1)
+		}()
+		if addOnLeaseCleanupController != nil {
+			go addOnLeaseCleanupController.Run(ctx, 1)
+		}
 		go addOnRegistrationController.Run(ctx, 1)
+		if addOnLeaseRBACController != nil {
+			go addOnLeaseRBACController.Run(ctx, 1)
+		}
+		if len(o.AddOnHealthBindAddress) != 0 {
+			go serveAddOnHealth(ctx, o.AddOnHealthBindAddress, addOnLeaseController)
+		}
 	}
 
 	<-ctx.Done()
+	if features.DefaultSpokeRegistrationMutableFeatureGate.Enabled(ocmfeature.AddonManagement) {
+		<-addOnLeaseControllerStopped
+	}
 	return nil
 }
 
+// serveAddOnHealth serves the addon lease health and readiness endpoints on the bind address until the context
+// is done
+func serveAddOnHealth(ctx context.Context, bindAddress string, controller addon.AddOnLeaseController) {
+	mux := http.NewServeMux()
+	mux.Handle(addon.AddOnHealthPath, controller)
+	mux.Handle(addon.AddOnReadyPath, addon.NewReadinessHandler(controller))
+	server := &http.Server{
+		Addr:              bindAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := server.Close(); err != nil {
+			klog.Errorf("failed to close the addon health server: %v", err)
+		}
+	}()
+
+	klog.Infof("Serving addon health on %s%s", bindAddress, addon.AddOnHealthPath)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		klog.Errorf("failed to serve the addon health: %v", err)
+	}
+}
+
 // AddFlags registers flags for Agent
 func (o *SpokeAgentOptions) AddFlags(fs *pflag.FlagSet) {
 	features.DefaultSpokeRegistrationMutableFeatureGate.AddFlag(fs)
@@ -432,6 +571,59 @@ func (o *SpokeAgentOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.Int32Var(&o.ClientCertExpirationSeconds, "client-cert-expiration-seconds", o.ClientCertExpirationSeconds,
 		"The requested duration in seconds of validity of the issued client certificate. If this is not set, "+
 			"the value of --cluster-signing-duration command-line flag of the kube-controller-manager will be used.")
+	fs.StringVar(&o.ClientCertSignerName, "client-cert-signer-name", o.ClientCertSignerName,
+		"The signer name of the csr requesting the hub client certificate, e.g. example.com/signer-name. A custom signer "+
+			"must be added to the flag --cluster-csr-signer-names of the hub registration controller to auto approve the csr.")
+	fs.Float64Var(&o.ClientCertRotationFraction, "client-cert-rotation-fraction", o.ClientCertRotationFraction,
+		"The fraction of the validity period of the client certificate after which the client certificate is rotated.")
+	fs.StringToStringVar(&o.CSRAnnotations, "csr-annotations", o.CSRAnnotations,
+		"The annotations added to the csr requesting the hub client certificate, e.g. cluster claims or agent version, "+
+			"so that an external approver can consume them.")
+	fs.StringVar(&o.AddOnHealthBindAddress, "addon-health-bind-address", o.AddOnHealthBindAddress,
+		"The address the addon lease health and readiness endpoints bind to, e.g. :8000. The endpoints are "+
+			"disabled if it is not set.")
+	fs.BoolVar(&o.AddOnLeaseRBACEnabled, "addon-lease-rbac", o.AddOnLeaseRBACEnabled,
+		"If true, a Role and RoleBinding granting the service accounts in the addon installation namespace the access "+
+			"to the addon lease are maintained in the addon lease namespace.")
+	fs.BoolVar(&o.AddOnHeartbeatConfigMap, "addon-heartbeat-configmap", o.AddOnHeartbeatConfigMap,
+		"If true, the heartbeat of the addons with the annotation addon.open-cluster-management.io/heartbeat-configmap "+
+			"is read from their configmaps, it requires the access to get the configmaps in the addon lease namespaces.")
+	fs.DurationVar(&o.AddOnStatusSummaryInterval, "addon-status-summary-interval", o.AddOnStatusSummaryInterval,
+		"The interval to log a summary of the addon statuses, e.g. 10m. The summary is disabled if it is not set.")
+	fs.BoolVar(&o.AddOnAggregateCondition, "addon-aggregate-condition", o.AddOnAggregateCondition,
+		"If true, the condition AllAddOnsAvailable is maintained on the managed cluster, reflecting whether all of "+
+			"the managed addons are available.")
+	fs.BoolVar(&o.AddOnNamespaceCheckEnabled, "addon-namespace-check", o.AddOnNamespaceCheckEnabled,
+		"If true, the installation namespace of an addon whose lease is not found is checked to distinguish an "+
+			"incomplete installation from a down agent, it requires the access to list and watch the namespaces.")
+	fs.StringVar(&o.AddOnStatusUpdateStrategy, "addon-status-update-strategy", o.AddOnStatusUpdateStrategy,
+		"The strategy to update the addon available conditions, Patch or ServerSideApply. The ServerSideApply "+
+			"strategy applies the conditions with a field manager of the addon lease controller to reduce the conflicts "+
+			"with the other controllers updating the addon status.")
+	fs.BoolVar(&o.AddOnCollapseUnknownStatus, "addon-collapse-unknown-status", o.AddOnCollapseUnknownStatus,
+		"If true, the unknown addon available conditions are written as false with the original reason in the "+
+			"message, for the alerting tooling which treats an unknown condition as healthy. The addons whose "+
+			"availability cannot be determined are then reported as not available.")
+	fs.DurationVar(&o.AddOnStatusWarmupWindow, "addon-status-warmup-window", o.AddOnStatusWarmupWindow,
+		"The duration since the addon lease controller starts, within which the addon status updates are suppressed "+
+			"and then the settled status is written once, e.g. 30s. The warmup is disabled if it is not set.")
+	fs.DurationVar(&o.AddOnLeaseWatchdogThreshold, "addon-lease-watchdog-threshold", o.AddOnLeaseWatchdogThreshold,
+		"The max duration since the last completed sync of the addon lease controller, e.g. 30m. Once it is exceeded, "+
+			"a warning event is emitted and the addon readiness endpoint fails until a sync is completed. The watchdog "+
+			"is disabled if it is not set.")
+	fs.BoolVar(&o.AddOnLeaseDurationDeclared, "addon-lease-duration-declared", o.AddOnLeaseDurationDeclared,
+		"If true, the grace period of an addon lease is derived from the lease duration seconds declared by the "+
+			"lease itself, the lease duration seconds of the addon is used if the lease does not declare one.")
+	fs.BoolVar(&o.AddOnLeaseCleanupEnabled, "addon-lease-cleanup", o.AddOnLeaseCleanupEnabled,
+		"If true, the lease of an addon is deleted once the addon is deleted, it requires the access to delete the "+
+			"leases.")
+	fs.BoolVar(&o.AddOnLeaseCleanupDryRun, "addon-lease-cleanup-dry-run", o.AddOnLeaseCleanupDryRun,
+		"If true, the addon lease cleanup only logs the leases that would be deleted. It takes effect only if "+
+			"--addon-lease-cleanup is true.")
+	fs.BoolVar(&o.AddOnDeploymentConfig, "addon-deployment-config", o.AddOnDeploymentConfig,
+		"If true, the installation namespace of an addon is resolved from the agentInstallNamespace of the "+
+			"AddOnDeploymentConfig referenced by the addon, it requires the access to list and watch the "+
+			"AddOnDeploymentConfigs on the hub cluster.")
 }
 
 // Validate verifies the inputs.
@@ -465,6 +657,27 @@ func (o *SpokeAgentOptions) Validate() error {
 		return errors.New("client certificate expiration seconds must greater or qual to 3600")
 	}
 
+	if o.ClientCertRotationFraction < 0 || o.ClientCertRotationFraction >= 1 {
+		return errors.New("client certificate rotation fraction must not be negative and must be less than 1")
+	}
+
+	if errs := apivalidation.ValidateAnnotations(o.CSRAnnotations, field.NewPath("csrAnnotations")); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+
+	if len(o.ClientCertSignerName) != 0 {
+		if err := clientcert.ValidateSignerName(o.ClientCertSignerName); err != nil {
+			return err
+		}
+	}
+
+	switch addon.StatusUpdateStrategy(o.AddOnStatusUpdateStrategy) {
+	case "", addon.StatusUpdateStrategyPatch, addon.StatusUpdateStrategyServerSideApply:
+	default:
+		return fmt.Errorf("addon status update strategy %q is invalid, it must be %s or %s", o.AddOnStatusUpdateStrategy,
+			addon.StatusUpdateStrategyPatch, addon.StatusUpdateStrategyServerSideApply)
+	}
+
 	return nil
 }
 
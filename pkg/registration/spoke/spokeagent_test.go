@@ -188,6 +188,32 @@ func TestValidate(t *testing.T) {
 			},
 			expectedErr: "cluster healthcheck period must greater than zero",
 		},
+		{
+			name: "invalid client cert signer name",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig: "/spoke/bootstrap/kubeconfig",
+				AgentOptions: &commonoptions.AgentOptions{
+					SpokeClusterName: "testcluster",
+				},
+				AgentName:                "testagent",
+				ClusterHealthCheckPeriod: 1 * time.Minute,
+				ClientCertSignerName:     "invalid",
+			},
+			expectedErr: "invalid signer name \"invalid\": it must be of the form <domain>/<path>",
+		},
+		{
+			name: "invalid addon status update strategy",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig: "/spoke/bootstrap/kubeconfig",
+				AgentOptions: &commonoptions.AgentOptions{
+					SpokeClusterName: "testcluster",
+				},
+				AgentName:                 "testagent",
+				ClusterHealthCheckPeriod:  1 * time.Minute,
+				AddOnStatusUpdateStrategy: "Update",
+			},
+			expectedErr: "addon status update strategy \"Update\" is invalid, it must be Patch or ServerSideApply",
+		},
 		{
 			name:        "default completed options",
 			options:     defaultCompletedOptions,